@@ -0,0 +1,129 @@
+package llog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AlertRule describes when an AlertHook should alert
+type AlertRule struct {
+	// Level is the minimum level of the entries which are counted
+	Level Level
+
+	// Threshold is the number of entries which must be exceeded within Window
+	// for an alert to happen
+	Threshold int
+
+	// Window is the period of time over which entries are counted
+	Window time.Duration
+
+	// Cooldown is the minimum time between alerts. Defaults to Window
+	Cooldown time.Duration
+}
+
+// Alert describes an AlertRule having been broken
+type Alert struct {
+	Rule AlertRule
+
+	// Count is the number of entries within the Rule's Window, including the
+	// Entry which caused the alert
+	Count int
+
+	// Entry is the entry which caused the alert
+	Entry Entry
+}
+
+// String implements the fmt.Stringer interface
+func (a Alert) String() string {
+	return fmt.Sprintf("%d %s entries within %s, most recently %q", a.Count, a.Rule.Level, a.Rule.Window, a.Entry.Msg)
+}
+
+// AlertHook is a Hook which tracks the rate of entries, and calls a function
+// whenever its AlertRule is broken, e.g. when there have been more than 10
+// errors within a minute. It's a lightweight alternative to alerting on
+// metrics, for services which don't have them.
+type AlertHook struct {
+	rule AlertRule
+	fn   func(Alert)
+
+	l         sync.Mutex
+	times     []time.Time
+	lastAlert time.Time
+}
+
+// NewAlertHook returns an AlertHook which calls the given function whenever the
+// AlertRule is broken. The function is called in its own go-routine, so it
+// doesn't block logging.
+func NewAlertHook(rule AlertRule, fn func(Alert)) *AlertHook {
+	if rule.Cooldown <= 0 {
+		rule.Cooldown = rule.Window
+	}
+	return &AlertHook{rule: rule, fn: fn}
+}
+
+// Level implements the Hook interface
+func (ah *AlertHook) Level() Level {
+	return ah.rule.Level
+}
+
+// Fire implements the Hook interface
+func (ah *AlertHook) Fire(e Entry) {
+	ah.l.Lock()
+	defer ah.l.Unlock()
+
+	// drop the times which have fallen out of the window
+	cutoff := e.Time.Add(-ah.rule.Window)
+	i := 0
+	for i < len(ah.times) && !ah.times[i].After(cutoff) {
+		i++
+	}
+	ah.times = append(ah.times[:0], ah.times[i:]...)
+	ah.times = append(ah.times, e.Time)
+
+	if len(ah.times) <= ah.rule.Threshold {
+		return
+	} else if !ah.lastAlert.IsZero() && e.Time.Sub(ah.lastAlert) < ah.rule.Cooldown {
+		return
+	}
+	ah.lastAlert = e.Time
+	a := Alert{Rule: ah.rule, Count: len(ah.times), Entry: e}
+	a.Entry.KV = e.KV.Copy()
+	go ah.fn(a)
+}
+
+// AlertWebhook returns a function, for use with NewAlertHook, which POSTs each
+// Alert to the given URL as a JSON object, like:
+//
+//	{"level":"ERROR","threshold":10,"window":"1m0s","count":11,"msg":"query failed","ts":"2020-01-02T03:04:05Z"}
+//
+// A nil client defaults to one with a 10 second timeout. Since there's nowhere
+// to report them to, errors from POSTing are ignored.
+func AlertWebhook(url string, client *http.Client) func(Alert) {
+	if client == nil {
+		client = defaultHTTPSinkClient
+	}
+	return func(a Alert) {
+		body, err := json.Marshal(map[string]interface{}{
+			"level":     a.Rule.Level.String(),
+			"threshold": a.Rule.Threshold,
+			"window":    a.Rule.Window.String(),
+			"count":     a.Count,
+			"msg":       a.Entry.Msg,
+			"ts":        a.Entry.Time.Format(time.RFC3339Nano),
+		})
+		if err != nil {
+			return
+		}
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
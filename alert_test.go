@@ -0,0 +1,85 @@
+package llog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertHook(t *T) {
+	alertCh := make(chan Alert, 10)
+	ah := NewAlertHook(AlertRule{
+		Level:     ErrorLevel,
+		Threshold: 2,
+		Window:    10 * time.Second,
+		Cooldown:  time.Minute,
+	}, func(a Alert) { alertCh <- a })
+	assert.Equal(t, ErrorLevel, ah.Level())
+
+	start := time.Unix(0, 0)
+	fire := func(after time.Duration, msg string) {
+		ah.Fire(Entry{Level: ErrorLevel, Time: start.Add(after), Msg: msg})
+	}
+	assertNoAlert := func() {
+		select {
+		case a := <-alertCh:
+			t.Fatalf("unexpected alert: %v", a)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	// spread out entries never break the rule
+	fire(0, "a")
+	fire(6*time.Second, "b")
+	fire(12*time.Second, "c")
+	assertNoAlert()
+
+	fire(13*time.Second, "d")
+	a := <-alertCh
+	assert.Equal(t, 3, a.Count)
+	assert.Equal(t, "d", a.Entry.Msg)
+	assert.Equal(t, "3 ERROR entries within 10s, most recently \"d\"", a.String())
+
+	// within the cooldown
+	fire(14*time.Second, "e")
+	assertNoAlert()
+
+	fire(73*time.Second, "f")
+	fire(74*time.Second, "g")
+	fire(75*time.Second, "h")
+	a = <-alertCh
+	assert.Equal(t, "h", a.Entry.Msg)
+	assertNoAlert()
+}
+
+func TestAlertWebhook(t *T) {
+	bodyCh := make(chan map[string]interface{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal(b, &m))
+		bodyCh <- m
+	}))
+	defer srv.Close()
+
+	AlertWebhook(srv.URL, nil)(Alert{
+		Rule:  AlertRule{Level: ErrorLevel, Threshold: 10, Window: time.Minute},
+		Count: 11,
+		Entry: Entry{Level: ErrorLevel, Time: time.Unix(0, 0).UTC(), Msg: "query failed"},
+	})
+	assert.Equal(t, map[string]interface{}{
+		"level":     "ERROR",
+		"threshold": float64(10),
+		"window":    "1m0s",
+		"count":     float64(11),
+		"msg":       "query failed",
+		"ts":        "1970-01-01T00:00:00Z",
+	}, <-bodyCh)
+}
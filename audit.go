@@ -0,0 +1,133 @@
+package llog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// The keys which every audit entry must have a value for, see Audit
+const (
+	AuditActorKey  = "actor"
+	AuditActionKey = "action"
+	AuditTargetKey = "target"
+)
+
+// The keys which hash chaining adds to audit entries, see SetAuditHashChain
+const (
+	AuditHashKey     = "hash"
+	AuditPrevHashKey = "prevHash"
+)
+
+// the audit state is only accessed from the global main loop
+var (
+	auditSinks       []Sink
+	configAuditSinks []Sink // the audit Sinks opened by ApplyConfig
+	auditHashChain   bool
+	auditPrevHash    string
+)
+
+// Audit writes an audit entry for the given event. Audit entries are a separate
+// stream from all other entries: they are never filtered by level or by
+// Processors, aren't passed to Hooks, and are written only to the audit Sinks
+// (see SetAuditSinks), or to Out if there aren't any. Redaction and scrubbing
+// are still applied.
+//
+// The KV must have non-empty values for AuditActorKey, AuditActionKey, and
+// AuditTargetKey, describing who did what to what, e.g.
+//
+//	llog.Audit("user deleted", llog.KV{"actor": adminID, "action": "delete", "target": userID})
+//
+// Audit entries are written synchronously, and an error is returned if the KV
+// is missing any of those keys or if the entry couldn't be written.
+func Audit(event string, kv KV) error {
+	for _, k := range [...]string{AuditActorKey, AuditActionKey, AuditTargetKey} {
+		if v := kv[k]; v == nil || v == "" {
+			return fmt.Errorf("audit entry %q is missing %q", event, k)
+		}
+	}
+	e := Entry{
+		Level: InfoLevel,
+		Time:  globalCore.now(),
+		Msg:   event,
+		KV:    kv.Copy(),
+	}
+	var err error
+	globalCore.apply(func() { err = writeAudit(e) })
+	return err
+}
+
+// SetAuditSinks replaces the Sinks which audit entries are written to, see
+// Audit. Calling it with no Sinks causes audit entries to be written to Out.
+func SetAuditSinks(ss ...Sink) {
+	globalCore.apply(func() { auditSinks = ss })
+}
+
+// SetAuditHashChain sets whether audit entries are hash-chained, for tamper
+// evidence. When on, every audit entry is given the hash of the previous one
+// under AuditPrevHashKey (empty for the first), and its own hash under
+// AuditHashKey. An entry's hash is the hex encoded SHA-256 of the previous
+// entry's hash followed by the entry, including AuditPrevHashKey but not
+// AuditHashKey, as written by JSONFormatter with its timestamp. Removing or
+// modifying any entry breaks the chain from that point on.
+//
+// Turning it on starts a new chain.
+func SetAuditHashChain(on bool) {
+	globalCore.apply(func() {
+		auditHashChain = on
+		auditPrevHash = ""
+	})
+}
+
+// writeAudit writes an audit entry. Shouldn't be called outside the global main
+// loop
+func writeAudit(e Entry) error {
+	resolveLazy(e.KV)
+	e = Sanitize(e)
+	if auditHashChain {
+		e.KV[AuditPrevHashKey] = auditPrevHash
+		h, err := auditHash(auditPrevHash, e)
+		if err != nil {
+			return err
+		}
+		e.KV[AuditHashKey] = h
+		// the chain moves on even if the entry can't be written, so that the
+		// gap is evident
+		auditPrevHash = h
+	}
+
+	if len(auditSinks) == 0 {
+		out, f, ts := globalCore.output()
+		if out == nil {
+			return nil
+		}
+		if err := safeFormat(out, f, e, ts); err != nil {
+			err = &WriteError{Err: err}
+			globalCore.countWriteError(err)
+			return err
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, s := range auditSinks {
+		if err := s.WriteEntry(e); err != nil {
+			err = &WriteError{Sink: s, Err: err}
+			globalCore.countWriteError(err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func auditHash(prev string, e Entry) (string, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString(prev)
+	if err := (JSONFormatter{}).Format(buf, e, true); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
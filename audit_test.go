@@ -0,0 +1,64 @@
+package llog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *T) {
+	buf := new(bytes.Buffer)
+	SetAuditSinks(WriterSink{Writer: buf, Formatter: JSONFormatter{}})
+	defer SetAuditSinks()
+
+	kv := KV{"actor": "admin", "action": "delete", "target": "user1"}
+	err := Audit("user deleted", KV{"actor": "admin", "action": "delete"})
+	assert.EqualError(t, err, `audit entry "user deleted" is missing "target"`)
+
+	// audit entries are never level filtered, and are still redacted
+	SetLevel(FatalLevel)
+	defer SetLevel(InfoLevel)
+	require.NoError(t, Audit("user deleted", Merge(kv, KV{"password": "hunter2"})))
+	assert.Equal(t,
+		`{"level":"INFO","msg":"user deleted","action":"delete","actor":"admin","password":"[REDACTED]","target":"user1"}`+"\n",
+		buf.String())
+
+	SetAuditSinks(WriterSink{Writer: errWriter{}})
+	assert.Error(t, Audit("user deleted", kv))
+}
+
+func TestAuditHashChain(t *T) {
+	SetClock(func() time.Time { return time.Unix(1, 0).UTC() })
+	defer SetClock(nil)
+	buf := new(bytes.Buffer)
+	SetAuditSinks(WriterSink{Writer: buf, Formatter: JSONFormatter{}, DisplayTimestamp: true})
+	defer SetAuditSinks()
+	SetAuditHashChain(true)
+	defer SetAuditHashChain(false)
+
+	kv := KV{"actor": "admin", "action": "delete", "target": "user1"}
+	require.NoError(t, Audit("a", kv))
+	require.NoError(t, Audit("b", kv))
+
+	var prev string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		assert.Equal(t, prev, m[AuditPrevHashKey])
+
+		// recompute the hash from the line without the hash
+		h := m[AuditHashKey].(string)
+		noHash := strings.Replace(line, `,"hash":"`+h+`"`, "", 1)
+		sum := sha256.Sum256([]byte(prev + noHash + "\n"))
+		assert.Equal(t, hex.EncodeToString(sum[:]), h)
+		prev = h
+	}
+	assert.NotEmpty(t, prev)
+}
@@ -0,0 +1,97 @@
+package llog
+
+import (
+	"bytes"
+	"io"
+)
+
+// maxBatchSize is the number of bytes of entries which are batched up before
+// they're written, even if there are more queued
+const maxBatchSize = 64 << 10
+
+// entryBatch holds formatted entries which are waiting to be written to Out
+// with a single Write, see SetBatchWrites. It's only accessed from the main
+// loop.
+type entryBatch struct {
+	enabled bool // set by SetBatchWrites
+	active  bool // set while draining multiple entries
+	buf     bytes.Buffer
+	entries []Entry // the entries in buf, for reporting write errors
+
+	// blockChs belong to blocking entries which were logged while buf wasn't
+	// empty, they're closed once buf has been written
+	blockChs []chan struct{}
+}
+
+// SetBatchWrites sets whether entries which are queued at the same time are
+// written to Out using a single Write, rather than one Write each. This can
+// cut the number of syscalls dramatically when writing to a pipe or network
+// connection under load, but must not be enabled if each Write to Out is
+// expected to be a single entry, e.g. if Out sends a datagram per Write. Sinks
+// aren't affected. If a batched Write fails the error is handled, as usual, for
+// every entry in the batch. It's disabled by default.
+func SetBatchWrites(on bool) {
+	globalCore.apply(func() { globalCore.batch.enabled = on })
+}
+
+// format formats the entry to out, or into the batch if one is being built
+// (writing the batch to Out if it's grown too big), and takes its size from the
+// byte limit, see SetRateLimit. Shouldn't be called outside the main loop.
+func (c *core) format(out io.Writer, f Formatter, e Entry, ts bool) error {
+	var n int
+	var err error
+	switch {
+	case c.batch.active:
+		start := c.batch.buf.Len()
+		if err = safeFormat(&c.batch.buf, f, e, ts); err == nil {
+			c.batch.entries = append(c.batch.entries, e)
+		}
+		n = c.batch.buf.Len() - start
+	case c.rate != nil && c.rate.limit.Bytes > 0:
+		c.rate.out = byteCounter{w: out}
+		err = safeFormat(&c.rate.out, f, e, ts)
+		n, c.rate.out.w = c.rate.out.n, nil
+	default:
+		err = safeFormat(out, f, e, ts)
+	}
+	if c.rate != nil {
+		c.rate.bytes -= float64(n)
+	}
+	if c.batch.active && c.batch.buf.Len() >= maxBatchSize {
+		c.writeBatch()
+	}
+	return err
+}
+
+// writeBatch writes the batch to Out, if there's anything in it, and then
+// unblocks any entries which were waiting on it. Shouldn't be called outside
+// the main loop.
+func (c *core) writeBatch() {
+	if c.batch.buf.Len() > 0 {
+		out, _, _ := c.output()
+		if _, err := out.Write(c.batch.buf.Bytes()); err != nil {
+			for _, e := range c.batch.entries {
+				c.writeError(&WriteError{Err: err}, e)
+			}
+		}
+		c.batch.buf.Reset()
+		clear(c.batch.entries)
+		c.batch.entries = c.batch.entries[:0]
+	}
+	for _, ch := range c.batch.blockChs {
+		close(ch)
+	}
+	clear(c.batch.blockChs)
+	c.batch.blockChs = c.batch.blockChs[:0]
+}
+
+// unblock closes blockCh, or if the entry it belongs to may be in the batch
+// defers that until the batch has been written. Shouldn't be called outside the
+// main loop.
+func (c *core) unblock(blockCh chan struct{}) {
+	if c.batch.buf.Len() > 0 {
+		c.batch.blockChs = append(c.batch.blockChs, blockCh)
+		return
+	}
+	close(blockCh)
+}
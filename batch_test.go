@@ -0,0 +1,103 @@
+package llog
+
+import (
+	"bytes"
+	"errors"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedWriter records every Write made to it, with each one waiting to receive
+// from the gate first
+type gatedWriter struct {
+	gate   chan struct{}
+	writes [][]byte
+	err    error
+}
+
+func (gw *gatedWriter) Write(b []byte) (int, error) {
+	<-gw.gate
+	gw.writes = append(gw.writes, bytes.Clone(b))
+	return len(b), gw.err
+}
+
+func TestBatchWrites(t *T) {
+	gw := &gatedWriter{gate: make(chan struct{})}
+	var errs int
+	l := New(
+		WithOutput(gw),
+		WithBufferSize(10),
+		WithBatchWrites(true),
+		WithWriteErrorHandler(func(error, Entry) { errs++ }),
+	)
+
+	// the first entry is picked up on its own, the rest are queued behind it
+	// and so batched
+	l.Info("a")
+	assert.Eventually(t, func() bool {
+		n, _ := l.QueueDepth()
+		return n == 0
+	}, time.Second, time.Millisecond)
+	for _, msg := range []string{"b", "c", "d"} {
+		l.Info(msg)
+	}
+	close(gw.gate)
+	l.Flush()
+	assert.Equal(t, [][]byte{
+		[]byte("~ INFO -- a\n"),
+		[]byte("~ INFO -- b\n~ INFO -- c\n~ INFO -- d\n"),
+	}, gw.writes)
+	assert.Zero(t, errs)
+
+	// a failed batch is reported for each of its entries
+	gw.err = errors.New("failed")
+	gw.gate = make(chan struct{})
+	gw.writes = nil
+	l.Info("a")
+	assert.Eventually(t, func() bool {
+		n, _ := l.QueueDepth()
+		return n == 0
+	}, time.Second, time.Millisecond)
+	l.Info("b")
+	l.Info("c")
+	close(gw.gate)
+	l.Flush()
+	assert.Len(t, gw.writes, 2)
+	assert.Equal(t, 3, errs)
+}
+
+func TestBatchWritesBlocking(t *T) {
+	gw := &gatedWriter{gate: make(chan struct{})}
+	l := New(WithOutput(gw), WithBufferSize(10), WithBatchWrites(true))
+
+	l.Info("a")
+	assert.Eventually(t, func() bool {
+		n, _ := l.QueueDepth()
+		return n == 0
+	}, time.Second, time.Millisecond)
+	l.Info("b")
+	doneCh := make(chan [][]byte)
+	go func() {
+		l.logEntry(InfoLevel, "c", nil, true)
+		doneCh <- gw.writes
+	}()
+	assert.Eventually(t, func() bool {
+		n, _ := l.QueueDepth()
+		return n == 2
+	}, time.Second, time.Millisecond)
+
+	// the blocking entry only returns once the batch it's in has been written
+	gw.gate <- struct{}{}
+	select {
+	case <-doneCh:
+		t.Fatal("blocking entry returned before it was written")
+	case <-time.After(50 * time.Millisecond):
+	}
+	gw.gate <- struct{}{}
+	assert.Equal(t, [][]byte{
+		[]byte("~ INFO -- a\n"),
+		[]byte("~ INFO -- b\n~ INFO -- c\n"),
+	}, <-doneCh)
+}
@@ -0,0 +1,128 @@
+package llog
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by a BreakerSink for entries written while its
+// circuit is open
+var ErrCircuitOpen = errors.New("llog: sink circuit is open")
+
+// BreakerSink is a Sink which stops writing to another Sink for a while once it
+// has failed too many times in a row, so that an outage doesn't cost a connect
+// timeout for every single entry. While the circuit is open entries fail
+// immediately with ErrCircuitOpen, and so go to the write error handler (see
+// OnWriteError), which by default writes them to Stdout. Once the backoff has
+// passed the next entry is written as a probe: if it succeeds the circuit is
+// closed again, otherwise it's reopened for twice as long.
+//
+// A BreakerSink must be used as a pointer, e.g.
+//
+//	llog.AddSink(&llog.BreakerSink{Sink: s, Failures: 5})
+type BreakerSink struct {
+	Sink Sink
+
+	// Failures is the number of consecutive failed writes which open the
+	// circuit. Defaults to 5
+	Failures int
+
+	// Backoff is how long the circuit stays open the first time, it doubles
+	// for each failed probe. Defaults to 1 second
+	Backoff time.Duration
+
+	// MaxBackoff is the longest the circuit stays open for. Defaults to 1
+	// minute
+	MaxBackoff time.Duration
+
+	lock      sync.Mutex
+	failures  int           // consecutive failures
+	backoff   time.Duration // how long the circuit was last opened for
+	openUntil time.Time     // zero if the circuit is closed
+	now       func() time.Time
+}
+
+// WriteEntry implements the Sink interface
+func (bs *BreakerSink) WriteEntry(e Entry) error {
+	if !bs.allow() {
+		return ErrCircuitOpen
+	}
+	err := bs.Sink.WriteEntry(e)
+	bs.record(err == nil)
+	return err
+}
+
+// Open returns whether the circuit is currently open, i.e. whether entries are
+// being failed without being written
+func (bs *BreakerSink) Open() bool {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+	return !bs.openUntil.IsZero() && bs.getNow().Before(bs.openUntil)
+}
+
+func (bs *BreakerSink) getNow() time.Time {
+	if bs.now != nil {
+		return bs.now()
+	}
+	return time.Now()
+}
+
+// allow returns whether an entry should be written to the Sink
+func (bs *BreakerSink) allow() bool {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+	return bs.openUntil.IsZero() || !bs.getNow().Before(bs.openUntil)
+}
+
+// record records the outcome of a write, opening or closing the circuit
+func (bs *BreakerSink) record(ok bool) {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+	if ok {
+		bs.failures, bs.backoff, bs.openUntil = 0, 0, time.Time{}
+		return
+	}
+	bs.failures++
+	max := bs.Failures
+	if max <= 0 {
+		max = 5
+	}
+	if bs.openUntil.IsZero() && bs.failures < max {
+		return
+	}
+
+	// either the threshold was just reached, or a probe failed
+	maxBackoff := bs.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = time.Minute
+	}
+	if bs.backoff == 0 {
+		if bs.backoff = bs.Backoff; bs.backoff <= 0 {
+			bs.backoff = time.Second
+		}
+	} else {
+		bs.backoff *= 2
+	}
+	if bs.backoff > maxBackoff {
+		bs.backoff = maxBackoff
+	}
+	bs.openUntil = bs.getNow().Add(bs.backoff)
+}
+
+// Flush flushes the underlying Sink, if it has either a Flush or Sync method,
+// unless the circuit is open
+func (bs *BreakerSink) Flush() {
+	if !bs.Open() {
+		flushWriter(bs.Sink)
+	}
+}
+
+// Close closes the underlying Sink, if it has a Close method
+func (bs *BreakerSink) Close() error {
+	if c, ok := bs.Sink.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package llog
+
+import (
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakerSink(t *T) {
+	now := time.Now()
+	fs := &flakySink{fails: 100}
+	bs := &BreakerSink{Sink: fs, Failures: 3, Backoff: time.Second, MaxBackoff: 3 * time.Second}
+	bs.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		assert.False(t, bs.Open())
+		assert.EqualError(t, bs.WriteEntry(Entry{}), "unavailable")
+	}
+	assert.True(t, bs.Open())
+	assert.Equal(t, ErrCircuitOpen, bs.WriteEntry(Entry{}))
+	assert.Equal(t, 97, fs.fails)
+
+	// a failed probe reopens the circuit for twice as long
+	now = now.Add(time.Second)
+	assert.False(t, bs.Open())
+	assert.EqualError(t, bs.WriteEntry(Entry{}), "unavailable")
+	assert.True(t, bs.Open())
+	now = now.Add(time.Second)
+	assert.Equal(t, ErrCircuitOpen, bs.WriteEntry(Entry{}))
+	now = now.Add(time.Second)
+	assert.EqualError(t, bs.WriteEntry(Entry{}), "unavailable")
+	// capped at MaxBackoff
+	assert.Equal(t, 3*time.Second, bs.backoff)
+
+	// a successful probe closes it
+	now = now.Add(3 * time.Second)
+	fs.fails = 0
+	assert.NoError(t, bs.WriteEntry(Entry{Msg: "foo"}))
+	assert.False(t, bs.Open())
+	require.Len(t, fs.entries, 1)
+	fs.fails = 1
+	assert.Error(t, bs.WriteEntry(Entry{}))
+	assert.False(t, bs.Open())
+}
+
+func TestOutputConfigBreaker(t *T) {
+	s, err := OutputConfig{Type: "stdout", Timeout: "5s", Breaker: 3}.sink(nil, false)
+	require.NoError(t, err)
+	require.IsType(t, &BreakerSink{}, s)
+	assert.Equal(t, 3, s.(*BreakerSink).Failures)
+	assert.IsType(t, &TimeoutSink{}, s.(*BreakerSink).Sink)
+	assert.IsType(t, WriterSink{}, unwrapSink(s))
+}
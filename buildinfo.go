@@ -0,0 +1,63 @@
+package llog
+
+import (
+	"runtime/debug"
+)
+
+// BuildInfoModules are the paths of the dependency modules whose versions
+// LogBuildInfo includes, under the "deps" key
+var BuildInfoModules []string
+
+// LogBuildInfo logs a standard Info entry announcing what the process is
+// running, read from debug.ReadBuildInfo, with the given KV merged on top. It
+// should be called once at startup. The entry looks like:
+//
+//	~ INFO -- starting -- commit="0123abc" commitTime="2020-01-02T03:04:05Z" goVersion="go1.22.1" modified="false" path="github.com/example/app" version="v1.2.3"
+//
+// Values which aren't known, e.g. the commit when the binary wasn't built from
+// within a repository, are left out.
+func LogBuildInfo(kv ...KV) {
+	var bkv KV
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		bkv = buildInfoKV(bi, BuildInfoModules)
+	}
+	Info("starting", append([]KV{bkv}, kv...)...)
+}
+
+func buildInfoKV(bi *debug.BuildInfo, modules []string) KV {
+	kv := KV{"goVersion": bi.GoVersion}
+	if bi.Main.Path != "" {
+		kv["path"] = bi.Main.Path
+	}
+	if v := bi.Main.Version; v != "" && v != "(devel)" {
+		kv["version"] = v
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			kv["commit"] = s.Value
+		case "vcs.time":
+			kv["commitTime"] = s.Value
+		case "vcs.modified":
+			kv["modified"] = s.Value == "true"
+		}
+	}
+
+	deps := KV{}
+	for _, path := range modules {
+		for _, dep := range bi.Deps {
+			if dep.Path != path {
+				continue
+			}
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			deps[path] = dep.Version
+			break
+		}
+	}
+	if len(deps) > 0 {
+		kv["deps"] = deps
+	}
+	return kv
+}
@@ -0,0 +1,52 @@
+package llog
+
+import (
+	"bytes"
+	"runtime/debug"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildInfoKV(t *T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.22.1",
+		Main:      debug.Module{Path: "github.com/example/app", Version: "v1.2.3"},
+		Deps: []*debug.Module{
+			{Path: "github.com/example/a", Version: "v0.1.0"},
+			{Path: "github.com/example/b", Version: "v0.2.0", Replace: &debug.Module{Path: "../b", Version: "v0.2.1"}},
+			{Path: "github.com/example/c", Version: "v0.3.0"},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123abc"},
+			{Key: "vcs.time", Value: "2020-01-02T03:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	assert.Equal(t, KV{
+		"goVersion":  "go1.22.1",
+		"path":       "github.com/example/app",
+		"version":    "v1.2.3",
+		"commit":     "0123abc",
+		"commitTime": "2020-01-02T03:04:05Z",
+		"modified":   true,
+		"deps": KV{
+			"github.com/example/a": "v0.1.0",
+			"github.com/example/b": "v0.2.1",
+		},
+	}, buildInfoKV(bi, []string{"github.com/example/a", "github.com/example/b", "github.com/example/d"}))
+
+	bi = &debug.BuildInfo{GoVersion: "go1.22.1", Main: debug.Module{Version: "(devel)"}}
+	assert.Equal(t, KV{"goVersion": "go1.22.1"}, buildInfoKV(bi, nil))
+}
+
+func TestLogBuildInfo(t *T) {
+	oldOut := Out
+	defer func() { Out = oldOut }()
+	buf := new(bytes.Buffer)
+	Out = buf
+
+	LogBuildInfo(KV{"env": "test"})
+	Flush()
+	assert.Regexp(t, `^~ INFO -- starting -- .*env="test" goVersion="go[^"]+"`, buf.String())
+}
@@ -0,0 +1,32 @@
+package llog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// clock holds the func() time.Time set by SetClock, if any
+var clock atomic.Value
+
+// SetClock sets the function used to get the time entries are timestamped
+// with, which is useful for producing stable timestamps in tests or when
+// replaying. Setting it to nil restores the default, time.Now. It only affects
+// Loggers created by New if they weren't given WithClock.
+func SetClock(fn func() time.Time) {
+	if fn == nil {
+		fn = time.Now
+	}
+	clock.Store(fn)
+}
+
+// now returns the current time according to the core's clock, or to the
+// package's clock if the core doesn't have one
+func (c *core) now() time.Time {
+	if c.clock != nil {
+		return c.clock()
+	}
+	if fn, _ := clock.Load().(func() time.Time); fn != nil {
+		return fn()
+	}
+	return time.Now()
+}
@@ -0,0 +1,45 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetClock(t *T) {
+	oldOut, oldTS := Out, DisplayTimestamp
+	defer func() {
+		SetOutput(oldOut)
+		SetDisplayTimestamp(oldTS)
+		SetClock(nil)
+	}()
+	buf := new(bytes.Buffer)
+	SetOutput(buf)
+	SetDisplayTimestamp(true)
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(func() time.Time { return now })
+	Info("foo")
+	Flush()
+	assert.Equal(t, "~ [2020-01-02 03:04:05 +0000 UTC] INFO -- foo\n", buf.String())
+
+	// a Logger's own clock takes precedence
+	buf.Reset()
+	later := now.Add(time.Hour)
+	l := New(
+		WithOutput(buf),
+		WithTimestamps(true),
+		WithClock(func() time.Time { return later }),
+	)
+	l.Info("bar")
+	l.Flush()
+	assert.Equal(t, "~ [2020-01-02 04:04:05 +0000 UTC] INFO -- bar\n", buf.String())
+
+	SetClock(nil)
+	buf.Reset()
+	Info("baz")
+	Flush()
+	assert.NotContains(t, buf.String(), "2020-01-02")
+}
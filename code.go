@@ -0,0 +1,27 @@
+package llog
+
+// CodeKey is the key which event codes are always written under, see Code
+const CodeKey = "code"
+
+// Code returns a KV with the given stable event code, e.g. "AUTH-401". Codes
+// identify what happened independently of the message, so alerting, i18n, and
+// documentation can key off of them instead of off of message strings which
+// may change:
+//
+//	llog.Warn("login failed", llog.Code("AUTH-401"), llog.KV{"user": user})
+func Code(code string) KV {
+	return KV{CodeKey: code}
+}
+
+// CodeField is like Code, but returns a Field for use with the "w" log
+// functions, e.g. Warnw
+func CodeField(code string) Field {
+	return String(CodeKey, code)
+}
+
+// Code returns the entry's event code, see Code, or empty string if it doesn't
+// have one
+func (e Entry) Code() string {
+	code, _ := e.KV[CodeKey].(string)
+	return code
+}
@@ -0,0 +1,23 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCode(t *T) {
+	buf := new(bytes.Buffer)
+	var codes []string
+	l := New(
+		WithOutput(buf),
+		WithSynchronous(true),
+		WithHooks(NewHook(DebugLevel, func(e Entry) { codes = append(codes, e.Code()) })),
+	)
+	l.Warn("login failed", Code("AUTH-401"), KV{"user": "bob"})
+	l.Warnw("login failed", CodeField("AUTH-402"))
+	l.Info("no code")
+	assert.Equal(t, "~ WARN -- login failed -- code=\"AUTH-401\" user=\"bob\"\n~ WARN -- login failed -- code=\"AUTH-402\"\n~ INFO -- no code\n", buf.String())
+	assert.Equal(t, []string{"AUTH-401", "AUTH-402", ""}, codes)
+}
@@ -0,0 +1,78 @@
+package llog
+
+// Style is an ANSI SGR (Select Graphic Rendition) parameter, which the
+// TextFormatter uses to color and style text, e.g. "31" for red or "1;31" for
+// bold red. Some common ones are provided.
+type Style string
+
+// Common Styles
+const (
+	StyleBold    Style = "1"
+	StyleRed     Style = "31"
+	StyleGreen   Style = "32"
+	StyleYellow  Style = "33"
+	StyleBlue    Style = "34"
+	StyleMagenta Style = "35"
+	StyleCyan    Style = "36"
+	StyleGray    Style = "90"
+)
+
+// Colors describes how the TextFormatter colors entries, for output which is
+// going to a terminal
+type Colors struct {
+	// Levels are the Styles of each level's name, indexed by level. Levels
+	// with an empty Style aren't styled.
+	Levels [FatalLevel + 1]Style
+
+	// Keys are the Styles of the values of particular keys, e.g.
+	// {"err": StyleRed}
+	Keys map[string]Style
+}
+
+// DefaultColors are the Colors used for the "color" format, see
+// ConfigureFromEnv. A copy can be used as the basis for custom Colors.
+var DefaultColors = &Colors{
+	Levels: [FatalLevel + 1]Style{
+		DebugLevel: StyleGray,
+		InfoLevel:  StyleCyan,
+		WarnLevel:  StyleYellow,
+		ErrorLevel: StyleRed,
+		FatalLevel: StyleBold + ";" + StyleRed,
+	},
+	Keys: map[string]Style{
+		"err": StyleRed,
+	},
+}
+
+func (c *Colors) levelStyle(l Level) Style {
+	if c == nil || l < 0 || int(l) >= len(c.Levels) {
+		return ""
+	}
+	return c.Levels[l]
+}
+
+func (c *Colors) keyStyle(k string) Style {
+	if c == nil {
+		return ""
+	}
+	return c.Keys[k]
+}
+
+// appendStyleStart appends the escape sequence which starts the Style, if any
+func appendStyleStart(buf []byte, s Style) []byte {
+	if s == "" {
+		return buf
+	}
+	buf = append(buf, "\x1b["...)
+	buf = append(buf, s...)
+	return append(buf, 'm')
+}
+
+// appendStyleEnd appends the escape sequence which resets any Style, if the
+// given Style was started
+func appendStyleEnd(buf []byte, s Style) []byte {
+	if s == "" {
+		return buf
+	}
+	return append(buf, "\x1b[0m"...)
+}
@@ -0,0 +1,25 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColors(t *T) {
+	buf := new(bytes.Buffer)
+	tf := TextFormatter{Colors: DefaultColors, PadLevel: true}
+	require.NoError(t, tf.Format(buf, Entry{Level: InfoLevel, Msg: "foo", KV: KV{"err": "bad", "a": 1}}, false))
+	assert.Equal(t, "~ \x1b[36mINFO\x1b[0m  -- foo -- a=\"1\" err=\x1b[31m\"bad\"\x1b[0m\n", buf.String())
+
+	buf.Reset()
+	tf.Colors = &Colors{Keys: map[string]Style{"a": StyleBold}}
+	require.NoError(t, tf.Format(buf, Entry{Level: ErrorLevel, Msg: "foo", KV: KV{"err": "bad", "a": 1}}, false))
+	assert.Equal(t, "~ ERROR -- foo -- a=\x1b[1m\"1\"\x1b[0m err=\"bad\"\n", buf.String())
+
+	f, err := parseFormat("color")
+	require.NoError(t, err)
+	assert.Equal(t, TextFormatter{Colors: DefaultColors}, f)
+}
@@ -0,0 +1,396 @@
+package llog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Config describes the full logging pipeline declaratively, so that it can be
+// loaded from a file (see the llconfig package) and applied with ApplyConfig.
+// Any field left empty leaves the corresponding configuration as it was.
+type Config struct {
+	// Level is the minimum level to log, e.g. "debug"
+	Level string `json:"level,omitempty" yaml:"level,omitempty" toml:"level,omitempty"`
+
+	// Packages overrides Level for particular packages, see
+	// SetPackageLevels
+	Packages map[string]string `json:"packages,omitempty" yaml:"packages,omitempty" toml:"packages,omitempty"`
+
+	// Format is the format entries are written to Out in, "text", "json",
+	// "color", "kubernetes", "docker", or "systemd"
+	Format string `json:"format,omitempty" yaml:"format,omitempty" toml:"format,omitempty"`
+
+	// Timestamp is whether timestamps are displayed when writing to Out
+	Timestamp *bool `json:"timestamp,omitempty" yaml:"timestamp,omitempty" toml:"timestamp,omitempty"`
+
+	// Outputs, if not empty, replace Out and all Sinks
+	Outputs []OutputConfig `json:"outputs,omitempty" yaml:"outputs,omitempty" toml:"outputs,omitempty"`
+
+	// Caller are the levels to annotate with their caller, see
+	// SetCallerLevels
+	Caller []string `json:"caller,omitempty" yaml:"caller,omitempty" toml:"caller,omitempty"`
+
+	// Stack is the minimum level to capture stack traces for, see
+	// SetStackTraces
+	Stack string `json:"stack,omitempty" yaml:"stack,omitempty" toml:"stack,omitempty"`
+
+	// Redact are the keys whose values are redacted, see SetRedactedKeys
+	Redact []string `json:"redact,omitempty" yaml:"redact,omitempty" toml:"redact,omitempty"`
+
+	// Scrub, if not empty, replaces all Scrubbers
+	Scrub []ScrubConfig `json:"scrub,omitempty" yaml:"scrub,omitempty" toml:"scrub,omitempty"`
+
+	// Audit, if not empty, replaces all audit Sinks, see SetAuditSinks. Since
+	// audit entries aren't filtered by level the Level of these outputs is
+	// ignored
+	Audit []OutputConfig `json:"audit,omitempty" yaml:"audit,omitempty" toml:"audit,omitempty"`
+}
+
+// OutputConfig describes a single destination for entries
+type OutputConfig struct {
+	// Type is one of "stdout", "stderr", "file", "syslog", or "http"
+	Type string `json:"type" yaml:"type" toml:"type"`
+
+	// Level is the minimum level of entries written to this output. Since
+	// entries below the Config's Level are never logged at all this can only
+	// be used to further restrict an output
+	Level string `json:"level,omitempty" yaml:"level,omitempty" toml:"level,omitempty"`
+
+	// Format is the format entries are written in, "text", "json", "color",
+	// "kubernetes", "docker", or "systemd". Defaults to the Config's Format
+	// for all outputs but http, which defaults to json
+	Format string `json:"format,omitempty" yaml:"format,omitempty" toml:"format,omitempty"`
+
+	// Timestamp is whether timestamps are displayed. Defaults to the Config's
+	// Timestamp
+	Timestamp *bool `json:"timestamp,omitempty" yaml:"timestamp,omitempty" toml:"timestamp,omitempty"`
+
+	// Path is the path of the file to append to, for the file type. The file
+	// is reopened by Reopen
+	Path string `json:"path,omitempty" yaml:"path,omitempty" toml:"path,omitempty"`
+
+	// Network, Address, and Tag are passed to syslog.Dial, for the syslog
+	// type. Leaving Network and Address empty connects to the local syslog
+	Network string `json:"network,omitempty" yaml:"network,omitempty" toml:"network,omitempty"`
+	Address string `json:"address,omitempty" yaml:"address,omitempty" toml:"address,omitempty"`
+	Tag     string `json:"tag,omitempty" yaml:"tag,omitempty" toml:"tag,omitempty"`
+
+	// URL is where entries are POSTed to, for the http type
+	URL string `json:"url,omitempty" yaml:"url,omitempty" toml:"url,omitempty"`
+
+	// LevelNames overrides the names levels are written as, e.g.
+	// {"warn": "notice"}. For the syslog type they're instead the syslog
+	// severities levels are written with, see NewSyslogSinkWithSeverities
+	LevelNames map[string]string `json:"levelNames,omitempty" yaml:"levelNames,omitempty" toml:"levelNames,omitempty"`
+
+	// Retries is how many times an entry which couldn't be written is retried,
+	// with backoff, for the syslog and http types. See RetrySink and HTTPSink
+	Retries int `json:"retries,omitempty" yaml:"retries,omitempty" toml:"retries,omitempty"`
+
+	// Timeout, if set, is the longest writing an entry to the output may take,
+	// e.g. "5s", after which it's written to Stdout instead. See TimeoutSink
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty" toml:"timeout,omitempty"`
+
+	// Breaker, if set, is the number of consecutive failed writes to the
+	// output after which it's skipped for a while. See BreakerSink
+	Breaker int `json:"breaker,omitempty" yaml:"breaker,omitempty" toml:"breaker,omitempty"`
+}
+
+// ScrubConfig describes a Scrubber
+type ScrubConfig struct {
+	Pattern     string `json:"pattern" yaml:"pattern" toml:"pattern"`
+	Replacement string `json:"replacement" yaml:"replacement" toml:"replacement"`
+}
+
+// configSinks are the Sinks opened by the last ApplyConfig with Outputs. Only
+// accessed from the main loop.
+var configSinks []Sink
+
+// ApplyConfig applies the given Config. The whole Config is validated, and all
+// of its outputs are opened, before any of it is applied, so if an error is
+// returned nothing has changed.
+//
+// Unlike modifying the package's public variables directly ApplyConfig may be
+// called at any time, e.g. to reload a config file. The Config is applied all
+// at once in between entries being written, and any outputs opened by a
+// previous ApplyConfig are closed once they've been replaced.
+func ApplyConfig(cfg Config) error {
+	var fns []func()
+
+	if cfg.Level != "" {
+		l, err := parseLevel(cfg.Level)
+		if err != nil {
+			return fmt.Errorf("invalid level: %w", err)
+		}
+		fns = append(fns, func() { SetLevel(l) })
+	}
+
+	if cfg.Packages != nil {
+		lvls := make(map[string]Level, len(cfg.Packages))
+		for pkg, ls := range cfg.Packages {
+			l, err := parseLevel(ls)
+			if err != nil {
+				return fmt.Errorf("invalid level for package %q: %w", pkg, err)
+			}
+			lvls[pkg] = l
+		}
+		fns = append(fns, func() { SetPackageLevels(lvls) })
+	}
+
+	// read from the main loop, since that's where they're written
+	var format Formatter
+	var ts bool
+	globalCore.apply(func() { format, ts = OutFormatter, DisplayTimestamp })
+	if cfg.Format != "" {
+		var err error
+		if format, err = parseFormat(cfg.Format); err != nil {
+			return fmt.Errorf("invalid format: %w", err)
+		}
+		fns = append(fns, func() { OutFormatter = format })
+	}
+
+	if cfg.Timestamp != nil {
+		ts = *cfg.Timestamp
+		fns = append(fns, func() { DisplayTimestamp = ts })
+	}
+
+	if cfg.Caller != nil {
+		lvls, err := parseLevels(strings.Join(cfg.Caller, ","))
+		if err != nil {
+			return fmt.Errorf("invalid caller: %w", err)
+		}
+		fns = append(fns, func() { SetCallerLevels(lvls...) })
+	}
+
+	if cfg.Stack != "" {
+		l, err := parseLevel(cfg.Stack)
+		if err != nil {
+			return fmt.Errorf("invalid stack: %w", err)
+		}
+		fns = append(fns, func() { SetStackTraces(&StackTraceOptions{Level: l}) })
+	}
+
+	if cfg.Redact != nil {
+		fns = append(fns, func() { SetRedactedKeys(cfg.Redact...) })
+	}
+
+	if len(cfg.Scrub) > 0 {
+		ss := make([]Scrubber, len(cfg.Scrub))
+		for i, sc := range cfg.Scrub {
+			re, err := regexp.Compile(sc.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid scrub pattern %q: %w", sc.Pattern, err)
+			}
+			ss[i] = Scrubber{Regexp: re, Replacement: sc.Replacement}
+		}
+		fns = append(fns, func() { SetScrubbers(ss...) })
+	}
+
+	// done last so that outputs are only opened if everything else was valid
+	var ss, auditSS []Sink
+	if len(cfg.Outputs) > 0 {
+		ss = make([]Sink, 0, len(cfg.Outputs))
+		for i, oc := range cfg.Outputs {
+			s, err := oc.sink(format, ts)
+			if err != nil {
+				closeSinks(ss)
+				return fmt.Errorf("invalid output %d (%s): %w", i, oc.Type, err)
+			}
+			ss = append(ss, s)
+		}
+		fns = append(fns, func() {
+			Out = nil
+			SetSinks(ss...)
+			closeSinks(configSinks)
+			configSinks = ss
+		})
+	}
+
+	if len(cfg.Audit) > 0 {
+		auditSS = make([]Sink, 0, len(cfg.Audit))
+		for i, oc := range cfg.Audit {
+			oc.Level = ""
+			s, err := oc.sink(format, ts)
+			if err != nil {
+				closeSinks(ss)
+				closeSinks(auditSS)
+				return fmt.Errorf("invalid audit output %d (%s): %w", i, oc.Type, err)
+			}
+			auditSS = append(auditSS, s)
+		}
+		fns = append(fns, func() {
+			auditSinks = auditSS
+			closeSinks(configAuditSinks)
+			configAuditSinks = auditSS
+		})
+	}
+
+	globalCore.apply(func() {
+		for _, fn := range fns {
+			fn()
+		}
+	})
+	return nil
+}
+
+func (oc OutputConfig) sink(format Formatter, ts bool) (Sink, error) {
+	var timeout time.Duration
+	if oc.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(oc.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	s, err := oc.baseSink(format, ts)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		s = NewTimeoutSink(s, timeout)
+	}
+	if oc.Breaker > 0 {
+		s = &BreakerSink{Sink: s, Failures: oc.Breaker}
+	}
+	return s, nil
+}
+
+func (oc OutputConfig) baseSink(format Formatter, ts bool) (Sink, error) {
+	var lvl Level
+	if oc.Level != "" {
+		var err error
+		if lvl, err = parseLevel(oc.Level); err != nil {
+			return nil, err
+		}
+	}
+	if oc.Type == "http" {
+		format = JSONFormatter{}
+	}
+	if oc.Format != "" {
+		var err error
+		if format, err = parseFormat(oc.Format); err != nil {
+			return nil, err
+		}
+	}
+	if oc.Timestamp != nil {
+		ts = *oc.Timestamp
+	}
+	names := make(map[Level]string, len(oc.LevelNames))
+	for ls, name := range oc.LevelNames {
+		l, err := parseLevel(ls)
+		if err != nil {
+			return nil, err
+		}
+		names[l] = name
+	}
+	if oc.Type != "syslog" {
+		format = withLevelNames(format, names)
+	}
+
+	switch oc.Type {
+	case "stdout":
+		return WriterSink{Writer: os.Stdout, Formatter: format, Level: lvl, DisplayTimestamp: ts}, nil
+	case "stderr":
+		if df, ok := format.(DockerFormatter); ok && df.Stream == "" {
+			df.Stream = "stderr"
+			format = df
+		}
+		return WriterSink{Writer: os.Stderr, Formatter: format, Level: lvl, DisplayTimestamp: ts}, nil
+	case "file":
+		f, err := OpenFile(oc.Path)
+		if err != nil {
+			return nil, err
+		}
+		return WriterSink{Writer: f, Formatter: format, Level: lvl, DisplayTimestamp: ts}, nil
+	case "syslog":
+		s, err := NewSyslogSinkWithSeverities(oc.Network, oc.Address, oc.Tag, lvl, format, names)
+		if err != nil {
+			return nil, err
+		}
+		return withRetries(s, oc.Retries), nil
+	case "http":
+		if oc.URL == "" {
+			return nil, fmt.Errorf("url is required")
+		}
+		return &HTTPSink{URL: oc.URL, Formatter: format, Level: lvl, Retries: oc.Retries}, nil
+	}
+	return nil, fmt.Errorf("unknown output type %q", oc.Type)
+}
+
+// withLevelNames returns the Formatter with the given LevelNames set, if it's
+// one which has them
+func withLevelNames(f Formatter, names map[Level]string) Formatter {
+	if len(names) == 0 {
+		return f
+	}
+	set := func(ln *LevelNames) {
+		for l, name := range names {
+			ln[l] = name
+		}
+	}
+	switch ff := f.(type) {
+	case TextFormatter:
+		set(&ff.LevelNames)
+		return ff
+	case JSONFormatter:
+		set(&ff.LevelNames)
+		return ff
+	case DockerFormatter:
+		if ff.Formatter == nil {
+			ff.Formatter = TextFormatter{}
+		}
+		ff.Formatter = withLevelNames(ff.Formatter, names)
+		return ff
+	case SystemdFormatter:
+		if ff.Formatter == nil {
+			ff.Formatter = TextFormatter{}
+		}
+		ff.Formatter = withLevelNames(ff.Formatter, names)
+		return ff
+	}
+	return f
+}
+
+func withRetries(s Sink, retries int) Sink {
+	if retries <= 0 {
+		return s
+	}
+	return RetrySink{Sink: s, Retries: retries}
+}
+
+// unwrapSink returns the Sink wrapped by TimeoutSinks and BreakerSinks
+func unwrapSink(s Sink) Sink {
+	for {
+		switch ss := s.(type) {
+		case *TimeoutSink:
+			s = ss.sink
+		case *BreakerSink:
+			s = ss.Sink
+		default:
+			return s
+		}
+	}
+}
+
+// closeSinks closes any of the given Sinks which can be closed, including the
+// files of WriterSinks
+func closeSinks(ss []Sink) {
+	for _, s := range ss {
+		s = unwrapSink(s)
+		if ws, ok := s.(WriterSink); ok {
+			switch w := ws.Writer.(type) {
+			case *FileWriter:
+				w.Close()
+			case *os.File:
+				if w != os.Stdout && w != os.Stderr {
+					w.Close()
+				}
+			}
+		} else if c, ok := s.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
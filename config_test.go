@@ -0,0 +1,100 @@
+package llog
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConfig(t *T) {
+	oldOut, oldFormatter, oldTS := Out, OutFormatter, DisplayTimestamp
+	defer func() {
+		closeSinks(getSinks())
+		configSinks = nil
+		Out, OutFormatter, DisplayTimestamp = oldOut, oldFormatter, oldTS
+		SetLevel(InfoLevel)
+		SetSinks()
+		SetScrubbers()
+		SetRedactedKeys(DefaultRedactedKeys...)
+		SetPackageLevels(nil)
+	}()
+
+	dir := t.TempDir()
+	allPath, errPath := filepath.Join(dir, "all.log"), filepath.Join(dir, "err.log")
+	f := false
+	cfg := Config{
+		Level:     "debug",
+		Packages:  map[string]string{"github.com/example/noisy": "error"},
+		Format:    "json",
+		Timestamp: &f,
+		Outputs: []OutputConfig{
+			{Type: "file", Path: allPath},
+			{Type: "file", Path: errPath, Level: "error", Format: "text", LevelNames: map[string]string{"error": "ERR"}},
+		},
+		Redact: []string{"secret"},
+		Scrub:  []ScrubConfig{{Pattern: `\d+`, Replacement: "#"}},
+	}
+
+	// an invalid config shouldn't have any effect
+	badCfg := cfg
+	badCfg.Outputs = append(badCfg.Outputs, OutputConfig{Type: "carrier pigeon"})
+	assert.EqualError(t, ApplyConfig(badCfg), `invalid output 2 (carrier pigeon): unknown output type "carrier pigeon"`)
+	assert.Equal(t, InfoLevel, GetLevel())
+	assert.Equal(t, oldOut, Out)
+	assert.Empty(t, getSinks())
+
+	require.NoError(t, ApplyConfig(cfg))
+	assert.Equal(t, DebugLevel, GetLevel())
+	assert.Nil(t, Out)
+	assert.Len(t, getSinks(), 2)
+	assert.Equal(t, map[string]Level{"github.com/example/noisy": ErrorLevel}, pkgLevels.Load().levels)
+
+	Debug("foo 1", KV{"secret": "a"})
+	Error("bar 2")
+	Flush()
+	b, err := ioutil.ReadFile(allPath)
+	require.NoError(t, err)
+	assert.Equal(t, `{"level":"DEBUG","msg":"foo #","secret":"[REDACTED]"}`+"\n"+`{"level":"ERROR","msg":"bar #"}`+"\n", string(b))
+	b, err = ioutil.ReadFile(errPath)
+	require.NoError(t, err)
+	assert.Equal(t, "~ ERR -- bar #\n", string(b))
+
+	// applying another config should swap out the outputs and close the old
+	// ones
+	oldSinks := getSinks()
+	reloadPath := filepath.Join(dir, "reload.log")
+	require.NoError(t, ApplyConfig(Config{
+		Level:   "warn",
+		Outputs: []OutputConfig{{Type: "file", Path: reloadPath}},
+	}))
+	assert.Equal(t, WarnLevel, GetLevel())
+	_, err = oldSinks[0].(WriterSink).Writer.Write([]byte("foo"))
+	assert.Error(t, err)
+
+	Info("baz")
+	Warn("qux")
+	Flush()
+	b, err = ioutil.ReadFile(reloadPath)
+	require.NoError(t, err)
+	assert.Equal(t, `{"level":"WARN","msg":"qux"}`+"\n", string(b))
+}
+
+func TestApplyConfigAudit(t *T) {
+	defer func() {
+		closeSinks(configAuditSinks)
+		configAuditSinks = nil
+		SetAuditSinks()
+	}()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	require.NoError(t, ApplyConfig(Config{
+		Audit: []OutputConfig{{Type: "file", Path: path, Format: "json", Level: "fatal"}},
+	}))
+	require.NoError(t, Audit("user deleted", KV{"actor": "admin", "action": "delete", "target": "user1"}))
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"level":"INFO","msg":"user deleted","action":"delete","actor":"admin","target":"user1"}`+"\n", string(b))
+}
@@ -0,0 +1,64 @@
+package llog
+
+import (
+	"context"
+	"sync"
+)
+
+// Correlator extracts KV from a Context which correlates entries with something
+// else, e.g. the IDs of the trace or request they were logged during. CtxKV,
+// and so CtxLogger, includes the KV of every registered Correlator, so that
+// correlation isn't tied to any one tracing library. See AddCorrelator.
+type Correlator interface {
+	// Correlate returns the KV for the Context, which may be nil
+	Correlate(ctx context.Context) KV
+}
+
+// CorrelatorFunc adapts a function into a Correlator
+type CorrelatorFunc func(ctx context.Context) KV
+
+// Correlate implements the Correlator interface
+func (fn CorrelatorFunc) Correlate(ctx context.Context) KV {
+	return fn(ctx)
+}
+
+// RequestIDCorrelator is a Correlator which returns the request ID embedded in
+// the Context by CtxWithRequestID, under RequestIDKey. It's registered by
+// default.
+type RequestIDCorrelator struct{}
+
+// Correlate implements the Correlator interface
+func (RequestIDCorrelator) Correlate(ctx context.Context) KV {
+	if id := CtxRequestID(ctx); id != "" {
+		return KV{RequestIDKey: id}
+	}
+	return nil
+}
+
+var correlators = []Correlator{RequestIDCorrelator{}}
+var correlatorsLock sync.RWMutex
+
+// AddCorrelator registers a Correlator, in addition to those already
+// registered. Where Correlators return the same key, the one registered last
+// takes precedence.
+func AddCorrelator(c Correlator) {
+	correlatorsLock.Lock()
+	defer correlatorsLock.Unlock()
+	// copy so that a slice being read by CtxKV is never modified
+	correlators = append(correlators[:len(correlators):len(correlators)], c)
+}
+
+// SetCorrelators replaces all registered Correlators, including the default
+// RequestIDCorrelator, with the given ones. Calling it with none disables
+// correlation.
+func SetCorrelators(cs ...Correlator) {
+	correlatorsLock.Lock()
+	defer correlatorsLock.Unlock()
+	correlators = append([]Correlator(nil), cs...)
+}
+
+func getCorrelators() []Correlator {
+	correlatorsLock.RLock()
+	defer correlatorsLock.RUnlock()
+	return correlators
+}
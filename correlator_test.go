@@ -0,0 +1,23 @@
+package llog
+
+import (
+	"context"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorrelators(t *T) {
+	defer SetCorrelators(RequestIDCorrelator{})
+	ctx := CtxWithKV(CtxWithRequestID(context.Background(), "abc"), KV{"a": 1})
+	assert.Equal(t, KV{"a": 1, RequestIDKey: "abc"}, CtxKV(ctx))
+
+	AddCorrelator(CorrelatorFunc(func(context.Context) KV { return KV{"a": 2, "b": 2} }))
+	AddCtxKVFunc(func(context.Context) KV { return KV{"b": 3} })
+	AddCtxKVFunc(func(context.Context) KV { return nil })
+	assert.Equal(t, KV{"a": 1, "b": 3, RequestIDKey: "abc"}, CtxKV(ctx))
+
+	SetCorrelators()
+	assert.Equal(t, KV{"a": 1}, CtxKV(ctx))
+	assert.Equal(t, KV{}, CtxKV(context.Background()))
+}
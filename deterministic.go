@@ -0,0 +1,36 @@
+package llog
+
+import "time"
+
+// DeterministicTime is the time which all entries have when deterministic
+// output is enabled
+var DeterministicTime = time.Unix(0, 0).UTC()
+
+// SetDeterministic enables or disables deterministic output, which is useful
+// for comparing output against golden files byte-for-byte. When enabled every
+// entry's Time is DeterministicTime, timestamps aren't displayed, and the
+// TextFormatter and JSONFormatter always sort keys, regardless of how they're
+// configured. Both formatters already quote values the same way on every
+// platform. Sinks are given entries with DeterministicTime too, but otherwise
+// write them as they're configured to. It doesn't affect Loggers created by
+// New, see WithDeterministic.
+//
+// Callers and stack traces (see SetCallerLevels and SetStackTraces) include
+// file paths and line numbers, so shouldn't be enabled alongside it.
+func SetDeterministic(on bool) {
+	globalCore.apply(func() { globalCore.deterministic = on })
+}
+
+// deterministicFormatter returns the given Formatter configured to produce
+// deterministic output, if it's one of this package's
+func deterministicFormatter(f Formatter) Formatter {
+	switch ff := f.(type) {
+	case TextFormatter:
+		ff.NoSort = false
+		return ff
+	case JSONFormatter:
+		ff.NoSort = false
+		return ff
+	}
+	return f
+}
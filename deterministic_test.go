@@ -0,0 +1,40 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeterministic(t *T) {
+	buf := new(bytes.Buffer)
+	var times []string
+	l := New(
+		WithOutput(buf),
+		WithFormatter(JSONFormatter{NoSort: true}),
+		WithTimestamps(true),
+		WithDeterministic(),
+		WithHooks(NewHook(DebugLevel, func(e Entry) { times = append(times, e.Time.String()) })),
+	)
+	l.Info("foo", KV{"c": 1, "b": 2, "a": 3})
+	l.Flush()
+	assert.Equal(t, `{"level":"INFO","msg":"foo","a":3,"b":2,"c":1}`+"\n", buf.String())
+	assert.Equal(t, []string{"1970-01-01 00:00:00 +0000 UTC"}, times)
+
+	oldOut, oldFormatter, oldTS := Out, OutFormatter, DisplayTimestamp
+	defer func() {
+		SetOutput(oldOut)
+		SetFormatter(oldFormatter)
+		SetDisplayTimestamp(oldTS)
+		SetDeterministic(false)
+	}()
+	buf.Reset()
+	SetOutput(buf)
+	SetFormatter(TextFormatter{NoSort: true})
+	SetDisplayTimestamp(true)
+	SetDeterministic(true)
+	Info("foo", KV{"c": 1, "b": 2, "a": 3})
+	Flush()
+	assert.Equal(t, `~ INFO -- foo -- a="3" b="2" c="1"`+"\n", buf.String())
+}
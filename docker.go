@@ -0,0 +1,77 @@
+package llog
+
+import (
+	"bytes"
+	"io"
+	"time"
+)
+
+// DockerFormatter writes entries in the schema of Docker's json-file log
+// driver, so that logs written by something other than Docker, e.g. an agent
+// which runs containers itself, can be consumed by the scrapers which already
+// understand Docker's logs:
+//
+//	{"log":"~ INFO -- connected -- addr=\"1.2.3.4\"\n","stream":"stdout","time":"2020-01-02T03:04:05.123456789Z"}
+//
+// Each line written by the wrapped Formatter becomes its own record, the same
+// as Docker does for multi-line output.
+type DockerFormatter struct {
+	// Formatter is used to format the entry which becomes the "log" field.
+	// Defaults to TextFormatter.
+	Formatter Formatter
+
+	// Stream is written as the "stream" field. Defaults to "stdout".
+	Stream string
+}
+
+// Format implements the Formatter interface. The whole entry is written with a
+// single Write call.
+func (df DockerFormatter) Format(w io.Writer, e Entry, displayTS bool) error {
+	f := df.Formatter
+	if f == nil {
+		f = TextFormatter{}
+	}
+	stream := df.Stream
+	if stream == "" {
+		stream = "stdout"
+	}
+
+	inner := new(bytes.Buffer)
+	if err := f.Format(inner, e, displayTS); err != nil {
+		return err
+	}
+
+	bufp := getBuf()
+	defer putBuf(bufp)
+	buf := *bufp
+	ts := e.Time.UTC().AppendFormat(nil, time.RFC3339Nano)
+	for b := inner.Bytes(); len(b) > 0; {
+		line := b
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			line, b = b[:i+1], b[i+1:]
+		} else {
+			b = nil
+		}
+		buf = append(buf, `{"log":`...)
+		buf = appendJSONString(buf, string(line))
+		buf = append(buf, `,"stream":`...)
+		buf = appendJSONString(buf, stream)
+		buf = append(buf, `,"time":"`...)
+		buf = append(buf, ts...)
+		buf = append(buf, '"', '}', '\n')
+	}
+
+	*bufp = buf
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReservedKeys implements the ReservedKeyer interface. Since entries' KV are
+// only written within what the wrapped Formatter writes, those are the wrapped
+// Formatter's reserved keys, if any.
+func (df DockerFormatter) ReservedKeys() []string {
+	if rk, ok := df.Formatter.(ReservedKeyer); ok {
+		return rk.ReservedKeys()
+	}
+	return nil
+}
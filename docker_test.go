@@ -0,0 +1,48 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerFormatter(t *T) {
+	buf := new(bytes.Buffer)
+	e := Entry{
+		Time:  time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		Level: InfoLevel,
+		Msg:   "foo",
+		KV:    KV{"a": "<b>"},
+	}
+	require.NoError(t, DockerFormatter{}.Format(buf, e, false))
+	assert.Equal(t, `{"log":"~ INFO -- foo -- a=\"\u003cb\u003e\"\n","stream":"stdout","time":"2020-01-02T03:04:05.000000006Z"}`+"\n", buf.String())
+
+	// every line of the wrapped Formatter's output is its own record
+	buf.Reset()
+	e.KV = KV{"sql": "SELECT *\nFROM users"}
+	df := DockerFormatter{
+		Formatter: TextFormatter{Multiline: MultilineBlock},
+		Stream:    "stderr",
+	}
+	require.NoError(t, df.Format(buf, e, false))
+	assert.Equal(t,
+		`{"log":"~ INFO -- foo\n","stream":"stderr","time":"2020-01-02T03:04:05.000000006Z"}`+"\n"+
+			`{"log":"\tsql:\n","stream":"stderr","time":"2020-01-02T03:04:05.000000006Z"}`+"\n"+
+			`{"log":"\t\tSELECT *\n","stream":"stderr","time":"2020-01-02T03:04:05.000000006Z"}`+"\n"+
+			`{"log":"\t\tFROM users\n","stream":"stderr","time":"2020-01-02T03:04:05.000000006Z"}`+"\n",
+		buf.String())
+
+	f, err := parseFormat("docker")
+	require.NoError(t, err)
+	assert.Equal(t, DockerFormatter{}, f)
+
+	s, err := OutputConfig{Type: "stderr", Format: "docker", LevelNames: map[string]string{"warn": "WARNING"}}.sink(nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, DockerFormatter{
+		Formatter: TextFormatter{LevelNames: LevelNames{WarnLevel: "WARNING"}},
+		Stream:    "stderr",
+	}, s.(WriterSink).Formatter)
+}
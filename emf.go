@@ -0,0 +1,69 @@
+package llog
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// EMFKey is the key which CloudWatch's Embedded Metric Format puts its metadata
+// under, see EMF
+const EMFKey = "_aws"
+
+// Metric is a single value of a CloudWatch metric, see EMF
+type Metric struct {
+	Name  string
+	Value float64
+
+	// Unit is the CloudWatch unit of the metric, e.g. "Milliseconds", "Count",
+	// or "Bytes". It may be left empty.
+	Unit string
+}
+
+// EMF returns a KV which, when written using the JSONFormatter, turns the entry
+// into one in CloudWatch's Embedded Metric Format. CloudWatch Logs then
+// publishes the given metrics in the given namespace, with the given
+// dimensions, without the CloudWatch metrics API having to be called, which is
+// useful for Lambda and ECS services whose logs already go to CloudWatch:
+//
+//	llog.Info("handled request", llog.EMF("MyService",
+//		llog.KV{"route": "/foo"},
+//		llog.Metric{Name: "latency", Value: 12, Unit: "Milliseconds"},
+//	))
+//
+// Dimension values are written as strings. Metric and dimension names become
+// keys of the entry, so shouldn't collide with any other keys, including
+// reserved ones like "msg" (see SetReservedKeyPolicy).
+func EMF(namespace string, dimensions KV, metrics ...Metric) KV {
+	kv := make(KV, len(dimensions)+len(metrics)+1)
+	dims := make([]string, 0, len(dimensions))
+	for k, v := range dimensions {
+		dims = append(dims, k)
+		kv[k] = fmt.Sprint(resolveMarshalers(v))
+	}
+	sort.Strings(dims)
+	defs := make([]KV, len(metrics))
+	for i, m := range metrics {
+		defs[i] = KV{"Name": m.Name}
+		if m.Unit != "" {
+			defs[i]["Unit"] = m.Unit
+		}
+		kv[m.Name] = m.Value
+	}
+
+	kv[EMFKey] = KV{
+		"Timestamp": globalCore.now().UnixNano() / int64(time.Millisecond),
+		"CloudWatchMetrics": []KV{{
+			"Namespace":  namespace,
+			"Dimensions": [][]string{dims},
+			"Metrics":    defs,
+		}},
+	}
+	return kv
+}
+
+// LogMetrics writes an InfoLevel entry which holds nothing but the given
+// metrics, see EMF
+func LogMetrics(namespace string, dimensions KV, metrics ...Metric) {
+	Info("metrics", EMF(namespace, dimensions, metrics...))
+}
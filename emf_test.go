@@ -0,0 +1,45 @@
+package llog
+
+import (
+	"bytes"
+	"encoding/json"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEMF(t *T) {
+	defer SetClock(nil)
+	SetClock(func() time.Time { return time.Unix(1600000000, 123456789) })
+
+	kv := EMF("MyService", KV{"route": "/foo", "status": 200},
+		Metric{Name: "latency", Value: 12.5, Unit: "Milliseconds"},
+		Metric{Name: "hits", Value: 1},
+	)
+	buf := new(bytes.Buffer)
+	require.NoError(t, JSONFormatter{}.Format(buf, Entry{Level: InfoLevel, Msg: "foo", KV: kv}, false))
+
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, map[string]interface{}{
+		"level":   "INFO",
+		"msg":     "foo",
+		"route":   "/foo",
+		"status":  "200",
+		"latency": 12.5,
+		"hits":    float64(1),
+		"_aws": map[string]interface{}{
+			"Timestamp": float64(1600000000123),
+			"CloudWatchMetrics": []interface{}{map[string]interface{}{
+				"Namespace":  "MyService",
+				"Dimensions": []interface{}{[]interface{}{"route", "status"}},
+				"Metrics": []interface{}{
+					map[string]interface{}{"Name": "latency", "Unit": "Milliseconds"},
+					map[string]interface{}{"Name": "hits"},
+				},
+			}},
+		},
+	}, m)
+}
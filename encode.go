@@ -0,0 +1,305 @@
+package llog
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// appendTextValue appends the value to buf as a quoted string, the same as
+// strconv.QuoteToASCII(textValue(v)) would (or strconv.Quote if ascii is
+// false), but without going through fmt for common types. The exception is nil
+// when the NilPolicy is NilNull, which is appended as an unquoted null.
+func appendTextValue(buf []byte, v interface{}, ascii bool) []byte {
+	switch vv := v.(type) {
+	case nil:
+		if getNilPolicy().mode == nilNull {
+			return append(buf, "null"...)
+		}
+		return appendQuote(buf, textValue(v), ascii)
+	case string:
+		return appendQuote(buf, strings.Replace(vv, `"`, `'`, -1), ascii)
+	case bool:
+		buf = append(buf, '"')
+		buf = strconv.AppendBool(buf, vv)
+	case int:
+		buf = append(buf, '"')
+		buf = strconv.AppendInt(buf, int64(vv), 10)
+	case int8:
+		buf = append(buf, '"')
+		buf = strconv.AppendInt(buf, int64(vv), 10)
+	case int16:
+		buf = append(buf, '"')
+		buf = strconv.AppendInt(buf, int64(vv), 10)
+	case int32:
+		buf = append(buf, '"')
+		buf = strconv.AppendInt(buf, int64(vv), 10)
+	case int64:
+		buf = append(buf, '"')
+		buf = strconv.AppendInt(buf, vv, 10)
+	case uint:
+		buf = append(buf, '"')
+		buf = strconv.AppendUint(buf, uint64(vv), 10)
+	case uint8:
+		buf = append(buf, '"')
+		buf = strconv.AppendUint(buf, uint64(vv), 10)
+	case uint16:
+		buf = append(buf, '"')
+		buf = strconv.AppendUint(buf, uint64(vv), 10)
+	case uint32:
+		buf = append(buf, '"')
+		buf = strconv.AppendUint(buf, uint64(vv), 10)
+	case uint64:
+		buf = append(buf, '"')
+		buf = strconv.AppendUint(buf, vv, 10)
+	case float32:
+		buf = append(buf, '"')
+		buf = strconv.AppendFloat(buf, float64(vv), 'g', -1, 32)
+	case float64:
+		buf = append(buf, '"')
+		buf = strconv.AppendFloat(buf, vv, 'g', -1, 64)
+	case time.Duration:
+		return appendTextDuration(buf, vv, ascii)
+	case time.Time:
+		if layout := getValueFormats().timeLayout; layout != "" {
+			return appendQuote(buf, vv.Format(layout), ascii)
+		}
+		return appendQuote(buf, textValue(v), ascii)
+	case Marshaler:
+		return appendTextValue(buf, marshalLog(vv), ascii)
+	case fmt.Formatter:
+		return appendQuote(buf, textValue(v), ascii)
+	case error:
+		return appendQuote(buf, strings.Replace(errorString(vv), `"`, `'`, -1), ascii)
+	default:
+		return appendQuote(buf, textValue(v), ascii)
+	}
+	return append(buf, '"')
+}
+
+// encodedKV caches the encoded values of a Logger's bound KV, so that they're
+// encoded once per Formatter rather than once per entry. Only values of basic
+// types are cached, since those are the only ones which can be safely compared
+// to check that an entry's value is still the bound one, rather than having
+// been replaced by the log call, a Processor, redaction, or scrubbing.
+type encodedKV struct {
+	kv                               KV
+	textOnce, textUTF8Once, jsonOnce sync.Once
+	text, textUTF8, json             map[string][]byte
+}
+
+func (ekv *encodedKV) encode(fn func([]byte, interface{}) []byte) map[string][]byte {
+	m := make(map[string][]byte, len(ekv.kv))
+	for k, v := range ekv.kv {
+		if isBasic(v) {
+			m[k] = fn(nil, v)
+		}
+	}
+	return m
+}
+
+// cached returns the cached encoding of the value, if the value is the one
+// bound to the key
+func cached(cache map[string][]byte, kv KV, k string, v interface{}) ([]byte, bool) {
+	b, ok := cache[k]
+	// the bound value is of a basic type so the comparison can't panic
+	return b, ok && kv[k] == v
+}
+
+// appendText is like appendTextValue, but uses the cached encoding if the
+// value is the one bound to the key. ekv may be nil.
+func (ekv *encodedKV) appendText(buf []byte, k string, v interface{}, ascii bool) []byte {
+	if ekv != nil {
+		once, cache := &ekv.textOnce, &ekv.text
+		if !ascii {
+			once, cache = &ekv.textUTF8Once, &ekv.textUTF8
+		}
+		once.Do(func() {
+			*cache = ekv.encode(func(buf []byte, v interface{}) []byte {
+				return appendTextValue(buf, v, ascii)
+			})
+		})
+		if b, ok := cached(*cache, ekv.kv, k, v); ok {
+			return append(buf, b...)
+		}
+	}
+	return appendTextValue(buf, v, ascii)
+}
+
+// appendQuote appends the string quoted using strconv.QuoteToASCII if ascii is
+// true, or strconv.Quote otherwise
+func appendQuote(buf []byte, s string, ascii bool) []byte {
+	if ascii {
+		return strconv.AppendQuoteToASCII(buf, s)
+	}
+	return strconv.AppendQuote(buf, s)
+}
+
+// appendJSON is like the package-level appendJSON, but uses the cached encoding
+// if the value is the one bound to the key. ekv may be nil.
+func (ekv *encodedKV) appendJSON(buf []byte, k string, v interface{}) []byte {
+	if ekv != nil {
+		ekv.jsonOnce.Do(func() { ekv.json = ekv.encode(appendJSON) })
+		if b, ok := cached(ekv.json, ekv.kv, k, v); ok {
+			return append(buf, b...)
+		}
+	}
+	return appendJSON(buf, v)
+}
+
+func isBasic(v interface{}) bool {
+	switch v.(type) {
+	case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16,
+		uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
+
+// errorString returns the error's Error string, or whatever fmt would print for
+// it if Error panics, e.g. because it's a nil pointer
+func errorString(err error) (s string) {
+	defer func() {
+		if recover() != nil {
+			s = fmt.Sprint(err)
+		}
+	}()
+	return err.Error()
+}
+
+// appendJSON appends the value to buf as JSON, the same as json.Marshal would
+// after converting it with jsonValue, but without going through encoding/json
+// for common types. Values which can't be encoded are appended as a string
+// using fmt.Sprint.
+func appendJSON(buf []byte, v interface{}) []byte {
+	switch vv := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, vv)
+	case bool:
+		return strconv.AppendBool(buf, vv)
+	case int:
+		return strconv.AppendInt(buf, int64(vv), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(vv), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(vv), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(vv), 10)
+	case int64:
+		return strconv.AppendInt(buf, vv, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(vv), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(vv), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(vv), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(vv), 10)
+	case uint64:
+		return strconv.AppendUint(buf, vv, 10)
+	case float32:
+		return appendJSONFloat(buf, float64(vv), 32)
+	case float64:
+		return appendJSONFloat(buf, vv, 64)
+	case time.Duration:
+		return appendJSONDuration(buf, vv)
+	case time.Time:
+		if layout := getValueFormats().timeLayout; layout != "" {
+			return appendJSONString(buf, vv.Format(layout))
+		}
+	case Marshaler:
+		return appendJSON(buf, marshalLog(vv))
+	case json.Marshaler:
+	case error:
+		return appendJSONString(buf, errorString(vv))
+	}
+
+	b, err := json.Marshal(jsonValue(v))
+	if err != nil {
+		return appendJSONString(buf, fmt.Sprint(v))
+	}
+	return append(buf, b...)
+}
+
+// appendJSONFloat appends the float the same way encoding/json does, or as a
+// string if it's NaN or infinite, since JSON can't represent those
+func appendJSONFloat(buf []byte, f float64, bits int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(buf); n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends the string as a JSON string, escaped the same way
+// encoding/json does (including HTML characters)
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				buf = append(buf, '\\', b)
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+		} else if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+		} else {
+			i += size
+			continue
+		}
+		i += size
+		start = i
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
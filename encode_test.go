@@ -0,0 +1,92 @@
+package llog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nilErr struct{ msg string }
+
+func (e *nilErr) Error() string { return e.msg }
+
+type jsonErr struct{}
+
+func (jsonErr) Error() string                { return "jsonErr" }
+func (jsonErr) MarshalJSON() ([]byte, error) { return []byte(`{"json":"err"}`), nil }
+
+func encodeTestValues() []interface{} {
+	return []interface{}{
+		nil, "", "foo", `a "quoted" string`, "tab\tnew\nline\r\x00\x1f",
+		"<html>&amp;", "uni©ode ☃", "line sep ",
+		"\b\f\\", true, false,
+		0, -1, int8(-8), int16(16), int32(-32), int64(math.MaxInt64), uint(1),
+		uint8(8), uint16(16), uint32(32), uint64(math.MaxUint64),
+		0.0, 1.5, -2.25, 1e-7, 1e21, 123456789.0, 1e20, float32(1.1),
+		float32(1e-7), math.NaN(), math.Inf(1), math.Inf(-1),
+		5 * time.Second, errors.New(`some "error"`), jsonErr{},
+		time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), []int{1, 2}, struct{ A int }{1},
+		KV{"a": 1}, map[string]bool{"b": true},
+	}
+}
+
+func TestAppendTextValue(t *T) {
+	for _, v := range encodeTestValues() {
+		expected := strconv.QuoteToASCII(textValue(v))
+		assert.Equal(t, expected, string(appendTextValue(nil, v, true)), "%#v", v)
+	}
+	assert.Equal(t, `"<nil>"`, string(appendTextValue(nil, (*nilErr)(nil), true)))
+}
+
+func TestAppendJSON(t *T) {
+	for _, v := range encodeTestValues() {
+		expected, err := json.Marshal(jsonValue(v))
+		if err != nil {
+			expected, _ = json.Marshal(fmt.Sprint(v))
+		}
+		assert.Equal(t, string(expected), string(appendJSON(nil, v)), "%#v", v)
+	}
+	assert.Equal(t, `"invalid \ufffd utf8"`, string(appendJSON(nil, "invalid \xff utf8")))
+	assert.Equal(t, `"\u003cnil\u003e"`, string(appendJSON(nil, (*nilErr)(nil))))
+}
+
+func TestEncodedKV(t *T) {
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithFormatter(JSONFormatter{})).With(KV{
+		"user":     "bob",
+		"n":        1,
+		"password": "hunter2",
+		"nested":   KV{"a": 1},
+	})
+
+	l.Info("foo")
+	l.Info("bar", KV{"n": 2})
+	l.Flush()
+	assert.Equal(t,
+		`{"level":"INFO","msg":"foo","n":1,"nested":{"a":1},"password":"[REDACTED]","user":"bob"}`+"\n"+
+			`{"level":"INFO","msg":"bar","n":2,"nested":{"a":1},"password":"[REDACTED]","user":"bob"}`+"\n",
+		buf.String(),
+	)
+	// only the basic values are cached
+	assert.Equal(t, map[string][]byte{
+		"user":     []byte(`"bob"`),
+		"n":        []byte(`1`),
+		"password": []byte(`"hunter2"`),
+	}, l.enc.json)
+	assert.Nil(t, l.enc.text)
+
+	buf.Reset()
+	e := Entry{Level: InfoLevel, Msg: "baz", KV: l.KV(), bound: l.enc}
+	e.KV["n"] = 3
+	require.NoError(t, TextFormatter{}.Format(buf, e, false))
+	assert.Equal(t, `~ INFO -- baz -- n="3" nested.a="1" password="hunter2" user="bob"`+"\n", buf.String())
+	assert.Len(t, l.enc.text, 3)
+}
@@ -0,0 +1,162 @@
+package llog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envOutFile is the file opened for LLOG_OUTPUT, which is closed once it's been
+// replaced by a later ConfigureFromEnv. Only accessed from the main loop.
+var envOutFile *FileWriter
+
+// ConfigureFromEnv configures logging using the following environment
+// variables, any of which may be left unset:
+//
+//	LLOG_LEVEL      minimum level to log, e.g. "debug" (see SetLevelFromString)
+//	LLOG_FORMAT     "text", "json", "color", "kubernetes", "docker", or "systemd" (see OutFormatter)
+//	LLOG_TIMESTAMP  whether to display timestamps, e.g. "true" (see DisplayTimestamp)
+//	LLOG_OUTPUT     "stdout", "stderr", or the path of a file to append to (see Out and OpenFile)
+//	LLOG_CALLER     comma separated levels to annotate with the caller (see SetCallerLevels)
+//	LLOG_STACK      minimum level to capture stack traces for (see SetStackTraces)
+//	LLOG_REDACT     comma separated keys to redact (see SetRedactedKeys)
+//	LLOG_KV         comma separated key=value pairs to include in every entry (see SetGlobalKV)
+//	LLOG_STRICT     whether to validate every entry, e.g. "true" (see SetStrict)
+//
+// It's generally called at the start of main, but like ApplyConfig it's safe to
+// call at any time. If any variable can't be interpreted an error is returned,
+// and none of the configuration is applied.
+func ConfigureFromEnv() error {
+	var fns []func()
+	var err error
+	env := func(name string, fn func(string) (func(), error)) {
+		v, ok := os.LookupEnv(name)
+		if !ok || err != nil {
+			return
+		}
+		var apply func()
+		if apply, err = fn(strings.TrimSpace(v)); err != nil {
+			err = fmt.Errorf("invalid %s: %w", name, err)
+			return
+		}
+		fns = append(fns, apply)
+	}
+
+	env("LLOG_LEVEL", func(v string) (func(), error) {
+		l, err := parseLevel(v)
+		return func() { SetLevel(l) }, err
+	})
+	env("LLOG_FORMAT", func(v string) (func(), error) {
+		f, err := parseFormat(v)
+		return func() { OutFormatter = f }, err
+	})
+	env("LLOG_TIMESTAMP", func(v string) (func(), error) {
+		b, err := strconv.ParseBool(v)
+		return func() { DisplayTimestamp = b }, err
+	})
+	env("LLOG_CALLER", func(v string) (func(), error) {
+		lvls, err := parseLevels(v)
+		return func() { SetCallerLevels(lvls...) }, err
+	})
+	env("LLOG_STACK", func(v string) (func(), error) {
+		l, err := parseLevel(v)
+		return func() { SetStackTraces(&StackTraceOptions{Level: l}) }, err
+	})
+	env("LLOG_REDACT", func(v string) (func(), error) {
+		keys := splitList(v)
+		return func() { SetRedactedKeys(keys...) }, nil
+	})
+	env("LLOG_STRICT", func(v string) (func(), error) {
+		b, err := strconv.ParseBool(v)
+		return func() { SetStrict(b) }, err
+	})
+	env("LLOG_KV", func(v string) (func(), error) {
+		kv := KV{}
+		for _, pair := range splitList(v) {
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(k) == "" {
+				return nil, fmt.Errorf("%q isn't a key=value pair", pair)
+			}
+			kv[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+		return func() { SetGlobalKV(kv) }, nil
+	})
+
+	// done last so that the file is only opened if everything else was valid
+	env("LLOG_OUTPUT", func(v string) (func(), error) {
+		var out io.Writer
+		var fw *FileWriter
+		switch strings.ToLower(v) {
+		case "stdout":
+			out = os.Stdout
+		case "stderr":
+			out = os.Stderr
+		default:
+			var err error
+			if fw, err = OpenFile(v); err != nil {
+				return nil, err
+			}
+			out = fw
+		}
+		return func() {
+			Out = out
+			if envOutFile != nil {
+				envOutFile.Close()
+			}
+			envOutFile = fw
+		}, nil
+	})
+
+	if err != nil {
+		return err
+	}
+	globalCore.apply(func() {
+		for _, fn := range fns {
+			fn()
+		}
+	})
+	return nil
+}
+
+func parseFormat(s string) (Formatter, error) {
+	switch strings.ToLower(s) {
+	case "text":
+		return TextFormatter{}, nil
+	case "json":
+		return JSONFormatter{}, nil
+	case "color":
+		return TextFormatter{Colors: DefaultColors}, nil
+	case "kubernetes":
+		return KubernetesFormatter(), nil
+	case "docker":
+		return DockerFormatter{}, nil
+	case "systemd":
+		return SystemdFormatter{}, nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", s)
+}
+
+func parseLevels(s string) ([]Level, error) {
+	strs := splitList(s)
+	lvls := make([]Level, len(strs))
+	for i, str := range strs {
+		var err error
+		if lvls[i], err = parseLevel(str); err != nil {
+			return nil, err
+		}
+	}
+	return lvls, nil
+}
+
+// splitList splits a comma separated list, ignoring empty elements
+func splitList(s string) []string {
+	var strs []string
+	for _, str := range strings.Split(s, ",") {
+		if str = strings.TrimSpace(str); str != "" {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
@@ -0,0 +1,74 @@
+package llog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureFromEnv(t *T) {
+	oldOut, oldFormatter, oldTS := Out, OutFormatter, DisplayTimestamp
+	defer func() {
+		Out, OutFormatter, DisplayTimestamp = oldOut, oldFormatter, oldTS
+		SetLevel(InfoLevel)
+		SetCallerLevels()
+		SetStackTraces(nil)
+		SetRedactedKeys(DefaultRedactedKeys...)
+		SetGlobalKV(nil)
+		SetStrict(false)
+	}()
+
+	path := filepath.Join(t.TempDir(), "out.log")
+	t.Setenv("LLOG_LEVEL", "warn")
+	t.Setenv("LLOG_FORMAT", "json")
+	t.Setenv("LLOG_TIMESTAMP", "false")
+	t.Setenv("LLOG_OUTPUT", path)
+	t.Setenv("LLOG_CALLER", "error, fatal")
+	t.Setenv("LLOG_REDACT", "secret")
+	t.Setenv("LLOG_KV", "env=prod, region = us-east-1")
+	t.Setenv("LLOG_STRICT", "true")
+
+	// an invalid variable should prevent anything from being applied
+	t.Setenv("LLOG_STACK", "loud")
+	assert.EqualError(t, ConfigureFromEnv(), `invalid LLOG_STACK: unknown log level "loud"`)
+	assert.Equal(t, InfoLevel, GetLevel())
+	assert.Equal(t, oldFormatter, OutFormatter)
+
+	t.Setenv("LLOG_STACK", "fatal")
+	require.NoError(t, ConfigureFromEnv())
+	assert.Equal(t, WarnLevel, GetLevel())
+	assert.Equal(t, JSONFormatter{}, OutFormatter)
+	assert.False(t, DisplayTimestamp)
+	assert.Equal(t, uint32(1<<ErrorLevel|1<<FatalLevel), callerLevels)
+	assert.Equal(t, FatalLevel, stackTraceOpts.Level)
+	assert.Equal(t, map[string]bool{"secret": true}, redactedKeys)
+	assert.Equal(t, KV{"env": "prod", "region": "us-east-1"}, GetGlobalKV())
+	assert.Equal(t, int32(1), strict)
+
+	Warn("foo", KV{"secret": "shh"})
+	Flush()
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"level":"WARN","msg":"foo","env":"prod","region":"us-east-1","secret":"[REDACTED]"}`+"\n", string(b))
+
+	// the file is reopened by Reopen
+	rotated := path + ".1"
+	require.NoError(t, os.Rename(path, rotated))
+	require.NoError(t, Reopen())
+	Warn("bar")
+	Flush()
+	b, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"level":"WARN","msg":"bar","env":"prod","region":"us-east-1"}`+"\n", string(b))
+
+	// and closed once it's been replaced
+	fw := Out.(*FileWriter)
+	t.Setenv("LLOG_OUTPUT", "stdout")
+	require.NoError(t, ConfigureFromEnv())
+	assert.Equal(t, os.Stdout, Out)
+	assert.ErrorIs(t, fw.Close(), os.ErrClosed)
+}
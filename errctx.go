@@ -2,12 +2,17 @@ package llog
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 
 	"github.com/levenlabs/errctx"
 )
 
 type kvKey int
 
+type loggerKey int
+
 // ErrWithKV embeds the merging of a set of KVs into an error and Marks the
 // function for convenience, returning a new error instance. If the error
 // already has a KV embedded in it then the returned error will have the
@@ -43,6 +48,35 @@ func ErrKV(err error) KV {
 	return kv
 }
 
+// Err returns a KV describing the given error, for consistent error logging. It
+// contains everything ErrKV does, as well as the key "errType", the type of the
+// innermost error in err's chain. If err wraps other errors (see errors.Unwrap)
+// the key "errChain" will be set as well, to the messages of the wrapped errors
+// from outermost to innermost. Returns empty KV if err is nil.
+func Err(err error) KV {
+	if err == nil {
+		return KV{}
+	}
+	kv := ErrKV(err)
+
+	var chain []string
+	prev, inner := err.Error(), err
+	for e := errors.Unwrap(err); e != nil; e = errors.Unwrap(e) {
+		inner = e
+		// wrappers which don't change the message, like errctx, aren't
+		// interesting
+		if msg := e.Error(); msg != prev {
+			chain = append(chain, msg)
+			prev = msg
+		}
+	}
+	kv["errType"] = fmt.Sprintf("%T", inner)
+	if len(chain) > 0 {
+		kv["errChain"] = chain
+	}
+	return kv
+}
+
 // CtxWithKV embeds a KV into a Context, returning a new Context instance. If
 // the Context already has a KV embedded in it then the returned context's KV
 // will be the merging of the two.
@@ -55,11 +89,78 @@ func CtxWithKV(ctx context.Context, kvs ...KV) context.Context {
 	return context.WithValue(ctx, kvKey(0), kv)
 }
 
-// CtxKV returns a copy of the KV embedded in the Context by CtxWithKV
+// CtxKV returns a copy of the KV embedded in the Context by CtxWithKV, merged
+// on top of the KV returned by every registered Correlator, which by default
+// includes the request ID embedded by CtxWithRequestID (if any), and on top of
+// the Context's pprof labels chosen with SetCtxPprofLabels
 func CtxKV(ctx context.Context) KV {
-	kv := ctx.Value(kvKey(0))
-	if kv == nil {
-		return KV{}
+	kv, _ := ctx.Value(kvKey(0)).(KV)
+	var kvs []KV
+	if lkv := pprofLabelsKV(ctx); len(lkv) > 0 {
+		kvs = append(kvs, lkv)
+	}
+	for _, c := range getCorrelators() {
+		if ckv := c.Correlate(ctx); len(ckv) > 0 {
+			kvs = append(kvs, ckv)
+		}
+	}
+	if len(kvs) == 0 {
+		if kv == nil {
+			return KV{}
+		}
+		return kv
+	}
+	return Merge(append(kvs, kv)...)
+}
+
+// AddCtxKVFunc registers a function which CtxKV, and so CtxLogger, will call to
+// get KV from a Context, in addition to the KV embedded by CtxWithKV. It's the
+// same as calling AddCorrelator with a CorrelatorFunc.
+func AddCtxKVFunc(fn func(context.Context) KV) {
+	AddCorrelator(CorrelatorFunc(fn))
+}
+
+var ctxProcessorFuncs []func(context.Context) Processor
+var ctxProcessorFuncsLock sync.RWMutex
+
+// AddCtxProcessorFunc registers a function which CtxLogger will call to get a
+// Processor for the Logger it returns, which is useful for Processors which need
+// something from the Context, e.g. the current span. The function may return
+// nil.
+func AddCtxProcessorFunc(fn func(context.Context) Processor) {
+	ctxProcessorFuncsLock.Lock()
+	defer ctxProcessorFuncsLock.Unlock()
+	// copy so that a slice being read by CtxLogger is never modified
+	ctxProcessorFuncs = append(ctxProcessorFuncs[:len(ctxProcessorFuncs):len(ctxProcessorFuncs)], fn)
+}
+
+func getCtxProcessorFuncs() []func(context.Context) Processor {
+	ctxProcessorFuncsLock.RLock()
+	defer ctxProcessorFuncsLock.RUnlock()
+	return ctxProcessorFuncs
+}
+
+// CtxWithLogger embeds a Logger into a Context, returning a new Context
+// instance.
+func CtxWithLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey(0), l)
+}
+
+// CtxLogger returns the Logger embedded in the Context by CtxWithLogger, with
+// the KV embedded by CtxWithKV (if any) bound on top of it. If no Logger was
+// embedded then one with only the CtxKV bound is returned. This will never
+// return nil. Any Processors returned by functions registered with
+// AddCtxProcessorFunc are added to the returned Logger.
+func CtxLogger(ctx context.Context) *Logger {
+	l, _ := ctx.Value(loggerKey(0)).(*Logger)
+	if l == nil {
+		l = new(Logger)
+	}
+	l = l.With(CtxKV(ctx))
+	for _, fn := range getCtxProcessorFuncs() {
+		if p := fn(ctx); p != nil {
+			l = l.WithProcessors(p)
+		}
 	}
-	return kv.(KV)
+	return l
 }
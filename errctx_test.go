@@ -66,3 +66,44 @@ func TestCtxKV(t *T) {
 	assert.Equal(t, KV{"a": "a", "b": "b"}, CtxKV(ctx3))
 	assert.Equal(t, KV{"a": "a", "b": "bb"}, CtxKV(ctx4))
 }
+
+func TestCtxLogger(t *T) {
+	ctx := context.Background()
+	assert.Equal(t, KV{}, CtxLogger(ctx).KV())
+
+	ctx2 := CtxWithLogger(ctx, With(KV{"a": "a"}))
+	assert.Equal(t, KV{}, CtxLogger(ctx).KV())
+	assert.Equal(t, KV{"a": "a"}, CtxLogger(ctx2).KV())
+
+	// KV embedded separately should be bound on top of the Logger's
+	ctx3 := CtxWithKV(ctx2, KV{"a": "aa", "b": "b"})
+	assert.Equal(t, KV{"a": "a"}, CtxLogger(ctx2).KV())
+	assert.Equal(t, KV{"a": "aa", "b": "b"}, CtxLogger(ctx3).KV())
+}
+
+type wrappedErr struct {
+	msg string
+	err error
+}
+
+func (we wrappedErr) Error() string { return we.msg + ": " + we.err.Error() }
+func (we wrappedErr) Unwrap() error { return we.err }
+
+func TestErr(t *T) {
+	assert.Equal(t, KV{}, Err(nil))
+
+	err := errors.New("foo")
+	assert.Equal(t, KV{"err": "foo", "errType": "*errors.errorString"}, Err(err))
+
+	err = wrappedErr{msg: "bar", err: wrappedErr{msg: "baz", err: err}}
+	assert.Equal(t, KV{
+		"err":      "bar: baz: foo",
+		"errType":  "*errors.errorString",
+		"errChain": []string{"baz: foo", "foo"},
+	}, Err(err))
+
+	kv := Err(ErrWithKV(err, KV{"a": "a"}))
+	assert.Equal(t, "bar: baz: foo", kv["err"])
+	assert.Equal(t, "a", kv["a"])
+	assert.Equal(t, "errctx_test.go:105", kv["source"])
+}
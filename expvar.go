@@ -0,0 +1,46 @@
+package llog
+
+import (
+	"expvar"
+	"strings"
+	"time"
+)
+
+// PublishExpvar publishes the package-level Stats under the given name using
+// expvar, so that they're shown by expvar's handler (/debug/vars) along with
+// any other debug variables. Like expvar.Publish it panics if the name is
+// already in use. The published value looks like:
+//
+//	{
+//		"written": {"debug": 0, "info": 120, "warn": 3, "error": 1, "fatal": 0},
+//		"dropped": 0,
+//		"write_errors": 1,
+//		"last_write_error": "could not write to Out: broken pipe",
+//		"last_write_error_time": "2020-01-02T03:04:05Z",
+//		"queue_depth": 0,
+//		"queue_size": 0
+//	}
+func PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return expvarStats(Stats())
+	}))
+}
+
+func expvarStats(s LoggerStats) map[string]interface{} {
+	written := make(map[string]uint64, len(s.Written))
+	for l, n := range s.Written {
+		written[strings.ToLower(l.String())] = n
+	}
+	m := map[string]interface{}{
+		"written":      written,
+		"dropped":      s.Dropped,
+		"write_errors": s.WriteErrors,
+		"queue_depth":  s.QueueDepth,
+		"queue_size":   s.QueueSize,
+	}
+	if s.LastWriteError != nil {
+		m["last_write_error"] = errorString(s.LastWriteError)
+		m["last_write_error_time"] = s.LastWriteErrorTime.Format(time.RFC3339Nano)
+	}
+	return m
+}
@@ -0,0 +1,34 @@
+package llog
+
+import (
+	"encoding/json"
+	"expvar"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishExpvar(t *T) {
+	PublishExpvar("llog_test")
+	v := expvar.Get("llog_test")
+	require.NotNil(t, v)
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(v.String()), &m))
+	assert.Contains(t, m, "written")
+	assert.Contains(t, m, "queue_depth")
+
+	l := New(WithOutput(errWriter{}), WithWriteErrorHandler(IgnoreWriteErrors))
+	start := time.Now()
+	l.Info("foo")
+	l.Flush()
+	s := l.Stats()
+	assert.EqualError(t, s.LastWriteError, "could not write to Out: can't write")
+	assert.False(t, s.LastWriteErrorTime.Before(start))
+
+	m = expvarStats(s)
+	assert.Equal(t, map[string]uint64{"debug": 0, "info": 1, "warn": 0, "error": 0, "fatal": 0}, m["written"])
+	assert.Equal(t, uint64(1), m["write_errors"])
+	assert.Equal(t, "could not write to Out: can't write", m["last_write_error"])
+}
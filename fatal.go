@@ -0,0 +1,129 @@
+package llog
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// FatalMode determines what Fatal does once its entry has been written
+type FatalMode int
+
+// All the possible FatalModes
+const (
+	// FatalExit runs the exit hooks and then exits the process with the exit
+	// code. This is the default.
+	FatalExit FatalMode = iota
+
+	// FatalPanic panics with a *FatalError, rather than exiting. Exit hooks
+	// aren't run. This is useful for tests which exercise Fatal paths.
+	FatalPanic
+
+	// FatalReturn returns from Fatal as if it were any other log function.
+	// Exit hooks aren't run.
+	FatalReturn
+)
+
+// FatalError is the value Fatal panics with when the FatalMode is FatalPanic
+type FatalError struct {
+	Msg string
+	KV  KV
+}
+
+// Error implements the error interface
+func (fe *FatalError) Error() string {
+	return "fatal: " + fe.Msg
+}
+
+// DefaultExitHookTimeout is the default for SetExitHookTimeout
+const DefaultExitHookTimeout = 5 * time.Second
+
+var fatalState = struct {
+	sync.Mutex
+	mode     FatalMode
+	code     int
+	hooks    []func()
+	timeout  time.Duration
+	exitFunc func(int)
+}{
+	code:     1,
+	timeout:  DefaultExitHookTimeout,
+	exitFunc: os.Exit,
+}
+
+// SetFatalMode sets what Fatal does once its entry has been written, see
+// FatalMode
+func SetFatalMode(m FatalMode) {
+	fatalState.Lock()
+	defer fatalState.Unlock()
+	fatalState.mode = m
+}
+
+// SetExitCode sets the code the process exits with when Fatal is called.
+// Defaults to 1.
+func SetExitCode(code int) {
+	fatalState.Lock()
+	defer fatalState.Unlock()
+	fatalState.code = code
+}
+
+// AddExitHook registers a function which will be called when Fatal exits the
+// process, after the entry has been written but before os.Exit is called.
+// Hooks are called in the order they were added. If they haven't all returned
+// within the exit hook timeout (see SetExitHookTimeout) the process exits
+// anyway.
+func AddExitHook(fn func()) {
+	fatalState.Lock()
+	defer fatalState.Unlock()
+	fatalState.hooks = append(fatalState.hooks[:len(fatalState.hooks):len(fatalState.hooks)], fn)
+}
+
+// SetExitHookTimeout sets how long Fatal waits for the exit hooks to all
+// return before exiting. Defaults to DefaultExitHookTimeout. If zero or less
+// Fatal waits indefinitely.
+func SetExitHookTimeout(d time.Duration) {
+	fatalState.Lock()
+	defer fatalState.Unlock()
+	fatalState.timeout = d
+}
+
+// fatal is called by all the Fatal functions once their entry has been written
+func fatal(msg string, kvs []KV) {
+	fatalState.Lock()
+	mode, code := fatalState.mode, fatalState.code
+	hooks, timeout := fatalState.hooks, fatalState.timeout
+	exitFunc := fatalState.exitFunc
+	fatalState.Unlock()
+
+	switch mode {
+	case FatalPanic:
+		panic(&FatalError{Msg: msg, KV: Merge(kvs...)})
+	case FatalReturn:
+		return
+	}
+	runExitHooks(hooks, timeout)
+	exitFunc(code)
+}
+
+func runExitHooks(hooks []func(), timeout time.Duration) {
+	if len(hooks) == 0 {
+		return
+	}
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for _, fn := range hooks {
+			fn()
+		}
+	}()
+	if timeout <= 0 {
+		<-doneCh
+		return
+	}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-doneCh:
+	case <-t.C:
+	}
+}
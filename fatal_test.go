@@ -0,0 +1,82 @@
+package llog
+
+import (
+	"bytes"
+	"sync"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFatal(t *T) {
+	oldOut := Out
+	defer func() { Out = oldOut }()
+	buf := new(bytes.Buffer)
+	Out = buf
+
+	// hooks may still be running when exit is called if they time out
+	var callsL sync.Mutex
+	var exitCode int
+	var calls []string
+	call := func(s string) {
+		callsL.Lock()
+		defer callsL.Unlock()
+		calls = append(calls, s)
+	}
+	fatalState.Lock()
+	mode, code, hooks := fatalState.mode, fatalState.code, fatalState.hooks
+	timeout, exitFunc := fatalState.timeout, fatalState.exitFunc
+	fatalState.exitFunc = func(code int) {
+		call("exit")
+		exitCode = code
+	}
+	fatalState.Unlock()
+	defer func() {
+		fatalState.Lock()
+		defer fatalState.Unlock()
+		fatalState.mode, fatalState.code, fatalState.hooks = mode, code, hooks
+		fatalState.timeout, fatalState.exitFunc = timeout, exitFunc
+	}()
+
+	SetExitCode(3)
+	AddExitHook(func() { call("a") })
+	AddExitHook(func() { call("b") })
+	Fatal("foo")
+	assert.Equal(t, "~ FATAL -- foo\n", buf.String())
+	assert.Equal(t, []string{"a", "b", "exit"}, calls)
+	assert.Equal(t, 3, exitCode)
+
+	// a hook which doesn't return shouldn't prevent exiting
+	calls = nil
+	block := make(chan struct{})
+	defer close(block)
+	AddExitHook(func() { <-block })
+	SetExitHookTimeout(10 * time.Millisecond)
+	Fatal("foo")
+	callsL.Lock()
+	assert.Equal(t, []string{"a", "b", "exit"}, calls)
+	calls = nil
+	callsL.Unlock()
+
+	buf.Reset()
+	SetFatalMode(FatalPanic)
+	func() {
+		defer func() {
+			fe, ok := recover().(*FatalError)
+			require.True(t, ok)
+			assert.Equal(t, "bar", fe.Msg)
+			assert.Equal(t, KV{"a": 1, "b": 2}, fe.KV)
+		}()
+		With(KV{"a": 1}).Fatal("bar", KV{"b": 2})
+	}()
+	assert.Equal(t, "~ FATAL -- bar -- a=\"1\" b=\"2\"\n", buf.String())
+	assert.Empty(t, calls)
+
+	buf.Reset()
+	SetFatalMode(FatalReturn)
+	Fatal("baz")
+	assert.Equal(t, "~ FATAL -- baz\n", buf.String())
+	assert.Empty(t, calls)
+}
@@ -0,0 +1,146 @@
+package llog
+
+import "time"
+
+// Field is a single key/value pair, created using one of the typed
+// constructors (String, Int, Duration, etc...) and passed to one of the "w"
+// log functions (Debugw, Infow, etc...). Logging with Fields rather than KV
+// saves building a map for every call, since they're set directly into the
+// entry's KV.
+type Field struct {
+	Key   string
+	Value interface{}
+
+	// isErr indicates that Value is an error which should be expanded as Err
+	// does
+	isErr bool
+}
+
+// String returns a Field with a string value
+func String(key, val string) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Int returns a Field with an int value
+func Int(key string, val int) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Int64 returns a Field with an int64 value
+func Int64(key string, val int64) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Uint64 returns a Field with a uint64 value
+func Uint64(key string, val uint64) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Float64 returns a Field with a float64 value
+func Float64(key string, val float64) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Bool returns a Field with a bool value
+func Bool(key string, val bool) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Duration returns a Field with a time.Duration value
+func Duration(key string, val time.Duration) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Time returns a Field with a time.Time value
+func Time(key string, val time.Time) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Any returns a Field with a value of any type, which will be written the same
+// way it would be if it were in a KV
+func Any(key string, val interface{}) Field {
+	return Field{Key: key, Value: val}
+}
+
+// ErrField returns a Field describing the given error, which sets all the keys
+// Err does. It isn't named Error since that's taken by the log function. If
+// err is nil no keys are set.
+func ErrField(err error) Field {
+	return Field{Key: "err", Value: err, isErr: true}
+}
+
+func (f Field) setIn(kv KV) {
+	if !f.isErr {
+		kv[f.Key] = f.Value
+		return
+	}
+	if err, _ := f.Value.(error); err != nil {
+		for k, v := range Err(err) {
+			kv[k] = v
+		}
+	}
+}
+
+func fieldsKV(fields []Field) KV {
+	kv := make(KV, len(fields))
+	for _, f := range fields {
+		f.setIn(kv)
+	}
+	return kv
+}
+
+// Debugw is like Debug, but takes Fields rather than KV
+func Debugw(msg string, fields ...Field) {
+	globalCore.logEntry(DebugLevel, msg, nil, nil, fields, nil, BlockByDefault)
+}
+
+// Infow is like Info, but takes Fields rather than KV
+func Infow(msg string, fields ...Field) {
+	globalCore.logEntry(InfoLevel, msg, nil, nil, fields, nil, BlockByDefault)
+}
+
+// Warnw is like Warn, but takes Fields rather than KV
+func Warnw(msg string, fields ...Field) {
+	globalCore.logEntry(WarnLevel, msg, nil, nil, fields, nil, BlockByDefault)
+}
+
+// Errorw is like Error, but takes Fields rather than KV
+func Errorw(msg string, fields ...Field) {
+	globalCore.logEntry(ErrorLevel, msg, nil, nil, fields, nil, BlockByDefault)
+}
+
+// Fatalw is like Fatal, but takes Fields rather than KV
+func Fatalw(msg string, fields ...Field) {
+	globalCore.logEntry(FatalLevel, msg, nil, nil, fields, nil, true)
+	fatal(msg, []KV{fieldsKV(fields)})
+}
+
+func (l *Logger) logFields(lvl Level, msg string, fields []Field, block bool) {
+	l.getCore().logEntry(lvl, msg, l.enc, nil, fields, l.procs, block)
+}
+
+// Debugw is like the package-level Debugw, but includes the Logger's KV
+func (l *Logger) Debugw(msg string, fields ...Field) {
+	l.logFields(DebugLevel, msg, fields, BlockByDefault)
+}
+
+// Infow is like the package-level Infow, but includes the Logger's KV
+func (l *Logger) Infow(msg string, fields ...Field) {
+	l.logFields(InfoLevel, msg, fields, BlockByDefault)
+}
+
+// Warnw is like the package-level Warnw, but includes the Logger's KV
+func (l *Logger) Warnw(msg string, fields ...Field) {
+	l.logFields(WarnLevel, msg, fields, BlockByDefault)
+}
+
+// Errorw is like the package-level Errorw, but includes the Logger's KV
+func (l *Logger) Errorw(msg string, fields ...Field) {
+	l.logFields(ErrorLevel, msg, fields, BlockByDefault)
+}
+
+// Fatalw is like the package-level Fatalw, but includes the Logger's KV
+func (l *Logger) Fatalw(msg string, fields ...Field) {
+	l.logFields(FatalLevel, msg, fields, true)
+	fatal(msg, []KV{l.kv, fieldsKV(fields)})
+}
@@ -0,0 +1,36 @@
+package llog
+
+import (
+	"bytes"
+	"errors"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFields(t *T) {
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithFormatter(JSONFormatter{}), WithLevel(DebugLevel), WithSynchronous(true))
+	l.With(KV{"a": "bound"}).Infow("foo",
+		String("s", "str"),
+		Int("i", 1),
+		Int64("i64", -2),
+		Uint64("u64", 3),
+		Float64("f", 1.5),
+		Bool("b", true),
+		Duration("d", time.Second),
+		Time("t", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Any("any", []int{1, 2}),
+		ErrField(errors.New("oops")),
+		ErrField(nil),
+	)
+	assert.Equal(t,
+		`{"level":"INFO","msg":"foo","a":"bound","any":[1,2],"b":true,"d":1000000000,"err":"oops",`+
+			`"errType":"*errors.errorString","f":1.5,"i":1,"i64":-2,"s":"str",`+
+			`"t":"2020-01-02T03:04:05Z","u64":3}`+"\n",
+		buf.String())
+
+	// disabled entries shouldn't cost anything
+	assert.Zero(t, AllocsPerRun(100, func() { Debugw("foo", String("s", "str"), Int("i", 1)) }))
+}
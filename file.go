@@ -0,0 +1,105 @@
+package llog
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// FileWriter is an io.Writer which appends to a file, and which can be reopened
+// so that it works with external log rotation, like logrotate. Once the file
+// has been renamed Reopen closes it and opens a new one at the original path,
+// rather than continuing to write to the renamed one.
+type FileWriter struct {
+	path string
+
+	l sync.Mutex
+	f *os.File
+}
+
+var fileWriters = map[*FileWriter]struct{}{}
+var fileWritersLock sync.Mutex
+
+// OpenFile opens the file at the given path for appending, creating it if
+// needed, and returns a FileWriter which writes to it. Until it's closed the
+// FileWriter is reopened by the package-level Reopen.
+func OpenFile(path string) (*FileWriter, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fw := &FileWriter{path: path, f: f}
+	fileWritersLock.Lock()
+	defer fileWritersLock.Unlock()
+	fileWriters[fw] = struct{}{}
+	return fw, nil
+}
+
+func openFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+}
+
+// Write implements the io.Writer interface
+func (fw *FileWriter) Write(b []byte) (int, error) {
+	fw.l.Lock()
+	defer fw.l.Unlock()
+	if fw.f == nil {
+		return 0, os.ErrClosed
+	}
+	return fw.f.Write(b)
+}
+
+// Reopen closes the file and opens the one at the same path again. If the new
+// file can't be opened the FileWriter continues writing to the old one, and the
+// error is returned.
+func (fw *FileWriter) Reopen() error {
+	fw.l.Lock()
+	defer fw.l.Unlock()
+	if fw.f == nil {
+		return os.ErrClosed
+	}
+	f, err := openFile(fw.path)
+	if err != nil {
+		return err
+	}
+	fw.f.Close()
+	fw.f = f
+	return nil
+}
+
+// Close implements the io.Closer interface
+func (fw *FileWriter) Close() error {
+	fileWritersLock.Lock()
+	delete(fileWriters, fw)
+	fileWritersLock.Unlock()
+
+	fw.l.Lock()
+	defer fw.l.Unlock()
+	if fw.f == nil {
+		return os.ErrClosed
+	}
+	err := fw.f.Close()
+	fw.f = nil
+	return err
+}
+
+// Reopen flushes, as with Flush, and then reopens every FileWriter which is
+// open, including those of any "file" outputs opened by ApplyConfig. It should
+// be called once log files have been rotated, see also HandleReopenSignal.
+func Reopen() error {
+	Flush()
+	fileWritersLock.Lock()
+	fws := make([]*FileWriter, 0, len(fileWriters))
+	for fw := range fileWriters {
+		fws = append(fws, fw)
+	}
+	fileWritersLock.Unlock()
+
+	var errs []error
+	for _, fw := range fws {
+		if err := fw.Reopen(); err != nil && !errors.Is(err, os.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
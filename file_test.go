@@ -0,0 +1,41 @@
+package llog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileWriter(t *T) {
+	dir := t.TempDir()
+	path, rotated := filepath.Join(dir, "app.log"), filepath.Join(dir, "app.log.1")
+	fw, err := OpenFile(path)
+	require.NoError(t, err)
+
+	_, err = fw.Write([]byte("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(path, rotated))
+	_, err = fw.Write([]byte("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, Reopen())
+	_, err = fw.Write([]byte("baz\n"))
+	require.NoError(t, err)
+
+	b, err := ioutil.ReadFile(rotated)
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar\n", string(b))
+	b, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "baz\n", string(b))
+
+	require.NoError(t, fw.Close())
+	_, err = fw.Write([]byte("qux\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+	assert.ErrorIs(t, fw.Reopen(), os.ErrClosed)
+	assert.NoError(t, Reopen())
+	assert.NotContains(t, fileWriters, fw)
+}
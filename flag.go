@@ -0,0 +1,83 @@
+package llog
+
+import (
+	"flag"
+	"strings"
+)
+
+// Set implements the flag.Value interface, so that a Level can be used with
+// flag.Var. It interprets the string the same way as SetLevelFromString.
+func (l *Level) Set(s string) error {
+	nl, err := parseLevel(s)
+	if err != nil {
+		return err
+	}
+	*l = nl
+	return nil
+}
+
+// levelFlag is a flag.Value which sets the current log level
+type levelFlag struct{ s string }
+
+func (f *levelFlag) String() string { return f.s }
+
+func (f *levelFlag) Set(s string) error {
+	if err := SetLevelFromString(s); err != nil {
+		return err
+	}
+	f.s = strings.ToLower(s)
+	return nil
+}
+
+// formatFlag is a flag.Value which sets OutFormatter using SetFormatter
+type formatFlag struct{ s string }
+
+func (f *formatFlag) String() string { return f.s }
+
+func (f *formatFlag) Set(s string) error {
+	of, err := parseFormat(s)
+	if err != nil {
+		return err
+	}
+	SetFormatter(of)
+	f.s = strings.ToLower(s)
+	return nil
+}
+
+// LevelFlag defines a flag with the given name on the given FlagSet (or
+// flag.CommandLine if nil) which sets the current log level when parsed, e.g.
+//
+//	llog.LevelFlag(nil, "log-level", "info")
+//	flag.Parse()
+//
+// The log level is set to def immediately. LevelFlag panics if def isn't a
+// valid level.
+func LevelFlag(fs *flag.FlagSet, name, def string) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	f := new(levelFlag)
+	if err := f.Set(def); err != nil {
+		panic(err)
+	}
+	fs.Var(f, name, "minimum log level: debug, info, warn, error, or fatal")
+}
+
+// FormatFlag defines a flag with the given name on the given FlagSet (or
+// flag.CommandLine if nil) which sets OutFormatter when parsed, e.g.
+//
+//	llog.FormatFlag(nil, "log-format", "text")
+//	flag.Parse()
+//
+// OutFormatter is set to the format named by def immediately. FormatFlag
+// panics if def isn't a valid format.
+func FormatFlag(fs *flag.FlagSet, name, def string) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	f := new(formatFlag)
+	if err := f.Set(def); err != nil {
+		panic(err)
+	}
+	fs.Var(f, name, "log format: text, json, or color")
+}
@@ -0,0 +1,48 @@
+package llog
+
+import (
+	"flag"
+	"io/ioutil"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelSet(t *T) {
+	var l Level
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(&l, "level", "")
+	require.NoError(t, fs.Parse([]string{"-level=warn"}))
+	assert.Equal(t, WarnLevel, l)
+	assert.Error(t, fs.Parse([]string{"-level=loud"}))
+	assert.Equal(t, WarnLevel, l)
+}
+
+func TestFlags(t *T) {
+	oldFormatter := OutFormatter
+	defer func() {
+		OutFormatter = oldFormatter
+		SetLevel(InfoLevel)
+	}()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	LevelFlag(fs, "log-level", "warn")
+	FormatFlag(fs, "log-format", "text")
+	assert.Equal(t, WarnLevel, GetLevel())
+	assert.Equal(t, "warn", fs.Lookup("log-level").DefValue)
+
+	require.NoError(t, fs.Parse([]string{"-log-level=DEBUG", "-log-format=json"}))
+	assert.Equal(t, DebugLevel, GetLevel())
+	assert.Equal(t, JSONFormatter{}, OutFormatter)
+	assert.Equal(t, "debug", fs.Lookup("log-level").Value.String())
+
+	assert.Error(t, fs.Parse([]string{"-log-level=loud"}))
+	assert.Error(t, fs.Parse([]string{"-log-format=xml"}))
+	assert.Equal(t, DebugLevel, GetLevel())
+	assert.Equal(t, JSONFormatter{}, OutFormatter)
+
+	assert.Panics(t, func() { LevelFlag(fs, "other-level", "loud") })
+}
@@ -0,0 +1,420 @@
+package llog
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Formatter writes entries to an io.Writer in some format. Each entry should
+// be written with a single Write call where possible, so that it can't be
+// interleaved with anything else writing to the same io.Writer.
+type Formatter interface {
+	// Format writes the Entry to the io.Writer, including its timestamp if
+	// displayTS is true
+	Format(w io.Writer, e Entry, displayTS bool) error
+}
+
+// OutFormatter is the Formatter used to write entries to Out. It can be changed
+// to anything you like, but the change should happen before any logging
+// occurs, or else be made using SetFormatter.
+var OutFormatter Formatter = TextFormatter{}
+
+// TextFormatter is the default Formatter. It writes entries as single lines of
+// human readable text, like:
+//
+//	~ ERROR -- an error happened -- err="some error" userID="1111"
+//
+// The timestamp, if displayed, is written in the same format as time.Time's
+// String method, but without the monotonic clock reading. KV values which are
+// themselves KVs, maps, or structs are flattened into dotted keys, see
+// KV.Flatten. Stack values are written as indented blocks on the lines
+// following the entry.
+type TextFormatter struct {
+	// NoFlatten disables the flattening of nested values, they will be written
+	// using fmt.Sprint instead
+	NoFlatten bool
+
+	// KeySeparator is used to separate the keys of flattened values. Defaults
+	// to "."
+	KeySeparator string
+
+	// NoSort disables the sorting of keys, they will be written in whatever
+	// order iterating over the KV gives, which is random
+	NoSort bool
+
+	// Multiline determines how string and error values containing newlines
+	// are written. Defaults to MultilineEscape.
+	Multiline MultilineMode
+
+	// UTF8 causes non-ASCII characters in values to be written as they are,
+	// rather than escaped, so that values like names stay readable. Only
+	// non-printable characters are escaped.
+	UTF8 bool
+
+	// LevelNames overrides the names which levels are written as
+	LevelNames LevelNames
+
+	// Prefix is written at the start of every entry. Defaults to "~ ", see
+	// NoPrefix for having none
+	Prefix string
+
+	// NoPrefix disables the Prefix
+	NoPrefix bool
+
+	// Separator is written between the level and message, and between the
+	// message and KV. Defaults to " -- "
+	Separator string
+
+	// KVSeparator is written between each key and its value. Defaults to "="
+	KVSeparator string
+
+	// PadLevel pads levels with spaces to the width of the longest level name,
+	// so that messages line up when the output is read by a human:
+	//
+	//	~ INFO  -- connected
+	//	~ ERROR -- disconnected
+	PadLevel bool
+
+	// Colors, if set, colors levels and the values of particular keys using
+	// ANSI escape sequences, see DefaultColors. It should only be set when
+	// writing to a terminal.
+	Colors *Colors
+}
+
+// tokens returns the prefix, separator, and KV separator to write entries with
+func (tf TextFormatter) tokens() (string, string, string) {
+	prefix, sep, kvSep := "~ ", " -- ", "="
+	if tf.NoPrefix {
+		prefix = ""
+	} else if tf.Prefix != "" {
+		prefix = tf.Prefix
+	}
+	if tf.Separator != "" {
+		sep = tf.Separator
+	}
+	if tf.KVSeparator != "" {
+		kvSep = tf.KVSeparator
+	}
+	return prefix, sep, kvSep
+}
+
+// LevelNames overrides the names which levels are written as, since different
+// backends disagree about what severities are called. It's indexed by level,
+// e.g. LevelNames{WarnLevel: "NOTICE"}, and levels with an empty name are
+// written as usual.
+type LevelNames [FatalLevel + 1]string
+
+// name returns the name the level should be written as
+func (ln LevelNames) name(l Level) string {
+	if l >= 0 && int(l) < len(ln) && ln[l] != "" {
+		return ln[l]
+	}
+	return l.String()
+}
+
+// width returns the number of characters in the longest level name
+func (ln LevelNames) width() int {
+	var w int
+	for l := range ln {
+		if n := utf8.RuneCountInString(ln.name(Level(l))); n > w {
+			w = n
+		}
+	}
+	return w
+}
+
+// MultilineMode determines how the TextFormatter writes string and error values
+// which contain newlines
+type MultilineMode int
+
+// All the possible MultilineModes
+const (
+	// MultilineEscape writes the value on the entry's line with its newlines
+	// escaped, like any other value:
+	//
+	//	~ INFO -- query -- sql="SELECT *\nFROM users"
+	MultilineEscape MultilineMode = iota
+
+	// MultilineIndent writes the value in place, but with its newlines kept,
+	// and each of its subsequent lines indented by a tab:
+	//
+	//	~ INFO -- query -- sql="SELECT *
+	//		FROM users"
+	MultilineIndent
+
+	// MultilineBlock removes the value from the entry's line and writes it as a
+	// block on the lines following the entry, after a line with its key, with
+	// every line of the value indented by two tabs and otherwise unescaped:
+	//
+	//	~ INFO -- query
+	//		sql:
+	//			SELECT *
+	//			FROM users
+	MultilineBlock
+)
+
+// multilineString returns the value as a string if it's a string or error
+// containing a newline
+func multilineString(v interface{}) (string, bool) {
+	var s string
+	switch vv := v.(type) {
+	case string:
+		s = vv
+	case error:
+		if vv == nil {
+			return "", false
+		}
+		s = errorString(vv)
+	default:
+		return "", false
+	}
+	return s, strings.Contains(s, "\n")
+}
+
+// appendIndented appends the string quoted as appendTextValue would, but with
+// its newlines kept and each subsequent line indented by a tab
+func appendIndented(buf []byte, s string, ascii bool) []byte {
+	buf = append(buf, '"')
+	for i, line := range strings.Split(strings.Replace(s, `"`, `'`, -1), "\n") {
+		if i > 0 {
+			buf = append(buf, "\n\t"...)
+		}
+		quoted := appendQuote(buf, line, ascii)
+		// strip the quotes, which appendQuote added around line
+		buf = append(quoted[:len(buf)], quoted[len(buf)+1:len(quoted)-1]...)
+	}
+	return append(buf, '"')
+}
+
+// splitMultiline returns a copy of the KV with all values which are multiline
+// strings removed, and the removed keys and strings in key order
+func splitMultiline(kv KV) (KV, [][2]string) {
+	var blocks [][2]string
+	for k, v := range kv {
+		if s, ok := multilineString(v); ok {
+			blocks = append(blocks, [2]string{k, s})
+		}
+	}
+	if len(blocks) == 0 {
+		return kv, nil
+	}
+
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i][0] < blocks[j][0] })
+	kv = kv.Copy()
+	for _, b := range blocks {
+		delete(kv, b[0])
+	}
+	return kv, blocks
+}
+
+// Format implements the Formatter interface. The whole entry is written with a
+// single Write call.
+func (tf TextFormatter) Format(w io.Writer, e Entry, displayTS bool) error {
+	bufp := getBuf()
+	defer putBuf(bufp)
+	buf := *bufp
+
+	prefix, sep, kvSep := tf.tokens()
+	buf = append(buf, prefix...)
+	if displayTS {
+		buf = append(buf, '[')
+		buf = textTimestamps.appendTimestamp(buf, e.Time)
+		buf = append(buf, "] "...)
+	}
+	name := tf.LevelNames.name(e.Level)
+	style := tf.Colors.levelStyle(e.Level)
+	buf = appendStyleStart(buf, style)
+	buf = append(buf, name...)
+	buf = appendStyleEnd(buf, style)
+	if tf.PadLevel {
+		for n := utf8.RuneCountInString(name); n < tf.LevelNames.width(); n++ {
+			buf = append(buf, ' ')
+		}
+	}
+	buf = append(buf, sep...)
+	buf = append(buf, e.Msg...)
+	var stacks []Stack
+	var blocks [][2]string
+	if len(e.KV) > 0 {
+		kv := e.KV
+		if !tf.NoFlatten && kv.isNested() {
+			sep := tf.KeySeparator
+			if sep == "" {
+				sep = "."
+			}
+			kv = kv.Flatten(sep)
+		}
+		kv, stacks = splitStacks(kv)
+		if tf.Multiline == MultilineBlock {
+			kv, blocks = splitMultiline(kv)
+		}
+		if len(kv) > 0 {
+			buf = append(buf, strings.TrimRight(sep, " ")...)
+		}
+		keys := getKeys(kv, !tf.NoSort)
+		for _, k := range *keys {
+			buf = append(buf, ' ')
+			buf = append(buf, k...)
+			buf = append(buf, kvSep...)
+			style := tf.Colors.keyStyle(k)
+			buf = appendStyleStart(buf, style)
+			if s, ok := multilineString(kv[k]); ok && tf.Multiline == MultilineIndent {
+				buf = appendIndented(buf, s, !tf.UTF8)
+			} else {
+				buf = e.bound.appendText(buf, k, kv[k], !tf.UTF8)
+			}
+			buf = appendStyleEnd(buf, style)
+		}
+		putKeys(keys)
+	}
+	buf = append(buf, '\n')
+	for _, stack := range stacks {
+		buf = append(buf, '\t')
+		buf = append(buf, strings.Replace(stack.String(), "\n", "\n\t", -1)...)
+		buf = append(buf, '\n')
+	}
+	for _, b := range blocks {
+		buf = append(buf, '\t')
+		buf = append(buf, b[0]...)
+		buf = append(buf, ":\n\t\t"...)
+		buf = append(buf, strings.Replace(strings.TrimSuffix(b[1], "\n"), "\n", "\n\t\t", -1)...)
+		buf = append(buf, '\n')
+	}
+
+	*bufp = buf
+	_, err := w.Write(buf)
+	return err
+}
+
+// splitStacks returns a copy of the KV with all Stack values removed, and
+// the removed Stacks in key order
+func splitStacks(kv KV) (KV, []Stack) {
+	var keys []string
+	for k, v := range kv {
+		if _, ok := v.(Stack); ok {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return kv, nil
+	}
+
+	sort.Strings(keys)
+	kv = kv.Copy()
+	stacks := make([]Stack, len(keys))
+	for i, k := range keys {
+		stacks[i] = kv[k].(Stack)
+		delete(kv, k)
+	}
+	return kv, stacks
+}
+
+// JSONFormatter writes entries as single-line JSON objects, like:
+//
+//	{"level":"ERROR","msg":"an error happened","err":"some error","userID":1111}
+//
+// The timestamp, if displayed, is written under the "ts" key in RFC3339 format.
+// KV values are written as JSON values, with nested KVs, maps, and structs
+// becoming nested objects. Errors are written as their Error string, and values
+// which can't be encoded as JSON are written using fmt.Sprint.
+type JSONFormatter struct {
+	// NoSort disables the sorting of keys, they will be written in whatever
+	// order iterating over the KV gives, which is random. Most consumers of
+	// JSON don't care about the order of keys, so this saves the cost of
+	// sorting them.
+	NoSort bool
+
+	// LevelNames overrides the names which levels are written as
+	LevelNames LevelNames
+
+	// LevelKey, TimeKey, and MsgKey override the keys which the level,
+	// timestamp, and message are written under. They default to "level",
+	// "ts", and "msg".
+	LevelKey, TimeKey, MsgKey string
+}
+
+// ReservedKeys implements the ReservedKeyer interface, returning the keys the
+// level, timestamp, and message are written under
+func (jf JSONFormatter) ReservedKeys() []string {
+	levelKey, timeKey, msgKey := jf.keys()
+	return []string{levelKey, timeKey, msgKey}
+}
+
+// keys returns the keys to write the level, timestamp, and message under
+func (jf JSONFormatter) keys() (string, string, string) {
+	levelKey, timeKey, msgKey := "level", "ts", "msg"
+	if jf.LevelKey != "" {
+		levelKey = jf.LevelKey
+	}
+	if jf.TimeKey != "" {
+		timeKey = jf.TimeKey
+	}
+	if jf.MsgKey != "" {
+		msgKey = jf.MsgKey
+	}
+	return levelKey, timeKey, msgKey
+}
+
+// Format implements the Formatter interface. The whole entry is written with a
+// single Write call.
+func (jf JSONFormatter) Format(w io.Writer, e Entry, displayTS bool) error {
+	bufp := getBuf()
+	defer putBuf(bufp)
+	buf := *bufp
+	levelKey, timeKey, msgKey := jf.keys()
+	buf = append(buf, '{')
+	buf = appendJSONKey(buf, levelKey)
+	buf = strconv.AppendQuote(buf, jf.LevelNames.name(e.Level))
+	if displayTS {
+		buf = append(buf, ',')
+		buf = appendJSONKey(buf, timeKey)
+		buf = append(buf, '"')
+		buf = jsonTimestamps.appendTimestamp(buf, e.Time)
+		buf = append(buf, '"')
+	}
+	buf = append(buf, ',')
+	buf = appendJSONKey(buf, msgKey)
+	buf = appendJSON(buf, e.Msg)
+
+	keys := getKeys(e.KV, !jf.NoSort)
+	for _, k := range *keys {
+		buf = append(buf, ',')
+		buf = appendJSONKey(buf, k)
+		buf = e.bound.appendJSON(buf, k, e.KV[k])
+	}
+	putKeys(keys)
+	buf = append(buf, '}', '\n')
+
+	*bufp = buf
+	_, err := w.Write(buf)
+	return err
+}
+
+const timeFormatJSON = "2006-01-02T15:04:05.000000Z07:00"
+
+// jsonValue converts the given value into one which encoding/json will encode
+// sensibly
+func jsonValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case Marshaler:
+		return jsonValue(marshalLog(vv))
+	case json.Marshaler:
+		return vv
+	case error:
+		return errorString(vv)
+	case time.Duration, time.Time:
+		return formatValue(v)
+	case KV:
+		m := make(map[string]interface{}, len(vv))
+		for k, vvv := range vv {
+			m[k] = jsonValue(vvv)
+		}
+		return m
+	}
+	return v
+}
@@ -0,0 +1,173 @@
+package llog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextFormatterNested(t *T) {
+	e := Entry{
+		Level: InfoLevel,
+		Msg:   "foo",
+		KV:    KV{"http": KV{"method": "GET", "status": 200}},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, TextFormatter{}.Format(buf, e, false))
+	assert.Equal(t, "~ INFO -- foo -- http.method=\"GET\" http.status=\"200\"\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, TextFormatter{KeySeparator: "_"}.Format(buf, e, false))
+	assert.Equal(t, "~ INFO -- foo -- http_method=\"GET\" http_status=\"200\"\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, TextFormatter{NoFlatten: true}.Format(buf, e, false))
+	assert.Equal(t, "~ INFO -- foo -- http=\"map[method:GET status:200]\"\n", buf.String())
+}
+
+func TestJSONFormatter(t *T) {
+	e := Entry{
+		Level: ErrorLevel,
+		Time:  time.Date(2021, 2, 3, 4, 5, 6, 7000, time.UTC),
+		Msg:   "foo \"bar\"",
+		KV: KV{
+			"err":  errors.New("baz"),
+			"http": KV{"method": "GET", "status": 200},
+			"ch":   make(chan int),
+			"s":    struct{ A int }{1},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, JSONFormatter{}.Format(buf, e, false))
+	require.NoError(t, JSONFormatter{}.Format(buf, Entry{Level: InfoLevel, Time: e.Time}, true))
+	out := buf.String()
+	assert.Regexp(t, `^{"level":"ERROR","msg":"foo \\"bar\\"","ch":"0x[0-9a-f]+","err":"baz","http":{"method":"GET","status":200},"s":{"A":1}}\n`, out)
+	assert.Contains(t, out, "\n"+`{"level":"INFO","ts":"2021-02-03T04:05:06.000007Z","msg":""}`+"\n")
+}
+
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	cw.writes++
+	return cw.Buffer.Write(b)
+}
+
+func TestFormatSingleWrite(t *T) {
+	e := Entry{
+		Level: ErrorLevel,
+		Msg:   "foo",
+		KV:    KV{"a": 1, "b": KV{"c": "d"}, "stack": Stack{{Function: "main.main", File: "main.go", Line: 1}}},
+	}
+	for _, f := range []Formatter{TextFormatter{}, JSONFormatter{}} {
+		cw := new(countingWriter)
+		require.NoError(t, f.Format(cw, e, true))
+		assert.Equal(t, 1, cw.writes)
+		assert.Contains(t, cw.String(), "foo")
+	}
+}
+
+func TestFormatNoSort(t *T) {
+	e := Entry{Level: InfoLevel, Msg: "foo", KV: KV{"a": 1, "b": "two", "c": true}}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, JSONFormatter{NoSort: true}.Format(buf, e, false))
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, map[string]interface{}{"level": "INFO", "msg": "foo", "a": 1.0, "b": "two", "c": true}, m)
+
+	buf.Reset()
+	require.NoError(t, TextFormatter{NoSort: true}.Format(buf, e, false))
+	for _, s := range []string{`a="1"`, `b="two"`, `c="true"`} {
+		assert.Contains(t, buf.String(), s)
+	}
+}
+
+func TestTextFormatterMultiline(t *T) {
+	e := Entry{Level: InfoLevel, Msg: "query", KV: KV{
+		"sql": "SELECT \"a\"\nFROM users\n",
+		"err": errors.New("line 1\nline 2"),
+		"db":  "main",
+	}}
+	assertFormat := func(mode MultilineMode, expected string) {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, TextFormatter{Multiline: mode}.Format(buf, e, false))
+		assert.Equal(t, expected, buf.String())
+	}
+
+	assertFormat(MultilineEscape,
+		`~ INFO -- query -- db="main" err="line 1\nline 2" sql="SELECT 'a'\nFROM users\n"`+"\n")
+	assertFormat(MultilineIndent,
+		"~ INFO -- query -- db=\"main\" err=\"line 1\n\tline 2\" sql=\"SELECT 'a'\n\tFROM users\n\t\"\n")
+	assertFormat(MultilineBlock,
+		"~ INFO -- query -- db=\"main\"\n\terr:\n\t\tline 1\n\t\tline 2\n\tsql:\n\t\tSELECT \"a\"\n\t\tFROM users\n")
+}
+
+func TestTextFormatterUTF8(t *T) {
+	l := With(KV{"name": "Zoë"})
+	e := Entry{Level: InfoLevel, Msg: "héllo", KV: l.KV(), bound: l.enc}
+	e.KV["took"] = 3 * time.Microsecond
+	e.KV["bell"] = "\a"
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, TextFormatter{}.Format(buf, e, false))
+	assert.Equal(t, `~ INFO -- héllo -- bell="\a" name="Zo\u00eb" took="3\u00b5s"`+"\n", buf.String())
+
+	// the bound value's cached encoding shouldn't be used for the other mode
+	buf.Reset()
+	assert.NoError(t, TextFormatter{UTF8: true}.Format(buf, e, false))
+	assert.Equal(t, `~ INFO -- héllo -- bell="\a" name="Zoë" took="3µs"`+"\n", buf.String())
+}
+
+func TestLevelNames(t *T) {
+	ln := LevelNames{WarnLevel: "NOTICE", ErrorLevel: "critical"}
+	e := Entry{Level: WarnLevel, Msg: "foo"}
+	buf := new(bytes.Buffer)
+	require.NoError(t, TextFormatter{LevelNames: ln}.Format(buf, e, false))
+	e.Level = ErrorLevel
+	require.NoError(t, JSONFormatter{LevelNames: ln}.Format(buf, e, false))
+	e.Level = InfoLevel
+	require.NoError(t, JSONFormatter{LevelNames: ln}.Format(buf, e, false))
+	assert.Equal(t,
+		"~ NOTICE -- foo\n"+
+			`{"level":"critical","msg":"foo"}`+"\n"+
+			`{"level":"INFO","msg":"foo"}`+"\n",
+		buf.String())
+
+	_, err := NewSyslogSinkWithSeverities("", "", "", InfoLevel, nil, map[Level]string{WarnLevel: "loud"})
+	assert.Error(t, err)
+}
+
+func TestTextFormatterTokens(t *T) {
+	e := Entry{Level: InfoLevel, Msg: "foo", KV: KV{"a": 1, "b": "c"}}
+	assertFormat := func(tf TextFormatter, expected string) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, tf.Format(buf, e, false))
+		assert.Equal(t, expected, buf.String())
+	}
+	assertFormat(TextFormatter{}, "~ INFO -- foo -- a=\"1\" b=\"c\"\n")
+	assertFormat(TextFormatter{Prefix: "> ", Separator: " | ", KVSeparator: ":"}, "> INFO | foo | a:\"1\" b:\"c\"\n")
+	assertFormat(TextFormatter{NoPrefix: true, Prefix: "> "}, "INFO -- foo -- a=\"1\" b=\"c\"\n")
+}
+
+func TestTextFormatterPadLevel(t *T) {
+	buf := new(bytes.Buffer)
+	tf := TextFormatter{PadLevel: true}
+	for _, l := range []Level{InfoLevel, ErrorLevel, WarnLevel} {
+		require.NoError(t, tf.Format(buf, Entry{Level: l, Msg: "foo"}, false))
+	}
+	tf.LevelNames = LevelNames{ErrorLevel: "CRITICAL"}
+	require.NoError(t, tf.Format(buf, Entry{Level: InfoLevel, Msg: "foo"}, false))
+	assert.Equal(t,
+		"~ INFO  -- foo\n~ ERROR -- foo\n~ WARN  -- foo\n~ INFO     -- foo\n",
+		buf.String())
+}
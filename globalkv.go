@@ -0,0 +1,33 @@
+package llog
+
+import "sync/atomic"
+
+// globalKV holds the *encodedKV of the KV set by SetGlobalKV, or nil
+var globalKV atomic.Pointer[encodedKV]
+
+// SetGlobalKV sets KV which is included in every entry, by every Logger, e.g.
+// tags describing where the process is running so that entries can be
+// filtered across a whole fleet:
+//
+//	llog.SetGlobalKV(llog.KV{"env": "prod", "region": "us-east-1"})
+//
+// Any KV given with an entry, or bound to its Logger, takes precedence over it.
+// Like a Logger's bound KV its values are only encoded once, rather than for
+// every entry. It can also be set using the LLOG_KV environment variable, see
+// ConfigureFromEnv. Calling it with nil removes the global KV.
+func SetGlobalKV(kv KV) {
+	if len(kv) == 0 {
+		globalKV.Store(nil)
+		return
+	}
+	kv = kv.Copy()
+	globalKV.Store(&encodedKV{kv: kv})
+}
+
+// GetGlobalKV returns a copy of the KV set by SetGlobalKV
+func GetGlobalKV() KV {
+	if g := globalKV.Load(); g != nil {
+		return g.kv.Copy()
+	}
+	return KV{}
+}
@@ -0,0 +1,33 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetGlobalKV(t *T) {
+	SetGlobalKV(KV{"env": "prod", "a": 1})
+	defer SetGlobalKV(nil)
+	assert.Equal(t, KV{"env": "prod", "a": 1}, GetGlobalKV())
+
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithSynchronous(true))
+	l.Info("foo")
+	l.Info("foo", KV{"a": 2})
+	l.With(KV{"b": 3}).Info("foo")
+	l.With(KV{"env": "dev"}).Infow("foo", Int("a", 4))
+	assert.Equal(t,
+		"~ INFO -- foo -- a=\"1\" env=\"prod\"\n"+
+			"~ INFO -- foo -- a=\"2\" env=\"prod\"\n"+
+			"~ INFO -- foo -- a=\"1\" b=\"3\" env=\"prod\"\n"+
+			"~ INFO -- foo -- a=\"4\" env=\"dev\"\n",
+		buf.String())
+
+	SetGlobalKV(nil)
+	assert.Equal(t, KV{}, GetGlobalKV())
+	buf.Reset()
+	l.Info("foo")
+	assert.Equal(t, "~ INFO -- foo\n", buf.String())
+}
@@ -1,8 +1,14 @@
 module github.com/levenlabs/go-llog
 
-go 1.15
+go 1.22
 
 require (
 	github.com/levenlabs/errctx v1.0.0
-	github.com/stretchr/testify v1.7.0
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
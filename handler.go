@@ -0,0 +1,57 @@
+package llog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// LevelHandler returns an http.Handler which can be used to inspect and change
+// the current log level at runtime. A GET responds with the current level, and
+// a PUT sets the level to the one given in the request body, e.g.
+//
+//	curl -X PUT -d debug localhost:6060/debug/llog/level
+//
+// The handler should only be mounted somewhere which isn't publicly accessible.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			b, err := io.ReadAll(io.LimitReader(r.Body, 64))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := SetLevelFromString(strings.TrimSpace(string(b))); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, GetLevel())
+	})
+}
+
+// StatsHandler returns an http.Handler which responds to a GET with the current
+// Stats as a JSON object, in the same form as PublishExpvar publishes them. It's
+// useful for including the health of logging in diagnostics endpoints.
+//
+// The handler should only be mounted somewhere which isn't publicly accessible.
+func StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(expvarStats(Stats()))
+	})
+}
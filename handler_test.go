@@ -0,0 +1,62 @@
+package llog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelHandler(t *T) {
+	defer SetLevel(InfoLevel)
+	h := LevelHandler()
+
+	do := func(method, body string) (int, string) {
+		r := httptest.NewRequest(method, "/", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code, w.Body.String()
+	}
+
+	code, body := do("GET", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "INFO\n", body)
+
+	code, body = do("PUT", "debug\n")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "DEBUG\n", body)
+	assert.Equal(t, DebugLevel, GetLevel())
+
+	code, body = do("PUT", "loud")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "unknown log level \"loud\"\n", body)
+	assert.Equal(t, DebugLevel, GetLevel())
+
+	code, _ = do("POST", "info")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+	assert.Equal(t, DebugLevel, GetLevel())
+}
+
+func TestStatsHandler(t *T) {
+	h := StatsHandler()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &m))
+	assert.Contains(t, m, "written")
+	assert.Contains(t, m, "dropped")
+	assert.Contains(t, m, "queue_depth")
+
+	r = httptest.NewRequest("POST", "/", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
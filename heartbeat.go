@@ -0,0 +1,42 @@
+package llog
+
+import (
+	"time"
+)
+
+// processStart is roughly when the process started, for the uptime of
+// heartbeats
+var processStart = time.Now()
+
+// StartHeartbeat starts logging an Info entry every interval, from its own
+// go-routine, so that monitors watching the logs can detect a process which has
+// become wedged and gone silent. The entry includes the process's uptime, and
+// how many entries have been written, dropped, and failed to be written (see
+// Stats) since the previous heartbeat:
+//
+//	~ INFO -- heartbeat -- dropped="0" uptime="1h5m0s" writeErrors="0" written="1520"
+//
+// The returned function stops the heartbeat, and can be called more than once.
+func StartHeartbeat(interval time.Duration) (stop func()) {
+	prev := Stats()
+	return every(interval, func() {
+		Info("heartbeat", heartbeatKV(&prev, Stats(), time.Now()))
+	})
+}
+
+// heartbeatKV returns the KV of a heartbeat with the given Stats, and updates
+// prev to them
+func heartbeatKV(prev *LoggerStats, s LoggerStats, now time.Time) KV {
+	var written uint64
+	for l, n := range s.Written {
+		written += n - prev.Written[l]
+	}
+	kv := KV{
+		"uptime":      now.Sub(processStart).Round(time.Second),
+		"written":     written,
+		"dropped":     s.Dropped - prev.Dropped,
+		"writeErrors": s.WriteErrors - prev.WriteErrors,
+	}
+	*prev = s
+	return kv
+}
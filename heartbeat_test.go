@@ -0,0 +1,35 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeatKV(t *T) {
+	prev := LoggerStats{Written: map[Level]uint64{InfoLevel: 10, ErrorLevel: 1}, Dropped: 2}
+	s := LoggerStats{Written: map[Level]uint64{InfoLevel: 15, ErrorLevel: 3}, Dropped: 2, WriteErrors: 1}
+	kv := heartbeatKV(&prev, s, processStart.Add(time.Hour+1400*time.Millisecond))
+	assert.Equal(t, KV{
+		"uptime":      time.Hour + time.Second,
+		"written":     uint64(7),
+		"dropped":     uint64(0),
+		"writeErrors": uint64(1),
+	}, kv)
+	assert.Equal(t, s, prev)
+}
+
+func TestStartHeartbeat(t *T) {
+	oldOut := Out
+	defer func() { Out = oldOut }()
+	buf := new(bytes.Buffer)
+	Out = buf
+
+	stop := StartHeartbeat(10 * time.Millisecond)
+	time.Sleep(35 * time.Millisecond)
+	stop()
+	Flush()
+	assert.Contains(t, buf.String(), "~ INFO -- heartbeat -- dropped=\"0\" uptime=")
+}
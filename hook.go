@@ -0,0 +1,66 @@
+package llog
+
+import "sync"
+
+// Hook is an extension point which is called with every entry of at least a
+// certain level. Hooks are called with entries which have passed level
+// filtering, in the order the entries are written, just before they are
+// written to Out. Since they are called from the same go-routine which writes
+// to Out a Hook which blocks will block all logging, so any slow work should
+// be done asynchronously.
+type Hook interface {
+	// Level returns the minimum level an entry must have for the Hook to be
+	// fired with it
+	Level() Level
+
+	// Fire is called with each entry. The Entry's KV must not be modified
+	Fire(Entry)
+}
+
+type hookFunc struct {
+	lvl Level
+	fn  func(Entry)
+}
+
+// NewHook returns a Hook which calls the given function with every entry of
+// the given level or above
+func NewHook(lvl Level, fn func(Entry)) Hook {
+	return hookFunc{lvl: lvl, fn: fn}
+}
+
+// Level implements the Hook interface
+func (hf hookFunc) Level() Level {
+	return hf.lvl
+}
+
+// Fire implements the Hook interface
+func (hf hookFunc) Fire(e Entry) {
+	hf.fn(e)
+}
+
+var hooks []Hook
+var hooksLock sync.RWMutex
+
+// AddHook registers a Hook to be fired for every entry of its Level or above
+func AddHook(h Hook) {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+	// copy so that a slice being read by fireHooks is never modified
+	hooks = append(hooks[:len(hooks):len(hooks)], h)
+}
+
+func getHooks() []Hook {
+	hooksLock.RLock()
+	defer hooksLock.RUnlock()
+	return hooks
+}
+
+// fireHooks fires each of the Hooks which the entry's level is at or above.
+// Shouldn't be called outside the main loop.
+func (c *core) fireHooks(e Entry, hs []Hook) {
+	for _, h := range hs {
+		if e.Level >= h.Level() {
+			c.fireHook(h, e)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package llog
+
+import (
+	"io/ioutil"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHook(t *T) {
+	oldOut := Out
+	defer func() {
+		Out = oldOut
+		hooks = nil
+	}()
+	Out = ioutil.Discard
+
+	var infos, warns []Entry
+	AddHook(NewHook(InfoLevel, func(e Entry) { infos = append(infos, e) }))
+	AddHook(NewHook(WarnLevel, func(e Entry) { warns = append(warns, e) }))
+
+	SetLevel(InfoLevel)
+	Debug("foo")
+	Info("bar", KV{"a": "a"})
+	Warn("baz")
+	Flush()
+
+	assert.Len(t, infos, 2)
+	assert.Equal(t, InfoLevel, infos[0].Level)
+	assert.Equal(t, "bar", infos[0].Msg)
+	assert.Equal(t, KV{"a": "a"}, infos[0].KV)
+	assert.False(t, infos[0].Time.IsZero())
+	assert.Equal(t, "baz", infos[1].Msg)
+
+	assert.Len(t, warns, 1)
+	assert.Equal(t, WarnLevel, warns[0].Level)
+	assert.Equal(t, "baz", warns[0].Msg)
+}
@@ -0,0 +1,57 @@
+package llog
+
+import "sync/atomic"
+
+// maxInternedKeys is the most keys which are interned, so that entries with
+// unbounded sets of keys, e.g. ones containing IDs, can't grow the cache
+// forever
+const maxInternedKeys = 1024
+
+// jsonKeys interns the encodings of keys as JSON object keys (e.g. `"userID":`)
+// so that keys which are logged over and over aren't escaped every time. It's
+// copied on write, since after the first few entries it's almost only read.
+var jsonKeys atomic.Pointer[map[string][]byte]
+
+// appendJSONKey appends the key as a JSON object key, followed by a colon
+func appendJSONKey(buf []byte, k string) []byte {
+	m := jsonKeys.Load()
+	if m != nil {
+		if b, ok := (*m)[k]; ok {
+			return append(buf, b...)
+		}
+	}
+	start := len(buf)
+	buf = appendJSONString(buf, k)
+	buf = append(buf, ':')
+	internJSONKey(m, k, buf[start:])
+	return buf
+}
+
+// internJSONKey adds the key's encoding to jsonKeys, unless it's full. m is
+// what jsonKeys held when the key wasn't found.
+func internJSONKey(m *map[string][]byte, k string, b []byte) {
+	for {
+		var n int
+		if m != nil {
+			n = len(*m)
+		}
+		if n >= maxInternedKeys {
+			return
+		}
+		nm := make(map[string][]byte, n+1)
+		if m != nil {
+			for kk, bb := range *m {
+				nm[kk] = bb
+			}
+		}
+		nm[k] = append([]byte(nil), b...)
+		if jsonKeys.CompareAndSwap(m, &nm) {
+			return
+		}
+		if m = jsonKeys.Load(); m != nil {
+			if _, ok := (*m)[k]; ok {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package llog
+
+import (
+	"fmt"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendJSONKey(t *T) {
+	for _, k := range []string{"userID", `a "quoted" key`, "<html>", "uni©ode"} {
+		expected := append(appendJSONString(nil, k), ':')
+		assert.Equal(t, string(expected), string(appendJSONKey(nil, k)), k)
+		// the second time it comes from jsonKeys
+		assert.Equal(t, string(expected), string(appendJSONKey([]byte("foo"), k)[3:]), k)
+		assert.Contains(t, *jsonKeys.Load(), k)
+	}
+
+	for i := 0; i < maxInternedKeys*2; i++ {
+		k := fmt.Sprintf("key%d", i)
+		assert.Equal(t, `"`+k+`":`, string(appendJSONKey(nil, k)))
+	}
+	assert.Len(t, *jsonKeys.Load(), maxInternedKeys)
+}
+
+func BenchmarkAppendJSONKey(b *B) {
+	buf := make([]byte, 0, 64)
+	for i := 0; i < b.N; i++ {
+		buf = appendJSONKey(buf[:0], "requestID")
+	}
+}
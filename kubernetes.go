@@ -0,0 +1,28 @@
+package llog
+
+// KubernetesFormatter returns a JSONFormatter which writes entries with the
+// fields which GKE's log agent recognizes, so that Cloud Logging shows them with
+// the correct severity rather than the default one:
+//
+//	{"severity":"WARNING","time":"2006-01-02T15:04:05.000000Z","message":"slow query","ms":1200}
+//
+// Levels are written as the severities of the same names, except for WarnLevel
+// and FatalLevel which are written as "WARNING" and "CRITICAL".
+func KubernetesFormatter() JSONFormatter {
+	return JSONFormatter{
+		LevelNames: LevelNames{WarnLevel: "WARNING", FatalLevel: "CRITICAL"},
+		LevelKey:   "severity",
+		TimeKey:    "time",
+		MsgKey:     "message",
+	}
+}
+
+// UseKubernetesPreset sets OutFormatter to KubernetesFormatter and displays
+// timestamps, which is what services running in GKE generally want. Like
+// SetFormatter it's safe to call at any time.
+func UseKubernetesPreset() {
+	globalCore.apply(func() {
+		OutFormatter = KubernetesFormatter()
+		DisplayTimestamp = true
+	})
+}
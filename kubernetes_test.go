@@ -0,0 +1,42 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubernetesFormatter(t *T) {
+	buf := new(bytes.Buffer)
+	e := Entry{
+		Time:  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level: WarnLevel,
+		Msg:   "foo",
+		KV:    KV{"a": 1},
+	}
+	require.NoError(t, KubernetesFormatter().Format(buf, e, true))
+	assert.Equal(t, `{"severity":"WARNING","time":"2020-01-02T03:04:05.000000Z","message":"foo","a":1}`+"\n", buf.String())
+
+	f, err := parseFormat("kubernetes")
+	require.NoError(t, err)
+	assert.Equal(t, KubernetesFormatter(), f)
+
+	oldOut, oldFormatter, oldTS := Out, OutFormatter, DisplayTimestamp
+	defer func() {
+		SetOutput(oldOut)
+		SetFormatter(oldFormatter)
+		SetDisplayTimestamp(oldTS)
+		SetClock(nil)
+	}()
+	buf.Reset()
+	SetOutput(buf)
+	SetClock(func() time.Time { return e.Time })
+	UseKubernetesPreset()
+	Error("bar")
+	Flush()
+	assert.Equal(t, `{"severity":"ERROR","time":"2020-01-02T03:04:05.000000Z","message":"bar"}`+"\n", buf.String())
+	assert.Equal(t, "CRITICAL", KubernetesFormatter().LevelNames.name(FatalLevel))
+}
@@ -0,0 +1,55 @@
+package llog
+
+// The methods on KV let an entry be written starting from its context, e.g.
+//
+//	llog.KV{"userID": id}.Info("logged in")
+//
+// Any KVs passed to them are Merge'd on top of the KV being called on, which
+// is unaffected.
+
+// Debug is like the package-level Debug, but includes the KV being called on
+func (kv KV) Debug(msg string, kvs ...KV) {
+	globalCore.logEntry(DebugLevel, msg, nil, kv.prepend(kvs), nil, nil, BlockByDefault)
+}
+
+// Info is like the package-level Info, but includes the KV being called on
+func (kv KV) Info(msg string, kvs ...KV) {
+	globalCore.logEntry(InfoLevel, msg, nil, kv.prepend(kvs), nil, nil, BlockByDefault)
+}
+
+// Warn is like the package-level Warn, but includes the KV being called on
+func (kv KV) Warn(msg string, kvs ...KV) {
+	globalCore.logEntry(WarnLevel, msg, nil, kv.prepend(kvs), nil, nil, BlockByDefault)
+}
+
+// Error is like the package-level Error, but includes the KV being called on
+func (kv KV) Error(msg string, kvs ...KV) {
+	globalCore.logEntry(ErrorLevel, msg, nil, kv.prepend(kvs), nil, nil, BlockByDefault)
+}
+
+// Fatal is like the package-level Fatal, but includes the KV being called on
+func (kv KV) Fatal(msg string, kvs ...KV) {
+	all := kv.prepend(kvs)
+	globalCore.logEntry(FatalLevel, msg, nil, all, nil, nil, true)
+	fatal(msg, all)
+}
+
+// Log writes an entry of the given level, including the KV being called on.
+// Logging at FatalLevel behaves the same as calling Fatal
+func (kv KV) Log(lvl Level, msg string, kvs ...KV) {
+	if lvl == FatalLevel {
+		kv.Fatal(msg, kvs...)
+		return
+	}
+	globalCore.logEntry(lvl, msg, nil, kv.prepend(kvs), nil, nil, BlockByDefault)
+}
+
+// With returns a Logger which has the KV being called on, with the given KVs
+// Merge'd on top of it, bound to it
+func (kv KV) With(kvs ...KV) *Logger {
+	return With(kv.prepend(kvs)...)
+}
+
+func (kv KV) prepend(kvs []KV) []KV {
+	return append([]KV{kv}, kvs...)
+}
@@ -0,0 +1,34 @@
+package llog
+
+import (
+	"bytes"
+	"errors"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKVLog(t *T) {
+	oldOut := Out
+	defer SetOutput(oldOut)
+	buf := new(bytes.Buffer)
+	SetOutput(buf)
+
+	kv := KV{"userID": 1}
+	kv.Info("logged in")
+	kv.Debug("not written")
+	kv.Warn("slow", KV{"userID": 2, "ms": 500})
+	kv.Log(ErrorLevel, "bar")
+	Flush()
+	assert.Equal(t, "~ INFO -- logged in -- userID=\"1\"\n"+
+		"~ WARN -- slow -- ms=\"500\" userID=\"2\"\n"+
+		"~ ERROR -- bar -- userID=\"1\"\n", buf.String())
+	assert.Equal(t, KV{"userID": 1}, kv)
+
+	// composes with a Logger's bound KV
+	buf.Reset()
+	l := KV{"requestID": "a"}.With(KV{"userID": 1})
+	l.Error("failed", Err(errors.New("oops")))
+	Flush()
+	assert.Equal(t, "~ ERROR -- failed -- err=\"oops\" errType=\"*errors.errorString\" requestID=\"a\" userID=\"1\"\n", buf.String())
+}
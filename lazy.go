@@ -0,0 +1,40 @@
+package llog
+
+import "fmt"
+
+// Lazy is a value whose function is only called if the entry it's part of is
+// going to be written, and is called from the go-routine which writes entries
+// rather than from the log call. Its result is used as the value instead. This
+// makes it useful for values which are expensive to compute, and are only
+// wanted at levels which are usually disabled:
+//
+//	llog.Debug("got response", llog.KV{
+//		"body": llog.Lazy(func() interface{} { return dump(resp) }),
+//	})
+//
+// Since the function is called later than the log call it mustn't depend on
+// anything which may change in the meantime. Only Lazy values directly in an
+// entry's KV are resolved, not ones nested within other values.
+type Lazy func() interface{}
+
+// resolveLazy replaces all Lazy values in the entry's KV with their results. If
+// a Lazy panics its value is replaced with a description of the panic.
+func resolveLazy(kv KV) {
+	for k, v := range kv {
+		if lz, ok := v.(Lazy); ok {
+			kv[k] = lz.resolve()
+		}
+	}
+}
+
+func (lz Lazy) resolve() (v interface{}) {
+	if lz == nil {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			v = fmt.Sprintf("PANIC=%v", r)
+		}
+	}()
+	return lz()
+}
@@ -0,0 +1,24 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLazy(t *T) {
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithLevel(InfoLevel))
+	var calls int
+	lz := Lazy(func() interface{} {
+		calls++
+		return "computed"
+	})
+	l.Debug("foo", KV{"a": lz})
+	l.Info("bar", KV{"a": lz, "b": Lazy(func() interface{} { panic("oops") })})
+	l.With(KV{"a": lz}).Info("baz")
+	l.Flush()
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "~ INFO -- bar -- a=\"computed\" b=\"PANIC=oops\"\n~ INFO -- baz -- a=\"computed\"\n", buf.String())
+}
@@ -0,0 +1,131 @@
+package llog
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// packageLevels is the rules table set by SetPackageLevels, with min and max
+// being the lowest and highest levels in it so that most entries can be
+// checked without finding their caller
+type packageLevels struct {
+	prefixes []string // sorted longest first
+	levels   map[string]Level
+	min, max Level
+}
+
+// pkgLevels is nil if there are no overrides. It's checked on every log call,
+// so is an atomic.Pointer rather than being protected by a lock.
+var pkgLevels atomic.Pointer[packageLevels]
+
+// hasPkgLevels is 1 if pkgLevels isn't nil. It's cheaper to check than
+// pkgLevels, which keeps Enabled within the inlining budget.
+var hasPkgLevels int32
+
+// SetPackageLevels overrides the current log level for entries logged from
+// within particular packages. The keys are package import paths, each of which
+// also applies to all packages beneath it, so
+//
+//	llog.SetPackageLevels(map[string]llog.Level{
+//		"github.com/example/app/storage": llog.DebugLevel,
+//		"github.com/example/app/storage/cache": llog.WarnLevel,
+//		"github.com/example/vendor/noisy": llog.ErrorLevel,
+//	})
+//
+// logs Debug entries from storage and its subpackages, except for cache, while
+// the rest of the process stays at the current log level. When more than one
+// key applies to a package the longest wins. A trailing "/..." on a key is
+// ignored. Calling with an empty map removes all overrides, which is the
+// default.
+//
+// The package of an entry is the package of the function which called the log
+// function. Finding it has a cost, but it's only done for entries which some
+// override may apply to, i.e. with a level between the lowest and highest
+// levels in use.
+func SetPackageLevels(levels map[string]Level) {
+	var pl *packageLevels
+	if len(levels) > 0 {
+		pl = &packageLevels{levels: make(map[string]Level, len(levels))}
+		first := true
+		for prefix, l := range levels {
+			prefix = strings.TrimSuffix(prefix, "/...")
+			pl.prefixes = append(pl.prefixes, prefix)
+			pl.levels[prefix] = l
+			if first || l < pl.min {
+				pl.min = l
+			}
+			if first || l > pl.max {
+				pl.max = l
+			}
+			first = false
+		}
+		sort.Slice(pl.prefixes, func(i, j int) bool {
+			return len(pl.prefixes[i]) > len(pl.prefixes[j])
+		})
+	}
+	pkgLevels.Store(pl)
+	if pl != nil {
+		atomic.StoreInt32(&hasPkgLevels, 1)
+	} else {
+		atomic.StoreInt32(&hasPkgLevels, 0)
+	}
+}
+
+// enabled returns whether an entry of the given level should be logged, taking
+// into account the package it's being logged from. It must be called from
+// within the log function (or something beneath it) so that the caller can be
+// found.
+func enabled(l Level) bool {
+	lvl := GetLevel()
+	pl := pkgLevels.Load()
+	if pl == nil {
+		return l >= lvl
+	} else if l >= lvl && l >= pl.max {
+		return true
+	} else if l < lvl && l < pl.min {
+		return false
+	}
+
+	stack := callers(0, 1)
+	if len(stack) == 0 {
+		return l >= lvl
+	}
+	pkg := funcPkg(stack[0].Function)
+	for _, prefix := range pl.prefixes {
+		if pkg == prefix || strings.HasPrefix(pkg, prefix+"/") {
+			return l >= pl.levels[prefix]
+		}
+	}
+	return l >= lvl
+}
+
+// Enabled returns whether an entry of the given level, logged from the calling
+// package, would be written by the package-level log functions, taking into
+// account both SetLevel and SetPackageLevels. It's cheap enough to guard the
+// building of KV which is expensive to compute:
+//
+//	if llog.Enabled(llog.DebugLevel) {
+//		llog.Debug("cache state", llog.KV{"entries": cache.Dump()})
+//	}
+//
+// Unless package overrides are in use it's only a couple of atomic loads, and
+// is inlined into the caller.
+func Enabled(l Level) bool {
+	if atomic.LoadInt32(&hasPkgLevels) == 0 {
+		return l >= GetLevel()
+	}
+	return enabled(l)
+}
+
+// funcPkg returns the import path of the package of the given fully qualified
+// function name, e.g. "github.com/example/app.(*T).Method" returns
+// "github.com/example/app"
+func funcPkg(fn string) string {
+	i := strings.LastIndexByte(fn, '/')
+	if j := strings.IndexByte(fn[i+1:], '.'); j >= 0 {
+		fn = fn[:i+1+j]
+	}
+	// dots in the last element of the path are escaped in function names
+	return strings.Replace(fn, "%2e", ".", -1)
+}
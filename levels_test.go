@@ -0,0 +1,78 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageLevels(t *T) {
+	oldOut := Out
+	defer func() {
+		Out = oldOut
+		SetPackageLevels(nil)
+	}()
+	buf := new(bytes.Buffer)
+	Out = buf
+
+	// tests within this package are logged from this package
+	SetPackageLevels(map[string]Level{
+		"github.com/levenlabs/go-llog/...":     DebugLevel,
+		"github.com/levenlabs/go-llog/llhttp":  ErrorLevel,
+		"github.com/levenlabs/go-llogger":      ErrorLevel,
+		"github.com/levenlabs/go-llog/foo/bar": FatalLevel,
+	})
+	Debug("foo")
+	Flush()
+	assert.Equal(t, "~ DEBUG -- foo\n", buf.String())
+
+	buf.Reset()
+	SetPackageLevels(map[string]Level{"github.com/levenlabs": WarnLevel})
+	Info("foo")
+	Warn("bar")
+	Flush()
+	assert.Equal(t, "~ WARN -- bar\n", buf.String())
+
+	buf.Reset()
+	SetPackageLevels(map[string]Level{"github.com/levenlabs/go": DebugLevel})
+	Debug("foo")
+	Info("bar")
+	Flush()
+	assert.Equal(t, "~ INFO -- bar\n", buf.String())
+}
+
+func TestEnabled(t *T) {
+	defer SetLevel(GetLevel())
+	defer SetPackageLevels(nil)
+
+	SetLevel(InfoLevel)
+	assert.False(t, Enabled(DebugLevel))
+	assert.True(t, Enabled(InfoLevel))
+	assert.False(t, new(Logger).Enabled(DebugLevel))
+	assert.True(t, With(KV{"foo": "bar"}).Enabled(WarnLevel))
+
+	SetPackageLevels(map[string]Level{"github.com/levenlabs/go-llog": DebugLevel})
+	assert.True(t, Enabled(DebugLevel))
+	assert.True(t, new(Logger).Enabled(DebugLevel))
+	SetPackageLevels(map[string]Level{"github.com/example": DebugLevel})
+	assert.False(t, Enabled(DebugLevel))
+
+	// Loggers created by New have their own level
+	l := New(WithOutput(new(bytes.Buffer)), WithLevel(WarnLevel))
+	defer l.Close()
+	assert.False(t, l.Enabled(InfoLevel))
+	assert.True(t, l.Enabled(WarnLevel))
+}
+
+func TestFuncPkg(t *T) {
+	for fn, pkg := range map[string]string{
+		"main.main":                           "main",
+		"github.com/example/app.Func":         "github.com/example/app",
+		"github.com/example/app.(*T).Method":  "github.com/example/app",
+		"github.com/example/app.Func.func1":   "github.com/example/app",
+		"gopkg.in/yaml%2ev3.(*decoder).parse": "gopkg.in/yaml.v3",
+	} {
+		assert.Equal(t, pkg, funcPkg(fn), fn)
+	}
+}
@@ -0,0 +1,127 @@
+package llog
+
+import (
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// SizeLimits describe the maximum sizes, in bytes, of entries' messages and
+// values. Values which are strings, byte slices, or errors are truncated to fit,
+// and have a marker like "…truncated 12034 bytes" appended. Values of other
+// types are never truncated. A limit of zero or less means no limit.
+type SizeLimits struct {
+	// Value is the maximum size of the message, and of any single value
+	Value int
+
+	// Entry is the maximum combined size of the message and all values. If an
+	// entry exceeds it its largest values are truncated first.
+	Entry int
+}
+
+// sizeLimits holds a *SizeLimits, or nil if they haven't been set
+var sizeLimits atomic.Value
+
+// SetSizeLimits sets the maximum sizes of entries' messages and values, so that
+// accidentally logging something huge (e.g. a response body) doesn't produce a
+// line which is too big for whatever is collecting logs. See SizeLimits. By
+// default there are no limits.
+func SetSizeLimits(l SizeLimits) {
+	sizeLimits.Store(&l)
+}
+
+func truncatedSuffix(n int) string {
+	return "…truncated " + strconv.Itoa(n) + " bytes"
+}
+
+// truncateBytes returns the string truncated to at most n bytes, not counting
+// the marker, without splitting a UTF-8 sequence
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncatedSuffix(len(s)-cut)
+}
+
+// truncatable returns the value as a string if it's of a type which can be
+// truncated
+func truncatable(v interface{}) (string, bool) {
+	switch vv := v.(type) {
+	case string:
+		return vv, true
+	case []byte:
+		return string(vv), true
+	case error:
+		if vv != nil {
+			return errorString(vv), true
+		}
+	}
+	return "", false
+}
+
+// limitEntry truncates the entry's message and values according to the
+// SizeLimits. Markers aren't counted towards the limits.
+func limitEntry(e Entry) Entry {
+	l, _ := sizeLimits.Load().(*SizeLimits)
+	if l == nil || (l.Value <= 0 && l.Entry <= 0) {
+		return e
+	}
+
+	// first work out how long each value is allowed to be, and only then
+	// truncate them, so that none are truncated twice
+	type sized struct {
+		k   string
+		s   string
+		max int
+	}
+	maxLen := func(s string) int {
+		if l.Value > 0 && len(s) > l.Value {
+			return l.Value
+		}
+		return len(s)
+	}
+	msgMax := maxLen(e.Msg)
+	total := msgMax
+	var values []sized
+	for k, v := range e.KV {
+		total += len(k)
+		if s, ok := truncatable(v); ok {
+			values = append(values, sized{k, s, maxLen(s)})
+			total += values[len(values)-1].max
+		}
+	}
+
+	if l.Entry > 0 && total > l.Entry {
+		// truncate the largest values first, until the entry fits
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].max != values[j].max {
+				return values[i].max > values[j].max
+			}
+			return values[i].k < values[j].k
+		})
+		for i := range values {
+			if total <= l.Entry {
+				break
+			}
+			reduce := total - l.Entry
+			if reduce > values[i].max {
+				reduce = values[i].max
+			}
+			values[i].max -= reduce
+			total -= reduce
+		}
+	}
+
+	e.Msg = truncateBytes(e.Msg, msgMax)
+	for _, sv := range values {
+		if len(sv.s) > sv.max {
+			e.KV[sv.k] = truncateBytes(sv.s, sv.max)
+		}
+	}
+	return e
+}
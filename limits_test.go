@@ -0,0 +1,55 @@
+package llog
+
+import (
+	"errors"
+	"strings"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateBytes(t *T) {
+	assert.Equal(t, "abc", truncateBytes("abc", 3))
+	assert.Equal(t, "ab…truncated 1 bytes", truncateBytes("abc", 2))
+	assert.Equal(t, "…truncated 3 bytes", truncateBytes("abc", 0))
+	// "é" is two bytes, and shouldn't be split
+	assert.Equal(t, "a…truncated 3 bytes", truncateBytes("aéb", 2))
+}
+
+func TestSizeLimits(t *T) {
+	defer SetSizeLimits(SizeLimits{})
+	e := func() Entry {
+		return Entry{Msg: "message", KV: KV{
+			"a": strings.Repeat("a", 20),
+			"b": []byte(strings.Repeat("b", 10)),
+			"c": errors.New("ccccc"),
+			"d": 123456789,
+		}}
+	}
+
+	SetSizeLimits(SizeLimits{Value: 8})
+	assert.Equal(t, Entry{Msg: "message", KV: KV{
+		"a": "aaaaaaaa…truncated 12 bytes",
+		"b": "bbbbbbbb…truncated 2 bytes",
+		"c": errors.New("ccccc"),
+		"d": 123456789,
+	}}, limitEntry(e()))
+
+	// 7 (msg) + 4 (keys) + 20 + 10 + 5 = 46, so 16 needs to come from the
+	// largest values
+	SetSizeLimits(SizeLimits{Entry: 30})
+	assert.Equal(t, Entry{Msg: "message", KV: KV{
+		"a": "aaaa…truncated 16 bytes",
+		"b": []byte(strings.Repeat("b", 10)),
+		"c": errors.New("ccccc"),
+		"d": 123456789,
+	}}, limitEntry(e()))
+
+	SetSizeLimits(SizeLimits{Value: 8, Entry: 20})
+	assert.Equal(t, Entry{Msg: "message", KV: KV{
+		"a": "…truncated 20 bytes",
+		"b": "bbbb…truncated 6 bytes",
+		"c": errors.New("ccccc"),
+		"d": 123456789,
+	}}, limitEntry(e()))
+}
@@ -0,0 +1,16 @@
+module github.com/levenlabs/go-llog/llconfig
+
+go 1.22
+
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/levenlabs/go-llog v1.0.1-0.20261016111038-c2d965122fef
+	github.com/stretchr/testify v1.11.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/levenlabs/errctx v1.0.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
@@ -0,0 +1,93 @@
+// Package llconfig loads llog.Config from JSON, YAML, or TOML files.
+//
+// Example config, in YAML:
+//
+//	level: info
+//	format: json
+//	timestamp: true
+//	outputs:
+//	  - type: stdout
+//	  - type: file
+//	    path: /var/log/app/error.log
+//	    level: error
+//	    format: text
+//	  - type: syslog
+//	    tag: app
+//	redact: [password, token]
+//	scrub:
+//	  - pattern: '\b\d{16}\b'
+//	    replacement: '[REDACTED]'
+package llconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/levenlabs/go-llog"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads the file at the given path and decodes it into an llog.Config.
+// The format of the file is determined by its extension, which must be one of
+// .json, .yaml, .yml, or .toml. Fields in the file which don't correspond to
+// anything in llog.Config are considered an error.
+func Load(path string) (llog.Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return llog.Config{}, err
+	}
+	return Parse(b, strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// Parse decodes the given bytes, in the given format ("json", "yaml", "yml", or
+// "toml"), into an llog.Config
+func Parse(b []byte, format string) (llog.Config, error) {
+	var cfg llog.Config
+	switch strings.ToLower(format) {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return cfg, err
+		}
+	case "yaml", "yml":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		// an empty document is a valid, empty, config
+		if err := dec.Decode(&cfg); err != nil && len(bytes.TrimSpace(b)) > 0 {
+			return cfg, err
+		}
+	case "toml":
+		md, err := toml.Decode(string(b), &cfg)
+		if err != nil {
+			return cfg, err
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i := range undecoded {
+				keys[i] = undecoded[i].String()
+			}
+			sort.Strings(keys)
+			return cfg, fmt.Errorf("unknown fields: %s", strings.Join(keys, ", "))
+		}
+	default:
+		return cfg, fmt.Errorf("unknown config format %q", format)
+	}
+	return cfg, nil
+}
+
+// Apply loads the config file at the given path and applies it using
+// llog.ApplyConfig
+func Apply(path string) error {
+	cfg, err := Load(path)
+	if err != nil {
+		return err
+	}
+	return llog.ApplyConfig(cfg)
+}
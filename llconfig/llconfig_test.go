@@ -0,0 +1,100 @@
+package llconfig
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	. "testing"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *T) {
+	tr := true
+	expected := llog.Config{
+		Level:     "info",
+		Format:    "json",
+		Timestamp: &tr,
+		Outputs: []llog.OutputConfig{
+			{Type: "stdout"},
+			{Type: "file", Path: "/tmp/err.log", Level: "error"},
+		},
+		Redact: []string{"password"},
+		Scrub:  []llog.ScrubConfig{{Pattern: `\d+`, Replacement: "#"}},
+	}
+
+	jsonCfg := `{
+		"level": "info",
+		"format": "json",
+		"timestamp": true,
+		"outputs": [
+			{"type": "stdout"},
+			{"type": "file", "path": "/tmp/err.log", "level": "error"}
+		],
+		"redact": ["password"],
+		"scrub": [{"pattern": "\\d+", "replacement": "#"}]
+	}`
+	yamlCfg := `
+level: info
+format: json
+timestamp: true
+outputs:
+  - type: stdout
+  - type: file
+    path: /tmp/err.log
+    level: error
+redact: [password]
+scrub:
+  - pattern: '\d+'
+    replacement: '#'
+`
+	tomlCfg := `
+level = "info"
+format = "json"
+timestamp = true
+redact = ["password"]
+
+[[outputs]]
+type = "stdout"
+
+[[outputs]]
+type = "file"
+path = "/tmp/err.log"
+level = "error"
+
+[[scrub]]
+pattern = '\d+'
+replacement = "#"
+`
+
+	for format, str := range map[string]string{"json": jsonCfg, "yaml": yamlCfg, "toml": tomlCfg} {
+		cfg, err := Parse([]byte(str), format)
+		require.NoError(t, err, format)
+		assert.Equal(t, expected, cfg, format)
+	}
+
+	cfg, err := Parse(nil, "yaml")
+	require.NoError(t, err)
+	assert.Equal(t, llog.Config{}, cfg)
+
+	for format, str := range map[string]string{
+		"json": `{"levle": "info"}`,
+		"yaml": `levle: info`,
+		"toml": `levle = "info"`,
+	} {
+		_, err := Parse([]byte(str), format)
+		assert.Error(t, err, format)
+	}
+
+	_, err = Parse(nil, "ini")
+	assert.EqualError(t, err, `unknown config format "ini"`)
+}
+
+func TestLoad(t *T) {
+	path := filepath.Join(t.TempDir(), "llog.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("level: warn\n"), 0644))
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, llog.Config{Level: "warn"}, cfg)
+}
@@ -0,0 +1,60 @@
+package llconfig
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/levenlabs/go-llog"
+)
+
+// ReloadOnSignal calls fn in a new go-routine every time one of the given
+// signals is received, or SIGHUP if none are given. Errors returned from fn are
+// logged. The returned function stops the signal handling, and can be called
+// more than once. On platforms without SIGHUP, e.g. js/wasm, it does nothing
+// if no signals are given.
+func ReloadOnSignal(fn func() error, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		if sigs = defaultReloadSignals; len(sigs) == 0 {
+			return func() {}
+		}
+	}
+	sigCh := make(chan os.Signal, 1)
+	stopCh := make(chan struct{})
+	signal.Notify(sigCh, sigs...)
+	go func() {
+		for {
+			select {
+			case sig := <-sigCh:
+				if err := fn(); err != nil {
+					llog.Error("Could not reload llog config", llog.KV{"signal": sig.String()}, llog.Err(err))
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigCh)
+			close(stopCh)
+		})
+	}
+}
+
+// Watch applies the config file at the given path, then applies it again every
+// time the process receives a SIGHUP, so that level, outputs, and formatting
+// can be changed without a restart. Only the initial Apply's error is returned,
+// errors from reloads are logged and leave the previous config in place.
+//
+// Fields which are removed from the file between reloads leave their
+// corresponding configuration as it was, rather than reverting it to the
+// default.
+func Watch(path string) (stop func(), err error) {
+	if err := Apply(path); err != nil {
+		return nil, err
+	}
+	return ReloadOnSignal(func() error { return Apply(path) }), nil
+}
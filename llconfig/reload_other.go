@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package llconfig
+
+import "os"
+
+// there's no SIGHUP on this platform
+var defaultReloadSignals []os.Signal
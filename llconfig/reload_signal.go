@@ -0,0 +1,10 @@
+//go:build unix || windows
+
+package llconfig
+
+import (
+	"os"
+	"syscall"
+)
+
+var defaultReloadSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,36 @@
+//go:build unix
+
+package llconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	. "testing"
+	"time"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *T) {
+	defer llog.SetLevel(llog.InfoLevel)
+
+	path := filepath.Join(t.TempDir(), "llog.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"level":"warn"}`), 0644))
+	stop, err := Watch(path)
+	require.NoError(t, err)
+	defer stop()
+	assert.Equal(t, llog.WarnLevel, llog.GetLevel())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"level":"debug"}`), 0644))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	assert.Eventually(t, func() bool {
+		return llog.GetLevel() == llog.DebugLevel
+	}, time.Second, 5*time.Millisecond)
+
+	stop()
+	stop()
+}
@@ -0,0 +1,21 @@
+module github.com/levenlabs/go-llog/llgrpc
+
+go 1.25.0
+
+require (
+	github.com/levenlabs/go-llog v1.0.1-0.20261016111038-c2d965122fef
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/grpc v1.82.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/levenlabs/errctx v1.0.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260414002931-afd174a4e478 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,107 @@
+package llgrpc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/levenlabs/go-llog"
+	"google.golang.org/grpc/grpclog"
+)
+
+// LoggerV2 implements the grpclog.LoggerV2 interface, writing gRPC's internal
+// logs as llog entries. gRPC's info logs are very chatty, and aren't shown by
+// gRPC's own default logger, so they are written at DebugLevel. Warning, Error
+// and Fatal logs are written at their llog equivalents.
+type LoggerV2 struct {
+	// Logger is used to write the entries. If nil then a Logger with the KV
+	// "component":"grpc" bound to it is used
+	Logger *llog.Logger
+
+	// Verbosity is the highest verbosity level V will return true for
+	Verbosity int
+}
+
+var _ grpclog.LoggerV2 = LoggerV2{}
+
+// ReplaceGrpcLogger sets a LoggerV2 with the given Verbosity as gRPC's logger.
+// Like grpclog.SetLoggerV2, this isn't thread-safe and should be called before
+// any gRPC functions are.
+func ReplaceGrpcLogger(verbosity int) {
+	grpclog.SetLoggerV2(LoggerV2{Verbosity: verbosity})
+}
+
+func (lv LoggerV2) logger() *llog.Logger {
+	if lv.Logger != nil {
+		return lv.Logger
+	}
+	return llog.With(llog.KV{"component": "grpc"})
+}
+
+func sprintln(args []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
+
+// Info implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Info(args ...interface{}) {
+	lv.logger().Debug(fmt.Sprint(args...))
+}
+
+// Infoln implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Infoln(args ...interface{}) {
+	lv.logger().Debug(sprintln(args))
+}
+
+// Infof implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Infof(format string, args ...interface{}) {
+	lv.logger().Debug(fmt.Sprintf(format, args...))
+}
+
+// Warning implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Warning(args ...interface{}) {
+	lv.logger().Warn(fmt.Sprint(args...))
+}
+
+// Warningln implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Warningln(args ...interface{}) {
+	lv.logger().Warn(sprintln(args))
+}
+
+// Warningf implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Warningf(format string, args ...interface{}) {
+	lv.logger().Warn(fmt.Sprintf(format, args...))
+}
+
+// Error implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Error(args ...interface{}) {
+	lv.logger().Error(fmt.Sprint(args...))
+}
+
+// Errorln implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Errorln(args ...interface{}) {
+	lv.logger().Error(sprintln(args))
+}
+
+// Errorf implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Errorf(format string, args ...interface{}) {
+	lv.logger().Error(fmt.Sprintf(format, args...))
+}
+
+// Fatal implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Fatal(args ...interface{}) {
+	lv.logger().Fatal(fmt.Sprint(args...))
+}
+
+// Fatalln implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Fatalln(args ...interface{}) {
+	lv.logger().Fatal(sprintln(args))
+}
+
+// Fatalf implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) Fatalf(format string, args ...interface{}) {
+	lv.logger().Fatal(fmt.Sprintf(format, args...))
+}
+
+// V implements the grpclog.LoggerV2 interface
+func (lv LoggerV2) V(l int) bool {
+	return l <= lv.Verbosity
+}
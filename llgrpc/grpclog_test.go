@@ -0,0 +1,35 @@
+package llgrpc
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerV2(t *T) {
+	buf := new(bytes.Buffer)
+	llog.Out = buf
+	llog.SetLevel(llog.DebugLevel)
+	defer llog.SetLevel(llog.InfoLevel)
+
+	lv := LoggerV2{Verbosity: 1}
+	lv.Info("foo", 1)
+	lv.Warningln("bar", 2)
+	lv.Errorf("baz %d", 3)
+	llog.Flush()
+	assert.Equal(t, "~ DEBUG -- foo1 -- component=\"grpc\"\n"+
+		"~ WARN -- bar 2 -- component=\"grpc\"\n"+
+		"~ ERROR -- baz 3 -- component=\"grpc\"\n", buf.String())
+
+	buf.Reset()
+	lv.Logger = llog.With(llog.KV{"a": "a"})
+	lv.Infof("foo")
+	llog.Flush()
+	assert.Equal(t, "~ DEBUG -- foo -- a=\"a\"\n", buf.String())
+
+	assert.True(t, lv.V(0))
+	assert.True(t, lv.V(1))
+	assert.False(t, lv.V(2))
+}
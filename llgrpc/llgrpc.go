@@ -0,0 +1,246 @@
+// Package llgrpc provides gRPC interceptors which log every RPC via llog, and
+// make a request-scoped llog.Logger available to server handlers through their
+// context.
+//
+// Examples:
+//
+//	s := grpc.NewServer(
+//		grpc.UnaryInterceptor(llgrpc.UnaryServerInterceptor()),
+//		grpc.StreamInterceptor(llgrpc.StreamServerInterceptor()),
+//	)
+//
+//	func (s *server) Foo(ctx context.Context, req *pb.FooRequest) (*pb.FooResponse, error) {
+//		llog.CtxLogger(ctx).Info("doing the thing")
+//		...
+//	}
+package llgrpc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/levenlabs/go-llog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultCodeLevels is the CodeLevels used by the package-level interceptor
+// functions. Codes which generally indicate a problem with the caller are
+// logged at InfoLevel, ones which might indicate a transient problem at
+// WarnLevel, and ones which indicate a bug at ErrorLevel.
+var DefaultCodeLevels = map[codes.Code]llog.Level{
+	codes.OK:                 llog.InfoLevel,
+	codes.Canceled:           llog.InfoLevel,
+	codes.Unknown:            llog.ErrorLevel,
+	codes.InvalidArgument:    llog.InfoLevel,
+	codes.DeadlineExceeded:   llog.WarnLevel,
+	codes.NotFound:           llog.InfoLevel,
+	codes.AlreadyExists:      llog.InfoLevel,
+	codes.PermissionDenied:   llog.WarnLevel,
+	codes.Unauthenticated:    llog.InfoLevel,
+	codes.ResourceExhausted:  llog.WarnLevel,
+	codes.FailedPrecondition: llog.WarnLevel,
+	codes.Aborted:            llog.WarnLevel,
+	codes.OutOfRange:         llog.WarnLevel,
+	codes.Unimplemented:      llog.ErrorLevel,
+	codes.Internal:           llog.ErrorLevel,
+	codes.Unavailable:        llog.WarnLevel,
+	codes.DataLoss:           llog.ErrorLevel,
+}
+
+// Interceptor describes how RPCs should be logged. Every RPC is logged at
+// DebugLevel when it starts, and at the level given by CodeLevels when it
+// finishes. The zero value logs every finished RPC at ErrorLevel
+type Interceptor struct {
+	// CodeLevels maps the code an RPC finished with to the level it should be
+	// logged at. Codes which aren't in the map are logged at ErrorLevel
+	CodeLevels map[codes.Code]llog.Level
+}
+
+var defaultInterceptor = Interceptor{CodeLevels: DefaultCodeLevels}
+
+// UnaryServerInterceptor returns the UnaryServer of an Interceptor using
+// DefaultCodeLevels
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return defaultInterceptor.UnaryServer()
+}
+
+// StreamServerInterceptor returns the StreamServer of an Interceptor using
+// DefaultCodeLevels
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return defaultInterceptor.StreamServer()
+}
+
+// UnaryClientInterceptor returns the UnaryClient of an Interceptor using
+// DefaultCodeLevels
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return defaultInterceptor.UnaryClient()
+}
+
+// StreamClientInterceptor returns the StreamClient of an Interceptor using
+// DefaultCodeLevels
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return defaultInterceptor.StreamClient()
+}
+
+func (i Interceptor) level(code codes.Code) llog.Level {
+	if lvl, ok := i.CodeLevels[code]; ok {
+		return lvl
+	}
+	return llog.ErrorLevel
+}
+
+// start logs the start of an RPC and returns the Logger it should be logged
+// with, as well as a function which logs its finish
+func (i Interceptor) start(ctx context.Context, method string) (*llog.Logger, func(error)) {
+	start := time.Now()
+	kv := llog.KV{"method": method}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		kv["peer"] = p.Addr.String()
+	}
+	l := llog.CtxLogger(ctx).With(kv)
+	l.Debug("Started grpc call")
+
+	return l, func(err error) {
+		code := status.Code(err)
+		kv := llog.KV{
+			// not "code", which is llog's key for event codes
+			"grpcCode": code.String(),
+			"duration": time.Since(start),
+		}
+		if err != nil {
+			kv = llog.Merge(llog.Err(err), kv)
+		}
+		l.Log(i.level(code), "Finished grpc call", kv)
+	}
+}
+
+// UnaryServer returns a grpc.UnaryServerInterceptor which logs each RPC's
+// start and finish with its method, code (as grpcCode), duration and peer as
+// KV. A child of the context's llog.Logger, with the method and peer bound to
+// it, is embedded into the context passed to the handler, and can be retrieved
+// with llog.CtxLogger.
+func (i Interceptor) UnaryServer() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		l, finish := i.start(ctx, info.FullMethod)
+		res, err := handler(llog.CtxWithLogger(ctx, l), req)
+		finish(err)
+		return res, err
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context implements the grpc.ServerStream interface
+func (ss serverStream) Context() context.Context {
+	return ss.ctx
+}
+
+// StreamServer is like UnaryServer, but for streaming RPCs. The stream's
+// Context will have the request-scoped llog.Logger embedded into it
+func (i Interceptor) StreamServer() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		l, finish := i.start(ctx, info.FullMethod)
+		err := handler(srv, serverStream{
+			ServerStream: ss,
+			ctx:          llog.CtxWithLogger(ctx, l),
+		})
+		finish(err)
+		return err
+	}
+}
+
+// UnaryClient returns a grpc.UnaryClientInterceptor which logs each RPC's
+// start and finish with its method, code (as grpcCode), and duration as KV. The
+// KV bound to the context's llog.Logger is included as well
+func (i Interceptor) UnaryClient() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		_, finish := i.start(ctx, method)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		finish(err)
+		return err
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	desc       *grpc.StreamDesc
+	finishOnce sync.Once
+	finishedCh chan struct{}
+	finish     func(error)
+}
+
+// end logs the RPC as finished, if it hasn't been already
+func (cs *clientStream) end(err error) {
+	cs.finishOnce.Do(func() {
+		close(cs.finishedCh)
+		cs.finish(err)
+	})
+}
+
+// SendMsg implements the grpc.ClientStream interface. The RPC is considered
+// finished if SendMsg returns an error other than io.EOF, which only means that
+// the error will be returned by RecvMsg instead
+func (cs *clientStream) SendMsg(m interface{}) error {
+	err := cs.ClientStream.SendMsg(m)
+	if err != nil && err != io.EOF {
+		cs.end(err)
+	}
+	return err
+}
+
+// RecvMsg implements the grpc.ClientStream interface. The RPC is considered
+// finished once RecvMsg returns an error, with io.EOF meaning it finished
+// successfully, or once it returns the only message of an RPC whose server
+// doesn't stream
+func (cs *clientStream) RecvMsg(m interface{}) error {
+	err := cs.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		cs.end(nil)
+	} else if err != nil {
+		cs.end(err)
+	} else if !cs.desc.ServerStreams {
+		cs.end(nil)
+	}
+	return err
+}
+
+// StreamClient is like UnaryClient, but for streaming RPCs. The RPC is logged
+// as finished when the stream fails to be created, RecvMsg returns an error or
+// the only message of an RPC whose server doesn't stream, SendMsg fails, or the
+// context is done. As with grpc itself, a stream which isn't finished in one of
+// those ways leaks a go-routine, the one which watches the context.
+func (i Interceptor) StreamClient() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		_, finish := i.start(ctx, method)
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			finish(err)
+			return nil, err
+		}
+		cs := &clientStream{
+			ClientStream: s,
+			desc:         desc,
+			finishedCh:   make(chan struct{}),
+			finish:       finish,
+		}
+		// the interceptor's ctx is watched rather than s.Context(), since
+		// calling that disables retries
+		go func() {
+			select {
+			case <-ctx.Done():
+				cs.end(status.FromContextError(ctx.Err()).Err())
+			case <-cs.finishedCh:
+			}
+		}()
+		return cs, nil
+	}
+}
@@ -0,0 +1,167 @@
+package llgrpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	. "testing"
+	"time"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type healthServer struct {
+	*health.Server
+}
+
+func (hs healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	llog.CtxLogger(ctx).Info("in handler")
+	return hs.Server.Check(ctx, req)
+}
+
+// sumHandler handles any unknown method as a client-streaming RPC, responding
+// once the client has finished sending
+func sumHandler(_ interface{}, stream grpc.ServerStream) error {
+	for {
+		err := stream.RecvMsg(new(healthpb.HealthCheckRequest))
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	return stream.SendMsg(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+func testConn(t *T) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor()),
+		grpc.StreamInterceptor(StreamServerInterceptor()),
+		grpc.UnknownServiceHandler(sumHandler),
+	)
+	hs := health.NewServer()
+	hs.SetServingStatus("foo", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer{hs})
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(StreamClientInterceptor()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { cc.Close() })
+	return cc
+}
+
+func testClient(t *T) healthpb.HealthClient {
+	return healthpb.NewHealthClient(testConn(t))
+}
+
+func TestUnary(t *T) {
+	buf := new(bytes.Buffer)
+	llog.SetOutput(buf)
+	llog.SetLevel(llog.InfoLevel)
+	client := testClient(t)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "foo"})
+	require.NoError(t, err)
+	llog.Flush()
+	lines := strings.SplitAfter(buf.String(), "\n")
+	require.Len(t, lines, 4)
+	assert.Regexp(t, regexp.MustCompile(`^~ INFO -- in handler -- method="/grpc.health.v1.Health/Check" peer="bufconn"\n$`), lines[0])
+	assert.Regexp(t, regexp.MustCompile(`^~ INFO -- Finished grpc call -- duration="[^"]+" grpcCode="OK" method="/grpc.health.v1.Health/Check" peer="bufconn"\n$`), lines[1])
+	assert.Regexp(t, regexp.MustCompile(`^~ INFO -- Finished grpc call -- duration="[^"]+" grpcCode="OK" method="/grpc.health.v1.Health/Check"\n$`), lines[2])
+
+	buf.Reset()
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "bar"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	llog.Flush()
+	assert.Contains(t, buf.String(), `grpcCode="NotFound"`)
+}
+
+func TestStream(t *T) {
+	buf := new(bytes.Buffer)
+	llog.SetOutput(buf)
+	llog.SetLevel(llog.InfoLevel)
+	client := testClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "foo"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	cancel()
+	_, err = stream.Recv()
+	require.Equal(t, codes.Canceled, status.Code(err))
+
+	llog.Flush()
+	assert.Regexp(t, regexp.MustCompile(`~ INFO -- Finished grpc call -- duration="[^"]+" err="[^"]+" errType="[^"]+" grpcCode="Canceled" method="/grpc.health.v1.Health/Watch"\n`), buf.String())
+}
+
+func TestStreamCancel(t *T) {
+	buf := new(bytes.Buffer)
+	llog.SetOutput(buf)
+	llog.SetLevel(llog.InfoLevel)
+	client := testClient(t)
+
+	// the RPC is finished once the context is done, even without RecvMsg
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "foo"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	cancel()
+
+	re := regexp.MustCompile(`~ INFO -- Finished grpc call -- duration="[^"]+" err="[^"]+" errType="[^"]+" grpcCode="Canceled" method="/grpc.health.v1.Health/Watch"\n`)
+	assert.Eventually(t, func() bool {
+		llog.Flush()
+		return re.MatchString(buf.String())
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestClientStream(t *T) {
+	buf := new(bytes.Buffer)
+	llog.SetOutput(buf)
+	llog.SetLevel(llog.InfoLevel)
+	cc := testConn(t)
+
+	// the RPC is finished once its only response has been received
+	desc := &grpc.StreamDesc{StreamName: "Sum", ClientStreams: true}
+	stream, err := cc.NewStream(context.Background(), desc, "/test.Test/Sum")
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, stream.SendMsg(&healthpb.HealthCheckRequest{Service: "foo"}))
+	}
+	require.NoError(t, stream.CloseSend())
+	res := new(healthpb.HealthCheckResponse)
+	require.NoError(t, stream.RecvMsg(res))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+
+	llog.Flush()
+	assert.Regexp(t, regexp.MustCompile(`~ INFO -- Finished grpc call -- duration="[^"]+" grpcCode="OK" method="/test.Test/Sum"\n`), buf.String())
+}
+
+func TestInterceptorLevel(t *T) {
+	assert.Equal(t, llog.InfoLevel, defaultInterceptor.level(codes.OK))
+	assert.Equal(t, llog.WarnLevel, defaultInterceptor.level(codes.Unavailable))
+	assert.Equal(t, llog.ErrorLevel, defaultInterceptor.level(codes.Internal))
+	assert.Equal(t, llog.ErrorLevel, Interceptor{}.level(codes.OK))
+}
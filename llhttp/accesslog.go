@@ -0,0 +1,80 @@
+package llhttp
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// accessLogTime is the layout of timestamps in the NCSA log formats
+const accessLogTime = "02/Jan/2006:15:04:05 -0700"
+
+// appendAccessLine appends a line describing the request to buf in the NCSA
+// combined log format, e.g.:
+//
+//	192.0.2.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /foo HTTP/1.1" 200 2326 "http://example.com/" "Mozilla/5.0"
+func appendAccessLine(buf []byte, r *http.Request, status int, bytes int64, start time.Time) []byte {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	buf = appendAccessField(buf, host)
+	buf = append(buf, " - "...)
+	user, _, _ := r.BasicAuth()
+	buf = appendAccessField(buf, user)
+	buf = append(buf, " ["...)
+	buf = start.AppendFormat(buf, accessLogTime)
+	buf = append(buf, "] "...)
+	buf = appendAccessQuoted(buf, r.Method+" "+r.RequestURI+" "+r.Proto)
+	buf = append(buf, ' ')
+	buf = strconv.AppendInt(buf, int64(status), 10)
+	buf = append(buf, ' ')
+	if bytes > 0 {
+		buf = strconv.AppendInt(buf, bytes, 10)
+	} else {
+		buf = append(buf, '-')
+	}
+	buf = append(buf, ' ')
+	buf = appendAccessQuoted(buf, r.Referer())
+	buf = append(buf, ' ')
+	buf = appendAccessQuoted(buf, r.UserAgent())
+	return append(buf, '\n')
+}
+
+// appendAccessField appends an unquoted field, which is "-" if empty
+func appendAccessField(buf []byte, s string) []byte {
+	if s == "" {
+		return append(buf, '-')
+	}
+	return appendAccessEscaped(buf, s, true)
+}
+
+// appendAccessQuoted appends a quoted field, which is "-" if empty
+func appendAccessQuoted(buf []byte, s string) []byte {
+	if s == "" {
+		s = "-"
+	}
+	buf = append(buf, '"')
+	buf = appendAccessEscaped(buf, s, false)
+	return append(buf, '"')
+}
+
+// appendAccessEscaped appends the string escaped the same way Apache does, so
+// that a client can't forge lines or fields: quotes and backslashes are
+// backslash escaped, and non-printable bytes are written as \xhh. If space is
+// true then spaces are escaped as well, for unquoted fields.
+func appendAccessEscaped(buf []byte, s string, space bool) []byte {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c < 0x20 || c > 0x7e || (space && c == ' '):
+			buf = append(buf, '\\', 'x', hex[c>>4], hex[c&0xf])
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,41 @@
+package llhttp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	. "testing"
+	"time"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendAccessLine(t *T) {
+	start := time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60))
+	r := httptest.NewRequest("GET", "/foo?a=b", nil)
+	r.SetBasicAuth("frank", "secret")
+	r.Header.Set("Referer", "http://example.com/")
+	r.Header.Set("User-Agent", `Mozilla/5.0 "evil"`+"\n")
+	assert.Equal(t,
+		`192.0.2.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /foo?a=b HTTP/1.1" 200 2326 "http://example.com/" "Mozilla/5.0 \"evil\"\x0a"`+"\n",
+		string(appendAccessLine(nil, r, 200, 2326, start)))
+
+	r = httptest.NewRequest("POST", "/", nil)
+	r.RemoteAddr = "192.0.2.2"
+	assert.Equal(t,
+		`192.0.2.2 - - [10/Oct/2000:13:55:36 -0700] "POST / HTTP/1.1" 204 - "-" "-"`+"\n",
+		string(appendAccessLine(nil, r, 204, 0, start)))
+}
+
+func TestMiddlewareAccessLog(t *T) {
+	llog.Out = new(bytes.Buffer)
+	access := new(bytes.Buffer)
+	h := Middleware{AccessLog: access}.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("hello"))
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/foo", nil))
+	llog.Flush()
+	assert.Regexp(t, `^192\.0\.2\.1 - - \[[^\]]+\] "GET /foo HTTP/1\.1" 404 5 "-" "-"\n$`, access.String())
+}
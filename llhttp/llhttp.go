@@ -0,0 +1,182 @@
+// Package llhttp provides net/http middleware which logs every request handled
+// via llog, and makes a request-scoped llog.Logger available to handlers
+// through the request's context.
+//
+// Examples:
+//
+//	http.ListenAndServe(":8080", llhttp.Handler(mux))
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		llog.CtxLogger(r.Context()).Info("doing the thing")
+//	}
+package llhttp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/levenlabs/go-llog"
+)
+
+// DefaultStatusLevels is the StatusLevels used by Handler. 4xx responses are
+// logged at WarnLevel, 5xx at ErrorLevel, and everything else at InfoLevel
+var DefaultStatusLevels = map[int]llog.Level{
+	4: llog.WarnLevel,
+	5: llog.ErrorLevel,
+}
+
+// Middleware describes how requests should be logged. The zero value logs
+// every request at InfoLevel
+type Middleware struct {
+	// StatusLevels maps the class of a response's status code (the status
+	// divided by 100, e.g. 5 for all 5xx statuses) to the level the request
+	// should be logged at. Classes which aren't in the map are logged at
+	// InfoLevel
+	StatusLevels map[int]llog.Level
+
+	// RequestIDHeader enables request IDs when set, and is the name of the
+	// header they're read from and written to, usually RequestIDHeader. Each
+	// request's ID is taken from its header, if that holds a valid one, or
+	// else generated by llog.NewRequestID. It's then embedded in the request's
+	// context with llog.CtxWithRequestID, so that it's included in every entry
+	// logged via llog.CtxLogger, and set in the response's header.
+	RequestIDHeader string
+
+	// AccessLog, if set, is written a line for every request in the NCSA
+	// combined log format, in addition to the entry logged via llog, for
+	// tools which only understand that format. It must be safe to call Write
+	// on concurrently, which an *llog.FileWriter or *os.File is.
+	AccessLog io.Writer
+}
+
+// RequestIDHeader is the usual header used for request IDs
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen is the longest request ID which will be accepted from a
+// request's header
+const maxRequestIDLen = 128
+
+// Handler wraps the given http.Handler using a Middleware with
+// DefaultStatusLevels
+func Handler(h http.Handler) http.Handler {
+	return Middleware{StatusLevels: DefaultStatusLevels}.Wrap(h)
+}
+
+// Wrap returns an http.Handler which calls the given one, logging each request
+// once it has been handled with its method, path, status, duration, bytes
+// written, and remote address as KV.
+//
+// Before the given handler is called a child of the request context's
+// llog.Logger, with the method, path, and remoteAddr bound to it, is embedded
+// into the request's context, and can be retrieved with llog.CtxLogger.
+func (m Middleware) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		if m.RequestIDHeader != "" {
+			id := r.Header.Get(m.RequestIDHeader)
+			if !validRequestID(id) {
+				id = llog.NewRequestID()
+			}
+			w.Header().Set(m.RequestIDHeader, id)
+			r = r.WithContext(llog.CtxWithRequestID(r.Context(), id))
+		}
+		l := llog.CtxLogger(r.Context()).With(llog.KV{
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"remoteAddr": r.RemoteAddr,
+		})
+		r = r.WithContext(llog.CtxWithLogger(r.Context(), l))
+
+		rw := &responseWriter{ResponseWriter: w}
+		h.ServeHTTP(rw, r)
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+
+		kv := llog.KV{
+			"status":   rw.status,
+			"duration": time.Since(start),
+			"bytes":    rw.bytes,
+		}
+		l.Log(m.level(rw.status), "Handled http request", kv)
+		if m.AccessLog != nil {
+			if _, err := m.AccessLog.Write(appendAccessLine(nil, r, rw.status, rw.bytes, start)); err != nil {
+				l.Error("error writing access log", llog.ErrKV(err))
+			}
+		}
+	})
+}
+
+// validRequestID returns whether a request ID taken from a request's header is
+// safe to use. Since it's entirely controlled by the client it's limited to a
+// reasonable length of printable ASCII.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Middleware) level(status int) llog.Level {
+	if lvl, ok := m.StatusLevels[status/100]; ok {
+		return lvl
+	}
+	return llog.InfoLevel
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader implements the http.ResponseWriter interface
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements the http.ResponseWriter interface
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements the http.Flusher interface, if the underlying
+// ResponseWriter does
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements the http.Hijacker interface, if the underlying
+// ResponseWriter does
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("underlying ResponseWriter is not an http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter, for use by
+// http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,88 @@
+package llhttp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	. "testing"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *T) {
+	buf := bytes.NewBuffer(make([]byte, 0, 128))
+	llog.Out = buf
+	llog.SetLevel(llog.InfoLevel)
+
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		llog.CtxLogger(r.Context()).Info("in handler")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte("hello"))
+	}))
+
+	assertOut := func(expected string) {
+		out, err := buf.ReadString('\n')
+		require.NoError(t, err)
+		assert.Regexp(t, regexp.MustCompile(expected), out)
+	}
+
+	r := httptest.NewRequest("GET", "/foo", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	llog.Flush()
+	assertOut(`^~ INFO -- in handler -- method="GET" path="/foo" remoteAddr="192.0.2.1:1234"\n$`)
+	assertOut(`^~ INFO -- Handled http request -- bytes="5" duration="[^"]+" method="GET" path="/foo" remoteAddr="192.0.2.1:1234" status="200"\n$`)
+
+	r = httptest.NewRequest("POST", "/missing", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	llog.Flush()
+	assertOut(`^~ INFO -- in handler -- `)
+	assertOut(`^~ WARN -- Handled http request -- bytes="5" duration="[^"]+" method="POST" path="/missing" remoteAddr="192.0.2.1:1234" status="404"\n$`)
+}
+
+func TestMiddlewareLevel(t *T) {
+	m := Middleware{StatusLevels: DefaultStatusLevels}
+	assert.Equal(t, llog.InfoLevel, m.level(200))
+	assert.Equal(t, llog.InfoLevel, m.level(302))
+	assert.Equal(t, llog.WarnLevel, m.level(404))
+	assert.Equal(t, llog.ErrorLevel, m.level(503))
+
+	m = Middleware{}
+	assert.Equal(t, llog.InfoLevel, m.level(503))
+}
+
+func TestMiddlewareRequestID(t *T) {
+	buf := bytes.NewBuffer(make([]byte, 0, 128))
+	llog.Out = buf
+	llog.SetLevel(llog.InfoLevel)
+
+	var id string
+	h := Middleware{RequestIDHeader: RequestIDHeader}.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = llog.CtxRequestID(r.Context())
+	}))
+
+	r := httptest.NewRequest("GET", "/foo", nil)
+	r.Header.Set(RequestIDHeader, "abc-123")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	llog.Flush()
+	assert.Equal(t, "abc-123", id)
+	assert.Equal(t, "abc-123", rw.Header().Get(RequestIDHeader))
+	assert.Contains(t, buf.String(), ` requestID="abc-123" `)
+
+	for _, reqID := range []string{"", "has space", strings.Repeat("a", 129)} {
+		r = httptest.NewRequest("GET", "/foo", nil)
+		r.Header.Set(RequestIDHeader, reqID)
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		assert.NotEqual(t, reqID, id)
+		assert.Len(t, id, 36)
+		assert.Equal(t, id, rw.Header().Get(RequestIDHeader))
+	}
+	llog.Flush()
+}
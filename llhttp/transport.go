@@ -0,0 +1,209 @@
+package llhttp
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/levenlabs/go-llog"
+)
+
+// Transport is an http.RoundTripper which logs every request made through it,
+// giving outbound requests the same visibility which Middleware gives handled
+// ones. Each request is logged once it has finished, via the llog.Logger in its
+// context (see llog.CtxLogger), with its method, url, status, duration, and
+// number of retries as KV, plus its error if it failed. The zero value logs
+// every request at InfoLevel, or ErrorLevel if it failed.
+//
+// Examples:
+//
+//	client := &http.Client{Transport: llhttp.Transport{StatusLevels: llhttp.DefaultStatusLevels}}
+type Transport struct {
+	// Base is the RoundTripper which actually makes requests. Defaults to
+	// http.DefaultTransport
+	Base http.RoundTripper
+
+	// StatusLevels maps the class of a response's status code to the level the
+	// request should be logged at, the same as for Middleware
+	StatusLevels map[int]llog.Level
+
+	// HostLevels maps hosts (without ports) to the level requests to them are
+	// logged at when their status class isn't in StatusLevels, instead of
+	// InfoLevel. It's useful for quieting frequent requests to particular
+	// services, e.g. {"metadata.google.internal": llog.DebugLevel}.
+	HostLevels map[string]llog.Level
+
+	// Retries is the maximum number of times a request which failed, or got a
+	// 5xx response, is retried. Only requests with idempotent methods, and
+	// either no body or a GetBody func, are retried.
+	Retries int
+
+	// Backoff is how long to wait before the first retry, it doubles for each
+	// subsequent one. Defaults to 100 milliseconds
+	Backoff time.Duration
+
+	// MaxBackoff is the longest to wait between any two attempts. Defaults to
+	// 1 second
+	MaxBackoff time.Duration
+
+	// Headers are the names of request headers which are included in the KV,
+	// under "headers". Their values are subject to llog's redaction, see
+	// llog.SetRedactedKeys, which includes Authorization by default.
+	Headers []string
+
+	// RedactHeaders are the names of headers whose values are always logged as
+	// llog.Redacted, in addition to llog's redacted keys
+	RedactHeaders []string
+
+	// BodyLimit, if positive, is the number of bytes of each request's body
+	// which are included in the KV, under "body". Only bodies which can be read
+	// again using the request's GetBody func are included, which is the case
+	// for requests created by http.NewRequest with a bytes or strings reader.
+	BodyLimit int
+
+	// RedactBody, if set, is called on each body before it's included in the
+	// KV, e.g. to remove secrets from it. llog's Scrubbers are also applied to
+	// it, see llog.AddScrubber.
+	RedactBody func(body string) string
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	kv := llog.KV{
+		"method": r.Method,
+		"url":    r.URL.Redacted(),
+	}
+	if headers := t.headersKV(r.Header); len(headers) > 0 {
+		kv["headers"] = headers
+	}
+	if body, ok := t.body(r); ok {
+		kv["body"] = body
+	}
+
+	resp, retries, err := t.roundTrip(r)
+	kv["duration"] = time.Since(start)
+	kv["retries"] = retries
+	l := llog.CtxLogger(r.Context())
+	if err != nil {
+		l.Error("Error making http request", kv, llog.ErrKV(err))
+		return nil, err
+	}
+	kv["status"] = resp.StatusCode
+	l.Log(t.level(r.URL.Hostname(), resp.StatusCode), "Made http request", kv)
+	return resp, nil
+}
+
+// roundTrip makes the request, retrying it if it fails and can be retried, and
+// returns the final response and number of retries
+func (t Transport) roundTrip(r *http.Request) (*http.Response, int, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	backoff, maxBackoff := t.Backoff, t.MaxBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = time.Second
+	}
+
+	resp, err := base.RoundTrip(r)
+	var retries int
+	for ; retries < t.Retries && retryable(r, resp, err); retries++ {
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return nil, retries, r.Context().Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+
+		rr := r.Clone(r.Context())
+		if r.GetBody != nil {
+			if rr.Body, err = r.GetBody(); err != nil {
+				return nil, retries, err
+			}
+		}
+		resp, err = base.RoundTrip(rr)
+	}
+	return resp, retries, err
+}
+
+// retryable returns whether a request which got the given response or error
+// can and should be retried
+func retryable(r *http.Request, resp *http.Response, err error) bool {
+	if err == nil && resp.StatusCode < 500 {
+		return false
+	}
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+	default:
+		return false
+	}
+	return r.Body == nil || r.Body == http.NoBody || r.GetBody != nil
+}
+
+func (t Transport) level(host string, status int) llog.Level {
+	if lvl, ok := t.StatusLevels[status/100]; ok {
+		return lvl
+	}
+	if lvl, ok := t.HostLevels[host]; ok {
+		return lvl
+	}
+	return llog.InfoLevel
+}
+
+// headersKV returns a KV of the values of the headers in Headers
+func (t Transport) headersKV(h http.Header) llog.KV {
+	var kv llog.KV
+	for _, name := range t.Headers {
+		v := h.Values(name)
+		if len(v) == 0 {
+			continue
+		}
+		if kv == nil {
+			kv = llog.KV{}
+		}
+		name = http.CanonicalHeaderKey(name)
+		kv[name] = strings.Join(v, ", ")
+		for _, rname := range t.RedactHeaders {
+			if strings.EqualFold(name, rname) {
+				kv[name] = llog.Redacted
+			}
+		}
+	}
+	return kv
+}
+
+// body returns up to BodyLimit bytes of the request's body, if it can be read
+// without consuming the body which will be sent
+func (t Transport) body(r *http.Request) (string, bool) {
+	if t.BodyLimit <= 0 || r.GetBody == nil {
+		return "", false
+	}
+	rc, err := r.GetBody()
+	if err != nil {
+		return "", false
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, int64(t.BodyLimit)))
+	if err != nil {
+		return "", false
+	}
+	body := string(b)
+	if t.RedactBody != nil {
+		body = t.RedactBody(body)
+	}
+	return body, true
+}
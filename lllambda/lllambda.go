@@ -0,0 +1,66 @@
+// Package lllambda helps llog work within AWS Lambda functions, where the
+// sandbox can be frozen as soon as a handler returns, losing any entries which
+// haven't been written yet.
+//
+// Examples:
+//
+//	func main() {
+//		lllambda.Configure()
+//		lambda.Start(lllambda.Wrap(handle, func(ctx context.Context) string {
+//			lc, _ := lambdacontext.FromContext(ctx)
+//			return lc.AwsRequestID
+//		}))
+//	}
+//
+//	func handle(ctx context.Context, req Request) (Response, error) {
+//		// includes requestID
+//		llog.Info("handling")
+//		...
+//	}
+package lllambda
+
+import (
+	"context"
+
+	"github.com/levenlabs/go-llog"
+)
+
+// Configure makes llog write entries synchronously, from within the log call,
+// rather than from a separate go-routine which would be frozen along with the
+// sandbox, see llog.SetSynchronous. Like SetSynchronous it must be called
+// before any logging occurs, generally at the very start of main.
+func Configure() {
+	llog.SetSynchronous(true)
+}
+
+// Wrap returns a handler which calls fn, with the invocation's AWS request ID
+// included in every entry written during it, under llog.RequestIDKey. The
+// request ID is also embedded in the Context given to fn, see
+// llog.CtxWithRequestID. llog is flushed before the handler returns, so that
+// nothing is lost when the sandbox is frozen.
+//
+// requestID returns the request ID of the invocation whose Context it's given.
+// llog doesn't depend on aws-lambda-go, so this is generally a function which
+// calls lambdacontext.FromContext, see the package example. If it's nil, or
+// returns an empty string, no request ID is included.
+//
+// Lambda only runs one invocation at a time in each sandbox, so the request ID
+// is included using llog.SetGlobalKV, and the previous global KV is restored
+// when the handler returns. This means fn must not return before go-routines it
+// started have finished logging.
+func Wrap[In, Out any](fn func(context.Context, In) (Out, error), requestID func(context.Context) string) func(context.Context, In) (Out, error) {
+	return func(ctx context.Context, in In) (Out, error) {
+		var id string
+		if requestID != nil {
+			id = requestID(ctx)
+		}
+		if id != "" {
+			ctx = llog.CtxWithRequestID(ctx, id)
+			prev := llog.GetGlobalKV()
+			llog.SetGlobalKV(llog.Merge(prev, llog.KV{llog.RequestIDKey: id}))
+			defer llog.SetGlobalKV(prev)
+		}
+		defer llog.Flush()
+		return fn(ctx, in)
+	}
+}
@@ -0,0 +1,44 @@
+package lllambda
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	. "testing"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+)
+
+type requestIDKey struct{}
+
+func TestWrap(t *T) {
+	Configure()
+	oldOut := llog.Out
+	defer llog.SetOutput(oldOut)
+	buf := new(bytes.Buffer)
+	llog.SetOutput(buf)
+	llog.SetGlobalKV(llog.KV{"env": "prod"})
+	defer llog.SetGlobalKV(nil)
+
+	handler := Wrap(func(ctx context.Context, in string) (int, error) {
+		llog.Info("handling", llog.KV{"in": in})
+		llog.CtxLogger(ctx).Info("ctx")
+		return len(in), errors.New("failed")
+	}, func(ctx context.Context) string {
+		id, _ := ctx.Value(requestIDKey{}).(string)
+		return id
+	})
+
+	n, err := handler(context.WithValue(context.Background(), requestIDKey{}, "abc"), "foo")
+	assert.Equal(t, 3, n)
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, "~ INFO -- handling -- env=\"prod\" in=\"foo\" requestID=\"abc\"\n"+
+		"~ INFO -- ctx -- env=\"prod\" requestID=\"abc\"\n", buf.String())
+	assert.Equal(t, llog.KV{"env": "prod"}, llog.GetGlobalKV())
+
+	// without a request ID nothing is added
+	buf.Reset()
+	_, _ = handler(context.Background(), "bar")
+	assert.Equal(t, "~ INFO -- handling -- env=\"prod\" in=\"bar\"\n~ INFO -- ctx -- env=\"prod\"\n", buf.String())
+}
@@ -13,32 +13,57 @@
 //
 // All public functions in this package are thread-safe and can be called at any
 // time. The public variables in this package are NOT thread-safe and should
-// only be modified before any logging takes place
+// only be modified directly before any logging takes place. SetOutput,
+// SetFormatter, and SetDisplayTimestamp can be used to change them safely at
+// any time.
 //
 // Examples:
 //
 //	Info("Something important has occurred")
-//	Error("Could not open file", llog.KV{"filename": filename}, llog.ErrKV(err))
-//
+//	Error("Could not open file", llog.KV{"filename": filename}, llog.Err(err))
 package llog
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Out is the io.Writer all log entries will be written to. It can be changed to
-// anything you like, but the change should happen before any logging occurs. If
-// an error occurs while writing to Out the entry will be written to Stdout
-// instead
+// anything you like, but the change should happen before any logging occurs,
+// or else be made using SetOutput. If an error occurs while writing to Out the
+// write error handler is called, which by default writes the entry to Stdout
+// instead (see OnWriteError). Out may be set to nil if entries should only be
+// written to Sinks.
 var Out io.Writer = os.Stdout
-var defaultOut = os.Stdout
+var defaultOut io.Writer = os.Stdout
+
+// SetOutput sets Out. Unlike setting Out directly it's safe to call at any
+// time, the change takes effect in between entries being written.
+func SetOutput(w io.Writer) {
+	globalCore.apply(func() { Out = w })
+}
+
+// SetFormatter sets OutFormatter. Unlike setting OutFormatter directly it's
+// safe to call at any time, the change takes effect in between entries being
+// written.
+func SetFormatter(f Formatter) {
+	globalCore.apply(func() { OutFormatter = f })
+}
+
+// SetDisplayTimestamp sets DisplayTimestamp. Unlike setting DisplayTimestamp
+// directly it's safe to call at any time, the change takes effect in between
+// entries being written.
+func SetDisplayTimestamp(display bool) {
+	globalCore.apply(func() { DisplayTimestamp = display })
+}
 
 // BlockByDefault controls whether the non-Fatal functions wait for the write
 // to Out to complete. This can be useful to set to true for tests so that
@@ -47,7 +72,8 @@ var BlockByDefault = false
 
 // DisplayTimestamp determines whether or not a timestamp is displayed in the
 // log messages. By default one is not displayed. This can be changed by it
-// should only be changed before any logging occurs
+// should only be changed before any logging occurs, or else by using
+// SetDisplayTimestamp
 var DisplayTimestamp bool
 
 // Truncate is a helper function to truncate a string to a given size. It will
@@ -88,43 +114,94 @@ func (l Level) String() string {
 	return "unknown level"
 }
 
-var currLevel = InfoLevel
-var currLevelLock sync.RWMutex
+// currLevel is only accessed atomically, since it's checked on every log call.
+// It's only modified with currLevelLock held though, so that SetLevelFor can
+// coordinate with SetLevel.
+var currLevel = int32(InfoLevel)
+var currLevelLock sync.Mutex
+
+// levelTimer restores levelTimerPrev at the end of a SetLevelFor, it's nil
+// if there isn't one pending. Both are protected by currLevelLock.
+var levelTimer *time.Timer
+var levelTimerPrev Level
 
 // GetLevel returns the current log level
 func GetLevel() Level {
-	currLevelLock.RLock()
-	defer currLevelLock.RUnlock()
-	return currLevel
+	return Level(atomic.LoadInt32(&currLevel))
 }
 
 // SetLevel sets the current minimum log level which will be written to Out
 func SetLevel(l Level) {
 	currLevelLock.Lock()
 	defer currLevelLock.Unlock()
-	currLevel = l
+	stopLevelTimer()
+	atomic.StoreInt32(&currLevel, int32(l))
+}
+
+// SetLevelFor sets the current minimum log level for the given duration, after
+// which the level is restored to what it was beforehand. If SetLevelFor is
+// called again before the duration is up the new duration replaces the old one,
+// but the level which is eventually restored is still the original. If SetLevel
+// is called before the duration is up the previous level is never restored.
+//
+//	llog.SetLevelFor(llog.DebugLevel, 10*time.Minute)
+func SetLevelFor(l Level, d time.Duration) {
+	currLevelLock.Lock()
+	defer currLevelLock.Unlock()
+	prev := GetLevel()
+	if levelTimer != nil {
+		prev = levelTimerPrev
+		stopLevelTimer()
+	}
+	atomic.StoreInt32(&currLevel, int32(l))
+
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		currLevelLock.Lock()
+		defer currLevelLock.Unlock()
+		// the timer may have fired while being stopped
+		if levelTimer == t {
+			atomic.StoreInt32(&currLevel, int32(prev))
+			levelTimer = nil
+		}
+	})
+	levelTimer, levelTimerPrev = t, prev
+}
+
+// must be called with currLevelLock held
+func stopLevelTimer() {
+	if levelTimer != nil {
+		levelTimer.Stop()
+		levelTimer = nil
+	}
 }
 
 // SetLevelFromString attempts to interpret the given string as a log level and
 // sets the current log level to that. If the string can't be interpreted an
 // error is returned and the log level remains what it was
 func SetLevelFromString(ls string) error {
+	l, err := parseLevel(ls)
+	if err != nil {
+		return err
+	}
+	SetLevel(l)
+	return nil
+}
+
+func parseLevel(ls string) (Level, error) {
 	switch strings.ToUpper(ls) {
 	case "DEBUG":
-		SetLevel(DebugLevel)
+		return DebugLevel, nil
 	case "INFO":
-		SetLevel(InfoLevel)
+		return InfoLevel, nil
 	case "WARN":
-		SetLevel(WarnLevel)
+		return WarnLevel, nil
 	case "ERROR":
-		SetLevel(ErrorLevel)
+		return ErrorLevel, nil
 	case "FATAL":
-		SetLevel(FatalLevel)
-	default:
-		return fmt.Errorf("unknown log level %q", ls)
+		return FatalLevel, nil
 	}
-
-	return nil
+	return 0, fmt.Errorf("unknown log level %q", ls)
 }
 
 func logFuncFromLevel(l Level) LogFunc {
@@ -162,7 +239,11 @@ func (kv KV) Copy() KV {
 // the passed in ones. Key/vals on the rightmost of the set take precedence over
 // conflicting ones to the left. This function will never return nil
 func Merge(kvs ...KV) KV {
-	kv := make(KV, len(kvs))
+	var n int
+	for i := range kvs {
+		n += len(kvs[i])
+	}
+	kv := make(KV, n)
 	for i := range kvs {
 		for k, v := range kvs[i] {
 			kv[k] = v
@@ -179,18 +260,104 @@ func (kv KV) Set(k string, v interface{}) KV {
 	return nkv
 }
 
+// Flatten returns a copy of the KV being called on, where every value which is
+// itself a KV, a map with string keys, or a struct is replaced by its own
+// key/vals, with their keys prefixed by the key of the value and sep. This is
+// done recursively, so
+//
+//	KV{"http": KV{"req": KV{"method": "GET"}}}.Flatten(".")
+//
+// returns KV{"http.req.method": "GET"}. Structs which implement fmt.Stringer
+// or error, like time.Time, are left as-is. Struct fields are named by their
+// json tag, if any.
+func (kv KV) Flatten(sep string) KV {
+	nkv := make(KV, len(kv))
+	for k, v := range kv {
+		flattenInto(nkv, k, sep, v)
+	}
+	return nkv
+}
+
+// isNested returns whether Flatten would change the KV, i.e. whether any of its
+// values are nested
+func (kv KV) isNested() bool {
+	for _, v := range kv {
+		if isNested(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNested(v interface{}) bool {
+	switch v.(type) {
+	case Marshaler, KV:
+		return true
+	case nil, string, fmt.Stringer, error, bool, int, int64, float64:
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Struct ||
+		(rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String)
+}
+
+func flattenInto(dst KV, key, sep string, v interface{}) {
+	if m, ok := v.(Marshaler); ok {
+		v = marshalLog(m)
+	}
+	if !isNested(v) {
+		dst[key] = v
+		return
+	}
+	for k, vv := range nestedKV(v) {
+		flattenInto(dst, key+sep+k, sep, vv)
+	}
+}
+
+// nestedKV returns the keys and values nested within a value for which isNested
+// returns true, other than a Marshaler. Maps are keyed by their keys, and
+// structs by the names of their exported fields, or their json tag if they
+// have one.
+func nestedKV(v interface{}) KV {
+	if vkv, ok := v.(KV); ok {
+		return vkv
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		kv := make(KV, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			kv[iter.Key().String()] = iter.Value().Interface()
+		}
+		return kv
+	case reflect.Struct:
+		rt := rv.Type()
+		kv := make(KV, rt.NumField())
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			if f.PkgPath != "" { // unexported
+				continue
+			}
+			name := f.Name
+			if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			kv[name] = rv.Field(i).Interface()
+		}
+		return kv
+	}
+	return nil
+}
+
 // StringSlice converts the KV into a slice of [2]string entries (first index is
 // the key, second is the string form of the value).
 func (kv KV) StringSlice() [][2]string {
 	slice := make([][2]string, 0, len(kv))
 	for kstr, v := range kv {
-		vstr := fmt.Sprint(v)
-		// TODO this is only here because logstash is dumb and doesn't
-		// properly handle escaped quotes. Once
-		// https://github.com/elastic/logstash/issues/1645
-		// gets figured out this Replace can be removed
-		vstr = strings.Replace(vstr, `"`, `'`, -1)
-		slice = append(slice, [2]string{kstr, vstr})
+		slice = append(slice, [2]string{kstr, textValue(v)})
 	}
 	sort.Slice(slice, func(i, j int) bool {
 		return slice[i][0] < slice[j][0]
@@ -198,133 +365,576 @@ func (kv KV) StringSlice() [][2]string {
 	return slice
 }
 
+// textValue returns the string form of a value, as used by StringSlice
+func textValue(v interface{}) string {
+	vstr := fmt.Sprint(resolveMarshalers(v))
+	// TODO this is only here because logstash is dumb and doesn't
+	// properly handle escaped quotes. Once
+	// https://github.com/elastic/logstash/issues/1645
+	// gets figured out this Replace can be removed
+	return strings.Replace(vstr, `"`, `'`, -1)
+}
+
+// Entry describes a single log entry. Its Time is when the log function was
+// called, rather than when the entry was written, which may be later if entries
+// are backed up.
+type Entry struct {
+	Level Level
+	Time  time.Time
+	Msg   string
+	KV    KV
+
+	// bound is the KV bound to the Logger which wrote the entry, if any, and
+	// is used to avoid re-encoding its values for every entry
+	bound *encodedKV
+}
+
 type entry struct {
+	Entry
+	procs   []Processor   // can be nil
 	blockCh chan struct{} // can be nil
-	msg     string
-	kvSlice [][2]string
-	level   Level
-}
-
-var (
-	prefix         = []byte("~ ")
-	separator      = []byte(" --")
-	separatorSpace = append(separator, ' ')
-	tsPrefix       = []byte("[")
-	tsSuffix       = []byte("] ")
-	space          = []byte(" ")
-	equals         = []byte("=")
-	newline        = []byte("\n")
-)
 
-func (e entry) printOut(w io.Writer, displayTS bool) error {
-	var err error
-	write := func(b []byte) {
-		if err == nil {
-			_, err = w.Write(b)
-		}
+	// unlimited entries aren't subject to the rate limit, see SetRateLimit
+	unlimited bool
+}
+
+type syncer interface {
+	Sync()
+}
+
+type flusher interface {
+	Flush()
+}
+
+// core writes entries from its own go-routine, which is referred to as its main
+// loop. Every Logger created by New has its own core, while all other logging
+// goes through globalCore, which is configured using the package's variables
+// and functions.
+type core struct {
+	global bool
+
+	// These are only used by non-global cores, and are only accessed from the
+	// main loop
+	out       io.Writer
+	formatter Formatter
+	displayTS bool
+	hooks     []Hook
+
+	// level is only used by non-global cores
+	level Level
+
+	// onWriteError is used by all cores, but only accessed from the main loop
+	onWriteError func(error, Entry)
+
+	// clock is set for non-global cores by WithClock, otherwise the package's
+	// clock is used
+	clock func() time.Time
+
+	// deterministic is used by all cores, but only accessed from the main loop
+	deterministic bool
+
+	// batch is used by all cores, but only accessed from the main loop
+	batch entryBatch
+
+	// rate is used by all cores, but only accessed from the main loop. It's
+	// nil if there's no rate limit.
+	rate *rateLimiter
+
+	// reserved holds the keys reserved by the output, see
+	// refreshReservedKeys. It's nil until first needed.
+	reserved atomic.Pointer[[]string]
+
+	counters coreStats
+
+	// The main loop isn't started until the first entry is logged, so that
+	// bufSize and synchronous can be set beforehand, and if synchronous it's
+	// never started. Whenever there's no main loop anything which it would do
+	// is done directly instead, with lock held.
+	bufSize     int
+	synchronous bool
+	startOnce   sync.Once
+	lock        sync.Mutex
+	started     uint32 // atomic, only modified with lock held
+
+	// closed is set atomically once close has been called, after which
+	// entries are dropped. stoppedCh is closed once the main loop has
+	// stopped.
+	closed    uint32
+	closeOnce sync.Once
+	closeCh   chan chan struct{}
+	stoppedCh chan struct{}
+
+	shards   int
+	queues   []*entryQueue // nil until started
+	notifyCh chan struct{}
+	flushCh  chan chan bool
+	applyCh  chan func()
+}
+
+var globalCore = newCore(true)
+
+func newCore(global bool) *core {
+	return &core{
+		global:    global,
+		out:       os.Stdout,
+		formatter: TextFormatter{},
+		level:     InfoLevel,
+		flushCh:   make(chan chan bool),
+		applyCh:   make(chan func()),
+		closeCh:   make(chan chan struct{}),
+		stoppedCh: make(chan struct{}),
 	}
+}
 
-	write(prefix)
-	if displayTS {
-		write(tsPrefix)
-		write([]byte(time.Now().String()))
-		write(tsSuffix)
-	}
-	write([]byte(e.level.String()))
-	write(separatorSpace)
-	write([]byte(e.msg))
-	if len(e.kvSlice) > 0 {
-		write(separator)
-		for _, kve := range e.kvSlice {
-			write(space)
-			write([]byte(kve[0]))
-			write(equals)
-			write([]byte(strconv.QuoteToASCII(kve[1])))
+// start starts the main loop, if it hasn't been already and the core isn't
+// synchronous or closed
+func (c *core) start() {
+	c.startOnce.Do(func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if c.synchronous || atomic.LoadUint32(&c.closed) == 1 {
+			return
+		}
+		c.notifyCh = make(chan struct{}, 1)
+		c.queues = make([]*entryQueue, max(c.shards, 1))
+		for i := range c.queues {
+			// the extra slot is for the entry being written, whose slot
+			// isn't freed until it has been
+			c.queues[i] = newEntryQueue(c.bufSize+1, c.notifyCh)
 		}
+		// refreshed from now on whenever the output may have changed
+		c.refreshReservedKeys()
+		atomic.StoreUint32(&c.started, 1)
+		go c.run()
+	})
+}
+
+// direct calls fn and returns true if there's no main loop, either because it
+// hasn't been started or because it's been stopped, otherwise it returns false
+// without calling fn
+func (c *core) direct(fn func()) bool {
+	if atomic.LoadUint32(&c.started) == 1 {
+		return false
 	}
-	write(newline)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.started == 1 {
+		return false
+	}
+	fn()
+	return true
+}
 
-	return err
+// SetBufferSize sets the number of entries which can be waiting to be written
+// before logging blocks. By default it's zero, meaning every log call waits
+// for the previous entry to have been written. A buffer lets bursts of entries
+// be logged without waiting on each other, at the cost of entries possibly
+// being lost if the process exits without calling Flush.
+//
+// SetBufferSize has no effect once any logging has occurred, so it should be
+// called at the very start of main.
+func SetBufferSize(n int) {
+	globalCore.direct(func() { globalCore.bufSize = n })
 }
 
-type syncer interface {
-	Sync()
+// SetShards sets the number of shards which entries are queued in before being
+// written. By default there's one, which every log call contends on. With more,
+// each log call pushes to a shard picked at random, so concurrent log calls
+// usually push to different shards, which reduces contention in services
+// logging at very high rates. The cost is that entries logged at almost the
+// same time from different go-routines may be written slightly out of order,
+// and that each shard has its own buffer of the size set by SetBufferSize. A
+// shard per CPU, i.e. runtime.GOMAXPROCS(0), is generally a good choice.
+//
+// SetShards has no effect once any logging has occurred, so it should be
+// called at the very start of main.
+func SetShards(n int) {
+	globalCore.direct(func() { globalCore.shards = n })
 }
 
-type flusher interface {
-	Flush()
+// SetSynchronous sets whether entries are written synchronously, from within
+// the log call, rather than from a separate go-routine. Log calls from multiple
+// go-routines still write one entry at a time, and Processors and Hooks are
+// still never called concurrently. This is useful for tests and short-lived
+// programs, where all output should have been written before the log call
+// returns, but means that the log calls take as long as the writes do.
+//
+// SetSynchronous has no effect once any logging has occurred, so it should be
+// called at the very start of main, or in TestMain.
+func SetSynchronous(on bool) {
+	globalCore.direct(func() { globalCore.synchronous = on })
 }
 
-var entryCh = make(chan entry)
-var flushCh = make(chan chan bool)
+// QueueDepth returns the number of entries which are currently waiting to be
+// written, and the size of the buffer they are waiting in (see SetBufferSize
+// and SetShards). If the number is consistently close to the size then entries
+// are being logged faster than they can be written.
+func QueueDepth() (n, size int) {
+	return globalCore.queueDepth()
+}
 
-func init() {
+func (c *core) queueDepth() (int, int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.started == 0 {
+		return 0, c.bufSize * max(c.shards, 1)
+	}
+	var n int
+	for _, q := range c.queues {
+		// the entry being written may not have been taken yet
+		n += min(q.len(), c.bufSize)
+	}
+	return n, c.bufSize * len(c.queues)
+}
+
+// run is the main loop
+func (c *core) run() {
+	for {
+		select {
+		case doneCh := <-c.flushCh:
+			c.drain()
+			c.flush()
+			close(doneCh)
+		case fn := <-c.applyCh:
+			c.drain()
+			fn()
+		case <-c.notifyCh:
+			c.drain()
+		case doneCh := <-c.closeCh:
+			c.drain()
+			c.flush()
+			c.lock.Lock()
+			atomic.StoreUint32(&c.started, 0)
+			// anything pushed before started was unset is written now, and
+			// anything after by the log call itself, see logEntry
+			c.drain()
+			c.lock.Unlock()
+			close(c.stoppedCh)
+			close(doneCh)
+			return
+		}
+	}
+}
+
+// close flushes, and then stops the main loop. Entries logged after close is
+// called are dropped.
+func (c *core) close() {
+	c.closeOnce.Do(func() {
+		atomic.StoreUint32(&c.closed, 1)
+		if c.direct(c.flush) {
+			return
+		}
+		doneCh := make(chan struct{})
+		c.closeCh <- doneCh
+		<-doneCh
+	})
+}
+
+// Close flushes all entries which have been logged, as with Flush, and then
+// stops the go-routine which writes entries. Any Sinks opened by ApplyConfig
+// are closed, but Out and any other Sinks aren't. Entries logged after Close is
+// called are dropped, so it should only be called right before the process
+// exits, e.g.
+//
+//	func main() {
+//		defer llog.Close()
+//		...
+//	}
+func Close() {
+	globalCore.close()
+	globalCore.apply(func() {
+		closeSinks(configSinks)
+		configSinks = nil
+		closeSinks(configAuditSinks)
+		configAuditSinks = nil
+	})
+}
+
+// Drain is like Close, but gives up waiting once the Context is done, returning
+// its error. Even then no more entries are accepted, and the remaining ones
+// continue being written in the background. It's intended for use alongside
+// other graceful shutdown, e.g.
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	srv.Shutdown(ctx)
+//	llog.Drain(ctx)
+func Drain(ctx context.Context) error {
+	return drain(ctx, Close)
+}
+
+func drain(ctx context.Context, closeFn func()) error {
+	doneCh := make(chan struct{})
 	go func() {
-		for {
-			select {
-			case doneCh := <-flushCh:
-				flush()
-				close(doneCh)
-			case e := <-entryCh:
-				err := e.printOut(Out, DisplayTimestamp)
-
-				// If we couldn't write the entry to Out we write an error to that
-				// effect to Stdout, then try to write the original entry as well
-				if err != nil && Out != defaultOut {
-					erre := entry{
-						level:   ErrorLevel,
-						msg:     "Could not write to error Out",
-						kvSlice: ErrKV(err).StringSlice(),
-					}
-					erre.printOut(defaultOut, DisplayTimestamp)
-					e.printOut(defaultOut, DisplayTimestamp)
-				}
+		defer close(doneCh)
+		closeFn()
+	}()
+	select {
+	case <-doneCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-				// If the error level is fatal this is the last entry we should ever
-				// write. We do want to attempt to flush Out though, in case it's
-				// buffered, otherwise exiting now will cause the fatal message to
-				// never be shown.
-				if e.level == FatalLevel {
-					flush()
-				}
+// drain writes all entries which are currently queued, so that anything
+// logged before a flush or apply is handled before it. When there are multiple
+// shards the earliest entry at the head of any of them is written next, so
+// entries are only out of order if they were logged at almost the same time.
+// Shouldn't be called outside the main loop, or once it has stopped without the
+// lock held.
+func (c *core) drain() {
+	var n int
+	for _, q := range c.queues {
+		n += q.len()
+	}
+	if c.batch.enabled && n > 1 {
+		c.batch.active = true
+		defer func() {
+			c.batch.active = false
+			c.writeBatch()
+		}()
+	}
+	for ; n > 0; n-- {
+		var next *entryQueue
+		var nextTime time.Time
+		for _, q := range c.queues {
+			if t, ok := q.peekTime(); ok && (next == nil || t.Before(nextTime)) {
+				next, nextTime = q, t
+			}
+		}
+		if next == nil {
+			// still being pushed, their log calls will notify
+			return
+		}
+		e, pos, _ := next.take()
+		c.writeEntry(e)
+		next.release(pos)
+	}
+}
 
-				if e.blockCh != nil {
-					close(e.blockCh)
+// output returns where and how entries should be written. Shouldn't be called
+// outside the main loop
+func (c *core) output() (io.Writer, Formatter, bool) {
+	out, f, ts := c.out, c.formatter, c.displayTS
+	if c.global {
+		out, f, ts = Out, OutFormatter, DisplayTimestamp
+	}
+	if c.deterministic {
+		f, ts = deterministicFormatter(f), false
+	}
+	return out, f, ts
+}
+
+// writes an entry to Out. Shouldn't be called outside the main loop
+func (c *core) writeEntry(e entry) {
+	if c.deterministic {
+		e.Time = DeterministicTime
+	}
+	hs, global := c.hooks, []Processor(nil)
+	if c.global {
+		hs, global = getHooks(), getProcessors()
+	}
+	resolveLazy(e.KV)
+	applyNilPolicy(e.KV)
+	var ok bool
+	if e.Entry, ok = processEntry(e.Entry, e.procs, global); ok && !c.rateLimited(e) {
+		c.countWritten(e.Level)
+		rt, routed := splitRoute(e.KV)
+		e.Entry = interpolateEntry(limitEntry(scrubEntry(redactEntry(e.Entry))))
+		c.fireHooks(e.Entry, hs)
+		var rss []Sink
+		if routed && c.global {
+			rss = routeSinks(rt.names)
+		}
+		if !rt.only || len(rss) == 0 {
+			if out, f, ts := c.output(); out != nil {
+				if err := c.format(out, f, e.Entry, ts); err != nil {
+					c.writeError(&WriteError{Err: err}, e.Entry)
 				}
 			}
+			if c.global {
+				c.writeSinks(e.Entry, getSinks())
+			}
 		}
-	}()
+		c.writeSinks(e.Entry, rss)
+	}
+
+	// If the error level is fatal this is the last entry we should ever
+	// write. We do want to attempt to flush Out though, in case it's
+	// buffered, otherwise exiting now will cause the fatal message to
+	// never be shown.
+	if e.Level == FatalLevel {
+		c.flush()
+	}
+
+	if e.blockCh != nil {
+		c.unblock(e.blockCh)
+	}
+}
+
+// does a raw flush on Out and all Sinks. Shouldn't be called outside the main
+// loop
+func (c *core) flush() {
+	c.writeBatch()
+	out, _, _ := c.output()
+	flushWriter(out)
+	if c.global {
+		for _, s := range getSinks() {
+			flushWriter(s)
+		}
+		for _, s := range allRouteSinks() {
+			flushWriter(s)
+		}
+	}
 }
 
-// does a raw flush on Out. Shouldn't be called outside the main loop
-func flush() {
+func flushWriter(w interface{}) {
 	// We try to cast to either an interface with a Sync or a Flush command as a
 	// form of ghetto reflection, to see if the writer has either, and use one
 	// if found.
-	if so, ok := Out.(syncer); ok {
+	if so, ok := w.(syncer); ok {
 		so.Sync()
-	} else if fo, ok := Out.(flusher); ok {
+	} else if fo, ok := w.(flusher); ok {
 		fo.Flush()
 	}
 }
 
-func logEntry(l Level, msg string, kvs []KV, block bool) {
-	if l < GetLevel() {
+// apply calls the given function from the main loop, in between entries being
+// written, and waits for it to return. Shouldn't be called from within the main
+// loop (e.g. from a Hook)
+func (c *core) apply(fn func()) {
+	// fn may have changed the output
+	applied := func() {
+		fn()
+		c.refreshReservedKeys()
+	}
+	if c.direct(applied) {
 		return
 	}
+	doneCh := make(chan struct{})
+	select {
+	case c.applyCh <- func() {
+		defer close(doneCh)
+		applied()
+	}:
+		<-doneCh
+	case <-c.stoppedCh:
+		c.direct(applied)
+	}
+}
+
+// enabled returns whether an entry of the given level should be written. It
+// must be called from within the log function (or something beneath it), see
+// the package-level enabled.
+func (c *core) enabled(l Level) bool {
+	if c.global {
+		return enabled(l)
+	}
+	return l >= c.level
+}
+
+// logEntry writes an entry with the Merge of bound, kvs, and fields as its KV,
+// bound being the KV bound to a Logger
+func (c *core) logEntry(l Level, msg string, bound *encodedKV, kvs []KV, fields []Field, procs []Processor, block bool) {
+	if !c.enabled(l) {
+		return
+	} else if atomic.LoadUint32(&c.closed) == 1 {
+		c.counters.dropped.Add(1)
+		return
+	}
+	var base, global KV
+	if g := globalKV.Load(); g != nil {
+		if bound == nil {
+			// the global KV takes the place of the bound KV, so that its
+			// encoding is cached
+			bound = g
+		} else {
+			global = g.kv
+		}
+	}
+	if bound != nil {
+		base = bound.kv
+	}
+	n := len(global) + len(base) + len(fields)
+	for i := range kvs {
+		n += len(kvs[i])
+	}
+	kv := make(KV, n)
+	for k, v := range global {
+		kv[k] = v
+	}
+	for k, v := range base {
+		kv[k] = v
+	}
+	for i := range kvs {
+		for k, v := range kvs[i] {
+			kv[k] = v
+		}
+	}
+	for i := range fields {
+		fields[i].setIn(kv)
+	}
+	reserved := c.reservedKeys()
+	problems := validateEntry(msg, kv, reserved)
+	if problems == nil {
+		problems = applyReservedKeyPolicy(kv, reserved)
+	}
+	if problems != nil {
+		l, msg, kv = ErrorLevel, "Invalid log entry", invalidEntryKV(msg, problems)
+	}
 	var blockCh chan struct{}
 	if block {
 		blockCh = make(chan struct{})
 		defer func() {
-			<-blockCh
+			// if the main loop stopped first the entry may have been dropped
+			select {
+			case <-blockCh:
+			case <-c.stoppedCh:
+			}
 		}()
 	}
-	entryCh <- entry{
-		level:   l,
-		msg:     msg,
-		kvSlice: Merge(kvs...).StringSlice(),
+	c.start()
+	if caller, ok := captureCaller(l); ok {
+		kv["caller"] = caller
+	}
+	if stack := captureStack(l); stack != nil {
+		kv["stack"] = stack
+	}
+	e := entry{
+		Entry: Entry{
+			Level: l,
+			Time:  c.now(),
+			Msg:   msg,
+			KV:    kv,
+			bound: bound,
+		},
+		procs:   procs,
 		blockCh: blockCh,
 	}
+	if c.direct(func() { c.writeEntry(e) }) {
+		return
+	}
+	c.queues[shard(len(c.queues))].push(e)
+	if atomic.LoadUint32(&c.started) == 0 {
+		// the main loop stopped while the entry was being pushed, and may
+		// not have written it
+		c.direct(c.drain)
+	}
+}
+
+// waitFlush flushes from the main loop, and waits for it to complete
+func (c *core) waitFlush() {
+	if c.direct(c.flush) {
+		return
+	}
+	doneCh := make(chan bool)
+	select {
+	case c.flushCh <- doneCh:
+		<-doneCh
+	case <-c.stoppedCh:
+		c.direct(c.flush)
+	}
 }
 
 // LogFunc is the function signature used by the different log functions (Debug,
@@ -334,39 +944,38 @@ type LogFunc func(string, ...KV)
 // Debug writes a Debug message to Out, with an optional set of key/value pairs
 // which will be Merge'd together.
 func Debug(msg string, kv ...KV) {
-	logEntry(DebugLevel, msg, kv, BlockByDefault)
+	globalCore.logEntry(DebugLevel, msg, nil, kv, nil, nil, BlockByDefault)
 }
 
 // Info writes an Info message to Out, with an optional set of key/value pairs
 // which will be Merge'd together.
 func Info(msg string, kv ...KV) {
-	logEntry(InfoLevel, msg, kv, BlockByDefault)
+	globalCore.logEntry(InfoLevel, msg, nil, kv, nil, nil, BlockByDefault)
 }
 
 // Warn writes a Warn message to Out, with an optional set of key/value pairs
 // which will be Merge'd together.
 func Warn(msg string, kv ...KV) {
-	logEntry(WarnLevel, msg, kv, BlockByDefault)
+	globalCore.logEntry(WarnLevel, msg, nil, kv, nil, nil, BlockByDefault)
 }
 
 // Error writes an Error message to Out, with an optional set of key/value pairs
 // which will be Merge'd together.
 func Error(msg string, kv ...KV) {
-	logEntry(ErrorLevel, msg, kv, BlockByDefault)
+	globalCore.logEntry(ErrorLevel, msg, nil, kv, nil, nil, BlockByDefault)
 }
 
 // Fatal writes a Fatal message to Out, with an optional set of key/value pairs
-// which will be Merge'd together. Once written the process will be exited with
-// an exit code of 1
+// which will be Merge'd together. Once written the exit hooks are run and the
+// process is exited with the exit code (1 by default), unless the FatalMode has
+// been changed using SetFatalMode.
 func Fatal(msg string, kv ...KV) {
-	logEntry(FatalLevel, msg, kv, true)
-	os.Exit(1)
+	globalCore.logEntry(FatalLevel, msg, nil, kv, nil, nil, true)
+	fatal(msg, kv)
 }
 
 // Flush will attempts to flush any buffered data in Out. Will block until the
 // flushing has been completed
 func Flush() {
-	doneCh := make(chan bool)
-	flushCh <- doneCh
-	<-doneCh
+	globalCore.waitFlush()
 }
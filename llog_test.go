@@ -43,6 +43,36 @@ func TestKV(t *T) {
 	assert.Equal(t, KV{"foo": "a", "bar": "b"}, kv)
 }
 
+func TestKVFlatten(t *T) {
+	type inner struct {
+		A   string
+		B   int `json:"b"`
+		C   int `json:"-"`
+		d   int
+		Now time.Time
+	}
+	now := time.Now()
+	kv := KV{
+		"a": "a",
+		"b": KV{"c": KV{"d": 1}, "e": "e"},
+		"f": map[string]int{"g": 2},
+		"h": inner{A: "A", B: 3, C: 4, d: 5, Now: now},
+		"i": []int{1, 2},
+		"j": nil,
+	}
+	assert.Equal(t, KV{
+		"a":     "a",
+		"b.c.d": 1,
+		"b.e":   "e",
+		"f.g":   2,
+		"h.A":   "A",
+		"h.b":   3,
+		"h.Now": now,
+		"i":     []int{1, 2},
+		"j":     nil,
+	}, kv.Flatten("."))
+}
+
 func TestLLog(t *T) {
 	// Unfortunately due to the nature of the package all testing involving Out
 	// must be syncronous
@@ -75,15 +105,15 @@ func TestLLog(t *T) {
 	assertOut("~ ERROR -- buz -- a=\"b\"\n")
 }
 
-func TestEntryPrintOut(t *T) {
-	assertEntry := func(postfix string, e entry) {
+func TestTextFormatter(t *T) {
+	assertEntry := func(postfix string, e Entry) {
 		expectedRegex := regexp.MustCompile(`^~ ` + postfix + `\n$`)
 		expectedRegexTS := regexp.MustCompile(`^~ \[[^\]]+\] ` + postfix + `\n$`)
 
 		buf := bytes.NewBuffer(make([]byte, 0, 128))
 
-		require.Nil(t, e.printOut(buf, false))
-		require.Nil(t, e.printOut(buf, true))
+		require.Nil(t, TextFormatter{}.Format(buf, e, false))
+		require.Nil(t, TextFormatter{}.Format(buf, e, true))
 
 		noTS, err := buf.ReadString('\n')
 		require.Nil(t, err)
@@ -94,26 +124,27 @@ func TestEntryPrintOut(t *T) {
 		assert.True(t, expectedRegexTS.MatchString(withTS), "regex: %q line: %q", expectedRegexTS.String(), withTS)
 	}
 
-	e := entry{
-		level: InfoLevel,
-		msg:   "this is a test",
+	e := Entry{
+		Level: InfoLevel,
+		Time:  time.Now(),
+		Msg:   "this is a test",
 	}
 	assertEntry("INFO -- this is a test", e)
 
-	e.kvSlice = KV{}.StringSlice()
+	e.KV = KV{}
 	assertEntry("INFO -- this is a test", e)
 
-	e.kvSlice = KV{"foo": "a"}.StringSlice()
+	e.KV = KV{"foo": "a"}
 	assertEntry("INFO -- this is a test -- foo=\"a\"", e)
 
-	e.kvSlice = KV{"foo": "a", "bar": "b"}.StringSlice()
+	e.KV = KV{"foo": "a", "bar": "b"}
 	assertEntry("INFO -- this is a test -- bar=\"b\" foo=\"a\"", e)
 
-	e.kvSlice = Merge(
+	e.KV = Merge(
 		KV{"foo": "aaaaa"},
 		KV{"foo": "a"},
 		KV{"bar": "b"},
-	).StringSlice()
+	)
 	assertEntry("INFO -- this is a test -- bar=\"b\" foo=\"a\"", e)
 }
 
@@ -138,7 +169,7 @@ func TestBlocking(t *T) {
 	SetLevelFromString("INFO")
 	var done int64
 	go func() {
-		logEntry(InfoLevel, "test", nil, true)
+		globalCore.logEntry(InfoLevel, "test", nil, nil, nil, nil, true)
 		atomic.AddInt64(&done, 1)
 	}()
 
@@ -158,3 +189,85 @@ func BenchmarkLLog(b *B) {
 		Info("This is a generic message", KV{"foo": "bar"})
 	}
 }
+
+func BenchmarkLLogParallel(b *B) {
+	l := New(WithOutput(ioutil.Discard), WithBufferSize(1024))
+	defer l.Close()
+	b.RunParallel(func(pb *PB) {
+		for pb.Next() {
+			l.Info("This is a generic message", KV{"foo": "bar"})
+		}
+	})
+}
+
+func TestSetLevelFor(t *T) {
+	defer SetLevel(InfoLevel)
+	SetLevel(WarnLevel)
+
+	SetLevelFor(DebugLevel, 20*time.Millisecond)
+	assert.Equal(t, DebugLevel, GetLevel())
+	assert.Eventually(t, func() bool { return GetLevel() == WarnLevel }, time.Second, time.Millisecond)
+
+	// extending the window should still restore the original level
+	SetLevelFor(InfoLevel, time.Hour)
+	SetLevelFor(DebugLevel, 20*time.Millisecond)
+	assert.Equal(t, DebugLevel, GetLevel())
+	assert.Eventually(t, func() bool { return GetLevel() == WarnLevel }, time.Second, time.Millisecond)
+
+	// SetLevel should end the window without restoring
+	SetLevelFor(DebugLevel, 20*time.Millisecond)
+	SetLevel(ErrorLevel)
+	time.Sleep(40 * time.Millisecond)
+	assert.Equal(t, ErrorLevel, GetLevel())
+}
+
+func TestSetOutput(t *T) {
+	oldOut, oldFormatter, oldTS := Out, OutFormatter, DisplayTimestamp
+	defer func() {
+		SetOutput(oldOut)
+		SetFormatter(oldFormatter)
+		SetDisplayTimestamp(oldTS)
+	}()
+
+	// logging concurrently with the changes shouldn't race
+	buf1, buf2 := new(bytes.Buffer), new(bytes.Buffer)
+	SetOutput(buf1)
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for i := 0; i < 100; i++ {
+			Info("foo")
+		}
+	}()
+	SetFormatter(JSONFormatter{})
+	SetDisplayTimestamp(true)
+	SetOutput(buf2)
+	<-doneCh
+	Flush()
+	assert.Equal(t, 100, bytes.Count(buf1.Bytes(), []byte("\n"))+bytes.Count(buf2.Bytes(), []byte("\n")))
+
+	buf2.Reset()
+	SetDisplayTimestamp(false)
+	Info("bar")
+	Flush()
+	assert.Equal(t, `{"level":"INFO","msg":"bar"}`+"\n", buf2.String())
+}
+
+func TestAllocs(t *T) {
+	// entries which aren't written shouldn't cost anything
+	kv := KV{"a": 1, "b": "two"}
+	l := With(KV{"c": 3})
+	assert.Zero(t, AllocsPerRun(100, func() { Debug("foo", kv) }))
+	assert.Zero(t, AllocsPerRun(100, func() { l.Debug("foo", kv) }))
+}
+
+func TestSetBufferSize(t *T) {
+	// the main loop has already been started by other tests, so it's too late
+	// to set the buffer size
+	Flush()
+	_, size := QueueDepth()
+	SetBufferSize(size + 10)
+	n, newSize := QueueDepth()
+	assert.Equal(t, 0, n)
+	assert.Equal(t, size, newSize)
+}
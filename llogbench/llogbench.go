@@ -0,0 +1,178 @@
+// Package llogbench benchmarks llog's formatters, write paths, and sinks with a
+// fixed, representative workload, so that the cost of changes to the encoders
+// can be compared across commits, and users can compare configurations before
+// choosing one. Its own benchmarks cover the configurations in Formatters,
+// WritePaths, and Sinks:
+//
+//	go test -run - -bench . -benchmem github.com/levenlabs/go-llog/llogbench
+//
+// In addition to the usual ns/op and allocs/op each benchmark reports
+// bytes/entry, the size of the output of each entry, and entries/s. Other
+// configurations can be benchmarked by calling Run, RunParallel, or RunSink
+// from a benchmark of your own:
+//
+//	func BenchmarkMyConfig(b *testing.B) {
+//		llogbench.Run(b, llog.WithFormatter(myFormatter{}))
+//	}
+package llogbench
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/levenlabs/go-llog"
+)
+
+// Msg is the message of every benchmarked entry
+const Msg = "Handled http request"
+
+// BoundKV is bound to the Logger every entry is written by, the way a
+// request-scoped Logger usually is
+var BoundKV = llog.KV{
+	"method":     "GET",
+	"path":       "/api/v1/users",
+	"remoteAddr": "10.0.0.1:54321",
+}
+
+// KV is the KV logged with every benchmarked entry. It mixes the types which
+// are commonly logged, including a string which needs escaping.
+var KV = llog.KV{
+	"status":    200,
+	"bytes":     int64(1534),
+	"duration":  1500 * time.Microsecond,
+	"cached":    true,
+	"ratio":     0.75,
+	"userAgent": `Mozilla/5.0 "quoted"`,
+	"err":       errors.New("connection reset by peer"),
+}
+
+// Config is a named configuration of a Logger to benchmark
+type Config struct {
+	Name    string
+	Options []llog.Option
+}
+
+// Formatters are synchronous configurations which differ only in their
+// Formatter, so that the cost of formatting is all that's measured
+var Formatters = []Config{
+	{"text", []llog.Option{llog.WithSynchronous(true)}},
+	{"textUTF8", []llog.Option{llog.WithSynchronous(true), llog.WithFormatter(llog.TextFormatter{UTF8: true})}},
+	{"textColor", []llog.Option{llog.WithSynchronous(true), llog.WithFormatter(llog.TextFormatter{Colors: llog.DefaultColors})}},
+	{"json", []llog.Option{llog.WithSynchronous(true), llog.WithFormatter(llog.JSONFormatter{})}},
+	{"kubernetes", []llog.Option{llog.WithSynchronous(true), llog.WithFormatter(llog.KubernetesFormatter())}},
+	{"docker", []llog.Option{llog.WithSynchronous(true), llog.WithFormatter(llog.DockerFormatter{Formatter: llog.JSONFormatter{}})}},
+	{"systemd", []llog.Option{llog.WithSynchronous(true), llog.WithFormatter(llog.SystemdFormatter{})}},
+}
+
+// WritePaths are configurations which differ in how entries get from the log
+// call to the output, all using the default TextFormatter
+var WritePaths = []Config{
+	{"synchronous", []llog.Option{llog.WithSynchronous(true)}},
+	{"unbuffered", nil},
+	{"buffered", []llog.Option{llog.WithBufferSize(1024)}},
+	{"sharded", []llog.Option{llog.WithBufferSize(1024), llog.WithShards(4)}},
+	{"batched", []llog.Option{llog.WithBufferSize(1024), llog.WithBatchWrites(true)}},
+}
+
+// SinkConfig is a named configuration of a Sink to benchmark
+type SinkConfig struct {
+	Name string
+
+	// New returns the Sink, which must write to w
+	New func(w io.Writer) llog.Sink
+}
+
+// Sinks are the configurations of the Sinks in llog which don't require a
+// network
+var Sinks = []SinkConfig{
+	{"writerText", func(w io.Writer) llog.Sink {
+		return llog.WriterSink{Writer: w}
+	}},
+	{"writerJSON", func(w io.Writer) llog.Sink {
+		return llog.WriterSink{Writer: w, Formatter: llog.JSONFormatter{}}
+	}},
+	{"retry", func(w io.Writer) llog.Sink {
+		return llog.RetrySink{Sink: llog.WriterSink{Writer: w}, Retries: 3}
+	}},
+}
+
+// countingWriter discards everything written to it, counting the bytes
+type countingWriter struct {
+	n atomic.Int64
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	cw.n.Add(int64(len(b)))
+	return len(b), nil
+}
+
+func report(b *testing.B, cw *countingWriter) {
+	b.StopTimer()
+	if b.N == 0 {
+		return
+	}
+	b.ReportMetric(float64(cw.n.Load())/float64(b.N), "bytes/entry")
+	if s := b.Elapsed().Seconds(); s > 0 {
+		b.ReportMetric(float64(b.N)/s, "entries/s")
+	}
+}
+
+// newLogger returns a Logger created with the given Options, which writes to cw
+// and has BoundKV bound to it
+func newLogger(cw *countingWriter, opts []llog.Option) *llog.Logger {
+	opts = append([]llog.Option{llog.WithOutput(cw)}, opts...)
+	return llog.New(opts...).With(BoundKV)
+}
+
+// Run benchmarks writing b.N entries, one after the other, with a Logger
+// created with the given Options. Its output is discarded, so the Options
+// shouldn't include WithOutput.
+func Run(b *testing.B, opts ...llog.Option) {
+	cw := new(countingWriter)
+	l := newLogger(cw, opts)
+	defer l.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info(Msg, KV)
+	}
+	l.Flush()
+	report(b, cw)
+}
+
+// RunParallel is like Run, but writes the entries from GOMAXPROCS go-routines
+// at once
+func RunParallel(b *testing.B, opts ...llog.Option) {
+	cw := new(countingWriter)
+	l := newLogger(cw, opts)
+	defer l.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info(Msg, KV)
+		}
+	})
+	l.Flush()
+	report(b, cw)
+}
+
+// RunSink benchmarks writing b.N entries directly to the Sink returned by the
+// SinkConfig, bypassing the rest of llog
+func RunSink(b *testing.B, sc SinkConfig) {
+	cw := new(countingWriter)
+	s := sc.New(cw)
+	kv := llog.Merge(BoundKV, KV)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e := llog.Entry{Level: llog.InfoLevel, Time: time.Now(), Msg: Msg, KV: kv}
+		if err := s.WriteEntry(e); err != nil {
+			b.Fatal(err)
+		}
+	}
+	report(b, cw)
+}
@@ -0,0 +1,39 @@
+package llogbench
+
+import (
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *T) {
+	res := Benchmark(func(b *B) { Run(b, Formatters[0].Options...) })
+	assert.NotZero(t, res.N)
+	assert.Greater(t, res.Extra["bytes/entry"], float64(len(Msg)))
+	assert.Greater(t, res.Extra["entries/s"], float64(0))
+	assert.Greater(t, res.AllocsPerOp(), int64(0))
+}
+
+func BenchmarkFormatters(b *B) {
+	for _, c := range Formatters {
+		b.Run(c.Name, func(b *B) { Run(b, c.Options...) })
+	}
+}
+
+func BenchmarkWritePaths(b *B) {
+	for _, c := range WritePaths {
+		b.Run(c.Name, func(b *B) { Run(b, c.Options...) })
+	}
+}
+
+func BenchmarkWritePathsParallel(b *B) {
+	for _, c := range WritePaths {
+		b.Run(c.Name, func(b *B) { RunParallel(b, c.Options...) })
+	}
+}
+
+func BenchmarkSinks(b *B) {
+	for _, sc := range Sinks {
+		b.Run(sc.Name, func(b *B) { RunSink(b, sc) })
+	}
+}
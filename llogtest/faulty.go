@@ -0,0 +1,114 @@
+package llogtest
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrInjected is the error returned by a FaultyWriter for a Fault which fails
+// without specifying its own error
+var ErrInjected = errors.New("llogtest: injected fault")
+
+// Fault describes how a FaultyWriter misbehaves for a write
+type Fault struct {
+	// Delay is how long the write blocks before doing anything else
+	Delay time.Duration
+
+	// Fail makes the write fail without writing anything, returning Err (or
+	// ErrInjected if Err is nil)
+	Fail bool
+	Err  error
+
+	// Short, if greater than zero, makes the write only write that many bytes
+	// and return io.ErrShortWrite (or Err if it's set)
+	Short int
+}
+
+// FaultyWriter is an io.Writer which passes writes through to another
+// io.Writer, except for those which have been programmed to fail, block, or
+// short-write using Inject. It's useful for testing how code behaves when
+// logs can't be written, e.g. with llog.OnWriteError or llog.RetrySink. It's
+// safe to use from multiple go-routines.
+type FaultyWriter struct {
+	w io.Writer
+
+	l      sync.Mutex
+	n      int
+	faults map[int]Fault
+	all    *Fault
+}
+
+// NewFaultyWriter returns a FaultyWriter which writes to the given io.Writer,
+// or discards writes if it's nil
+func NewFaultyWriter(w io.Writer) *FaultyWriter {
+	if w == nil {
+		w = io.Discard
+	}
+	return &FaultyWriter{w: w, faults: map[int]Fault{}}
+}
+
+// Inject makes the nth write (counting from 1) have the given Fault. If n is
+// zero or less the Fault applies to every write which doesn't have a Fault of
+// its own.
+func (fw *FaultyWriter) Inject(n int, f Fault) {
+	fw.l.Lock()
+	defer fw.l.Unlock()
+	if n <= 0 {
+		fw.all = &f
+		return
+	}
+	fw.faults[n] = f
+}
+
+// Reset removes all Faults, and resets the count of writes
+func (fw *FaultyWriter) Reset() {
+	fw.l.Lock()
+	defer fw.l.Unlock()
+	fw.n, fw.faults, fw.all = 0, map[int]Fault{}, nil
+}
+
+// Writes returns the number of writes which have been made, whether or not
+// they failed
+func (fw *FaultyWriter) Writes() int {
+	fw.l.Lock()
+	defer fw.l.Unlock()
+	return fw.n
+}
+
+// Write implements the io.Writer interface
+func (fw *FaultyWriter) Write(b []byte) (int, error) {
+	fw.l.Lock()
+	fw.n++
+	f, ok := fw.faults[fw.n]
+	if !ok && fw.all != nil {
+		f, ok = *fw.all, true
+	}
+	fw.l.Unlock()
+	if !ok {
+		return fw.w.Write(b)
+	}
+
+	if f.Delay > 0 {
+		time.Sleep(f.Delay)
+	}
+	err := f.Err
+	switch {
+	case f.Fail:
+		if err == nil {
+			err = ErrInjected
+		}
+		return 0, err
+	case f.Short > 0 && f.Short < len(b):
+		if err == nil {
+			err = io.ErrShortWrite
+		}
+		n, werr := fw.w.Write(b[:f.Short])
+		if werr != nil {
+			return n, werr
+		}
+		return n, err
+	}
+	return fw.w.Write(b)
+}
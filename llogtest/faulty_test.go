@@ -0,0 +1,69 @@
+package llogtest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	. "testing"
+	"time"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultyWriter(t *T) {
+	buf := new(bytes.Buffer)
+	fw := NewFaultyWriter(buf)
+	myErr := errors.New("my error")
+	fw.Inject(2, Fault{Fail: true})
+	fw.Inject(3, Fault{Fail: true, Err: myErr})
+	fw.Inject(4, Fault{Short: 2})
+	fw.Inject(5, Fault{Delay: 10 * time.Millisecond})
+
+	write := func(s string) (int, error) { return fw.Write([]byte(s)) }
+	n, err := write("a")
+	assert.Equal(t, 1, n)
+	assert.NoError(t, err)
+
+	_, err = write("b")
+	assert.Equal(t, ErrInjected, err)
+	_, err = write("c")
+	assert.Equal(t, myErr, err)
+
+	n, err = write("def")
+	assert.Equal(t, 2, n)
+	assert.Equal(t, io.ErrShortWrite, err)
+
+	start := time.Now()
+	n, err = write("g")
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+	assert.Equal(t, 1, n)
+	assert.NoError(t, err)
+
+	fw.Inject(0, Fault{Fail: true})
+	_, err = write("h")
+	assert.Error(t, err)
+
+	assert.Equal(t, 6, fw.Writes())
+	assert.Equal(t, "adeg", buf.String())
+
+	fw.Reset()
+	_, err = write("i")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fw.Writes())
+}
+
+func TestFaultyWriterFallback(t *T) {
+	fw := NewFaultyWriter(nil)
+	fw.Inject(2, Fault{Fail: true})
+	var failed []string
+	l := llog.New(
+		llog.WithOutput(fw),
+		llog.WithWriteErrorHandler(func(err error, e llog.Entry) { failed = append(failed, e.Msg) }),
+	)
+	l.Info("foo")
+	l.Info("bar")
+	l.Info("baz")
+	l.Flush()
+	assert.Equal(t, []string{"bar"}, failed)
+}
@@ -0,0 +1,150 @@
+// Package llogtest provides helpers for testing code which logs using llog.
+//
+// A Recorder captures entries in memory so that tests can make assertions
+// about them, rather than parsing Out:
+//
+//	r := llogtest.NewRecorder()
+//	llog.AddSink(r)
+//	defer llog.SetSinks()
+//
+//	doThing()
+//	r.AssertLogged(t, llog.ErrorLevel, "thing failed", llog.KV{"id": 1})
+//
+// Recorder.Logger can instead be used to get a *llog.Logger which writes only
+// to the Recorder, for code which takes a Logger.
+//
+// A FaultyWriter can be used as an output in order to test what happens when
+// entries can't be written.
+package llogtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/levenlabs/go-llog"
+)
+
+// TestingT is the subset of testing.TB which is used by the assertion methods
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Recorder captures entries in memory. It implements both llog.Sink and
+// llog.Hook, so it can be added using llog.AddSink, llog.AddHook, or
+// llog.WithHooks. It's safe to use from multiple go-routines.
+type Recorder struct {
+	l       sync.Mutex
+	entries []llog.Entry
+}
+
+// NewRecorder returns an empty Recorder
+func NewRecorder() *Recorder {
+	return new(Recorder)
+}
+
+// WriteEntry implements the llog.Sink interface
+func (r *Recorder) WriteEntry(e llog.Entry) error {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+// Level implements the llog.Hook interface, all entries are recorded
+func (r *Recorder) Level() llog.Level {
+	return llog.DebugLevel
+}
+
+// Fire implements the llog.Hook interface
+func (r *Recorder) Fire(e llog.Entry) {
+	r.WriteEntry(e)
+}
+
+// Logger returns a synchronous Logger which logs entries of all levels to only
+// the Recorder
+func (r *Recorder) Logger() *llog.Logger {
+	return llog.New(
+		llog.WithOutput(nil),
+		llog.WithLevel(llog.DebugLevel),
+		llog.WithHooks(r),
+		llog.WithSynchronous(true),
+	)
+}
+
+// Entries returns all entries which have been recorded, in the order they were
+// written. llog.Flush is called first, so that any entries logged using the
+// package-level functions have been written.
+func (r *Recorder) Entries() []llog.Entry {
+	llog.Flush()
+	r.l.Lock()
+	defer r.l.Unlock()
+	return append([]llog.Entry(nil), r.entries...)
+}
+
+// Reset discards all recorded entries
+func (r *Recorder) Reset() {
+	llog.Flush()
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.entries = nil
+}
+
+// Find returns all recorded entries with the given level and message which
+// have all of the given KV's keys set to equal values. Other keys in the
+// entries' KV are ignored.
+func (r *Recorder) Find(l llog.Level, msg string, kv ...llog.KV) []llog.Entry {
+	want := llog.Merge(kv...)
+	var found []llog.Entry
+	for _, e := range r.Entries() {
+		if e.Level == l && e.Msg == msg && hasKV(e.KV, want) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+func hasKV(kv, want llog.KV) bool {
+	for k, v := range want {
+		ev, ok := kv[k]
+		if !ok || !reflect.DeepEqual(ev, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// AssertLogged fails the test if no entry matching the arguments (see Find)
+// has been recorded, and returns whether one was
+func (r *Recorder) AssertLogged(t TestingT, l llog.Level, msg string, kv ...llog.KV) bool {
+	t.Helper()
+	if len(r.Find(l, msg, kv...)) > 0 {
+		return true
+	}
+	t.Errorf("no entry logged matching %s -- %s -- %v, entries were:\n%s", l, msg, llog.Merge(kv...), r)
+	return false
+}
+
+// AssertNotLogged fails the test if any entry matching the arguments (see Find)
+// has been recorded, and returns whether none were
+func (r *Recorder) AssertNotLogged(t TestingT, l llog.Level, msg string, kv ...llog.KV) bool {
+	t.Helper()
+	if len(r.Find(l, msg, kv...)) == 0 {
+		return true
+	}
+	t.Errorf("unexpected entry logged matching %s -- %s -- %v, entries were:\n%s", l, msg, llog.Merge(kv...), r)
+	return false
+}
+
+// String returns all recorded entries, formatted using llog.TextFormatter
+func (r *Recorder) String() string {
+	sb := new(strings.Builder)
+	for _, e := range r.Entries() {
+		if err := (llog.TextFormatter{}).Format(sb, e, false); err != nil {
+			fmt.Fprintf(sb, "%s -- %s -- (could not format: %s)\n", e.Level, e.Msg, err)
+		}
+	}
+	return sb.String()
+}
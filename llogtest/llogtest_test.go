@@ -0,0 +1,60 @@
+package llogtest
+
+import (
+	"fmt"
+	. "testing"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeT records the failures of assertions which are expected to fail
+type fakeT struct {
+	errs []string
+}
+
+func (ft *fakeT) Helper() {}
+
+func (ft *fakeT) Errorf(format string, args ...interface{}) {
+	ft.errs = append(ft.errs, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *T) {
+	r := NewRecorder()
+	l := r.Logger()
+	l.Debug("foo")
+	l.With(llog.KV{"a": 1}).Error("bar", llog.KV{"b": "two"})
+
+	entries := r.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, llog.DebugLevel, entries[0].Level)
+	assert.Equal(t, "foo", entries[0].Msg)
+	assert.Equal(t, llog.KV{"a": 1, "b": "two"}, entries[1].KV)
+
+	assert.True(t, r.AssertLogged(t, llog.DebugLevel, "foo"))
+	assert.True(t, r.AssertLogged(t, llog.ErrorLevel, "bar", llog.KV{"a": 1}))
+	assert.True(t, r.AssertNotLogged(t, llog.InfoLevel, "foo"))
+
+	ft := new(fakeT)
+	assert.False(t, r.AssertLogged(ft, llog.ErrorLevel, "bar", llog.KV{"a": 2}))
+	assert.False(t, r.AssertNotLogged(ft, llog.ErrorLevel, "bar"))
+	require.Len(t, ft.errs, 2)
+	assert.Contains(t, ft.errs[0], "~ ERROR -- bar -- a=\"1\" b=\"two\"\n")
+
+	r.Reset()
+	assert.Empty(t, r.Entries())
+}
+
+func TestRecorderSink(t *T) {
+	r := NewRecorder()
+	llog.AddSink(r)
+	defer llog.SetSinks()
+	oldOut := llog.Out
+	llog.SetOutput(nil)
+	defer llog.SetOutput(oldOut)
+
+	// no Flush or sleep should be needed
+	llog.Warn("foo", llog.KV{"a": 1})
+	r.AssertLogged(t, llog.WarnLevel, "foo", llog.KV{"a": 1})
+}
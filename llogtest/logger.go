@@ -0,0 +1,46 @@
+package llogtest
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/levenlabs/go-llog"
+)
+
+// tWriter writes each entry to a test's log, until the test has completed
+type tWriter struct {
+	t    testing.TB
+	l    sync.Mutex
+	done bool
+}
+
+func (tw *tWriter) Write(b []byte) (int, error) {
+	tw.l.Lock()
+	defer tw.l.Unlock()
+	// logging to a test after it's completed panics, so entries logged by
+	// go-routines which outlive the test are dropped
+	if !tw.done {
+		tw.t.Log(string(bytes.TrimSuffix(b, []byte("\n"))))
+	}
+	return len(b), nil
+}
+
+// Logger returns a synchronous Logger which writes entries of all levels to the
+// test's log using t.Log, so they're only shown if the test fails or -v is
+// used, and appear alongside the test's own output. Entries logged after the
+// test has completed are dropped. Any given Options are applied after the
+// defaults, e.g. to raise the level.
+func Logger(t testing.TB, opts ...llog.Option) *llog.Logger {
+	tw := &tWriter{t: t}
+	t.Cleanup(func() {
+		tw.l.Lock()
+		defer tw.l.Unlock()
+		tw.done = true
+	})
+	return llog.New(append([]llog.Option{
+		llog.WithOutput(tw),
+		llog.WithLevel(llog.DebugLevel),
+		llog.WithSynchronous(true),
+	}, opts...)...)
+}
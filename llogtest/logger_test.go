@@ -0,0 +1,37 @@
+package llogtest
+
+import (
+	. "testing"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTB records what's logged to it
+type fakeTB struct {
+	TB
+	logs []string
+}
+
+func (ft *fakeTB) Log(args ...interface{}) {
+	ft.logs = append(ft.logs, args[0].(string))
+}
+
+func TestLogger(t *T) {
+	var ft *fakeTB
+	var l *llog.Logger
+	t.Run("sub", func(t *T) {
+		ft = &fakeTB{TB: t}
+		l = Logger(ft, llog.WithLevel(llog.InfoLevel))
+		l.Debug("foo")
+		l.Info("bar", llog.KV{"a": 1})
+	})
+	assert.Equal(t, []string{`~ INFO -- bar -- a="1"`}, ft.logs)
+
+	// once the test has completed entries should be dropped
+	l.Info("baz")
+	assert.Len(t, ft.logs, 1)
+
+	// the real thing
+	Logger(t).Info("this is logged to the test")
+}
@@ -0,0 +1,77 @@
+package llotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/levenlabs/go-llog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanEvents returns a Processor which records every entry of at least the given
+// level as an event on the span in the Context, with the entry's message as the
+// event's name and its KV as the event's attributes. Redaction and scrubbing
+// are applied to the event first, see llog.Sanitize. If the Context has no
+// span which is recording then nil is returned.
+func SpanEvents(ctx context.Context, lvl llog.Level) llog.Processor {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+	return func(e llog.Entry) (llog.Entry, bool) {
+		if e.Level < lvl {
+			return e, true
+		}
+		ee := e
+		ee.KV = e.KV.Copy()
+		ee = llog.Sanitize(ee)
+		span.AddEvent(ee.Msg,
+			trace.WithTimestamp(ee.Time),
+			trace.WithAttributes(attributes(ee)...),
+		)
+		return e, true
+	}
+}
+
+// InstallSpanEvents registers SpanEvents with llog.AddCtxProcessorFunc, so that
+// entries of at least the given level written by a Logger returned from
+// llog.CtxLogger are recorded as events on the active span. WarnLevel is a good
+// choice of level, so that warnings and errors show up inline in traces. It
+// should only be called once.
+func InstallSpanEvents(lvl llog.Level) {
+	llog.AddCtxProcessorFunc(func(ctx context.Context) llog.Processor {
+		return SpanEvents(ctx, lvl)
+	})
+}
+
+// attributes returns the entry's level and flattened KV as attributes
+func attributes(e llog.Entry) []attribute.KeyValue {
+	kv := e.KV.Flatten(".")
+	attrs := make([]attribute.KeyValue, 0, len(kv)+1)
+	attrs = append(attrs, attribute.String("level", e.Level.String()))
+	for k, v := range kv {
+		attrs = append(attrs, attributeValue(k, v))
+	}
+	return attrs
+}
+
+func attributeValue(k string, v interface{}) attribute.KeyValue {
+	switch vv := v.(type) {
+	case string:
+		return attribute.String(k, vv)
+	case bool:
+		return attribute.Bool(k, vv)
+	case int:
+		return attribute.Int(k, vv)
+	case int64:
+		return attribute.Int64(k, vv)
+	case float64:
+		return attribute.Float64(k, vv)
+	case error:
+		return attribute.String(k, vv.Error())
+	case fmt.Stringer:
+		return attribute.Stringer(k, vv)
+	}
+	return attribute.String(k, fmt.Sprint(v))
+}
@@ -0,0 +1,46 @@
+package llotel
+
+import (
+	"context"
+	"errors"
+	. "testing"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpanEvents(t *T) {
+	assert.Nil(t, SpanEvents(context.Background(), llog.WarnLevel))
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+
+	l := llog.New(llog.WithOutput(nil), llog.WithLevel(llog.DebugLevel), llog.WithSynchronous(true))
+	l = l.WithProcessors(SpanEvents(ctx, llog.WarnLevel))
+	l.Info("ignored")
+	l.Error("failed", llog.KV{
+		"err":      errors.New("boom"),
+		"n":        3,
+		"password": "hunter2",
+		"req":      llog.KV{"method": "GET"},
+	})
+	span.End()
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	events := spans[0].Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "failed", events[0].Name)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("level", "ERROR"),
+		attribute.String("err", "boom"),
+		attribute.Int("n", 3),
+		attribute.String("password", llog.Redacted),
+		attribute.String("req.method", "GET"),
+	}, events[0].Attributes)
+}
@@ -0,0 +1,57 @@
+// Package llotel connects llog with OpenTelemetry, so that entries and traces
+// can be correlated with each other.
+//
+// Calling Install once adds the IDs of the active span to the KV of every
+// Logger returned by llog.CtxLogger:
+//
+//	func main() {
+//		llotel.Install()
+//		...
+//	}
+//
+//	func handle(ctx context.Context) {
+//		// includes trace_id and span_id
+//		llog.CtxLogger(ctx).Info("handling")
+//	}
+package llotel
+
+import (
+	"context"
+
+	"github.com/levenlabs/go-llog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The keys SpanKV uses
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// SpanKV returns a KV with the trace and span IDs of the span in the Context,
+// or nil if there isn't a valid one
+func SpanKV(ctx context.Context) llog.KV {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return llog.KV{
+		TraceIDKey: sc.TraceID().String(),
+		SpanIDKey:  sc.SpanID().String(),
+	}
+}
+
+// Correlator is an llog.Correlator which returns the KV returned by SpanKV
+type Correlator struct{}
+
+// Correlate implements the llog.Correlator interface
+func (Correlator) Correlate(ctx context.Context) llog.KV {
+	return SpanKV(ctx)
+}
+
+// Install registers a Correlator with llog.AddCorrelator, so that llog.CtxKV
+// and llog.CtxLogger include the IDs of the active span. It should only be
+// called once.
+func Install() {
+	llog.AddCorrelator(Correlator{})
+}
@@ -0,0 +1,32 @@
+package llotel
+
+import (
+	"context"
+	. "testing"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpanKV(t *T) {
+	ctx := context.Background()
+	assert.Nil(t, SpanKV(ctx))
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+	})
+	ctx = trace.ContextWithSpanContext(ctx, sc)
+	expected := llog.KV{
+		"trace_id": "0102030405060708090a0b0c0d0e0f10",
+		"span_id":  "0102030405060708",
+	}
+	assert.Equal(t, expected, SpanKV(ctx))
+	assert.Equal(t, expected, Correlator{}.Correlate(ctx))
+
+	Install()
+	ctx = llog.CtxWithKV(ctx, llog.KV{"a": 1})
+	assert.Equal(t, llog.Merge(expected, llog.KV{"a": 1}), llog.CtxKV(ctx))
+	assert.Equal(t, llog.KV{}, llog.CtxKV(context.Background()))
+}
@@ -0,0 +1,86 @@
+// Package llprom exposes llog's statistics (see llog.Stats) as Prometheus
+// metrics, so that alerts can be based on how much is being logged at each
+// level, and on the health of logging itself.
+//
+// Example:
+//
+//	prometheus.MustRegister(llprom.NewCollector(nil))
+package llprom
+
+import (
+	"strings"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var levels = []llog.Level{
+	llog.DebugLevel,
+	llog.InfoLevel,
+	llog.WarnLevel,
+	llog.ErrorLevel,
+	llog.FatalLevel,
+}
+
+// Collector is a prometheus.Collector which exposes the following metrics,
+// read from llog's statistics whenever it's collected:
+//
+//	llog_entries_total{level="info"}  counter, entries written by level
+//	llog_dropped_entries_total        counter, entries logged but never written
+//	llog_write_errors_total           counter, failed writes to Out or a Sink
+//	llog_queue_depth                  gauge, entries waiting to be written
+//	llog_queue_size                   gauge, size of the buffer entries wait in
+type Collector struct {
+	stats func() llog.LoggerStats
+
+	entries, dropped, writeErrors *prometheus.Desc
+	queueDepth, queueSize         *prometheus.Desc
+}
+
+// NewCollector returns a Collector which reads statistics using the given
+// function, or llog.Stats if it's nil. Any constant labels are added to all of
+// its metrics, which is useful for telling multiple Collectors apart.
+func NewCollector(stats func() llog.LoggerStats, constLabels ...prometheus.Labels) *Collector {
+	if stats == nil {
+		stats = llog.Stats
+	}
+	var labels prometheus.Labels
+	if len(constLabels) > 0 {
+		labels = constLabels[0]
+	}
+	return &Collector{
+		stats: stats,
+		entries: prometheus.NewDesc("llog_entries_total",
+			"Number of log entries written, by level.", []string{"level"}, labels),
+		dropped: prometheus.NewDesc("llog_dropped_entries_total",
+			"Number of log entries which were logged but never written.", nil, labels),
+		writeErrors: prometheus.NewDesc("llog_write_errors_total",
+			"Number of times a log entry couldn't be written to an output.", nil, labels),
+		queueDepth: prometheus.NewDesc("llog_queue_depth",
+			"Number of log entries waiting to be written.", nil, labels),
+		queueSize: prometheus.NewDesc("llog_queue_size",
+			"Size of the buffer log entries wait to be written in.", nil, labels),
+	}
+}
+
+// Describe implements the prometheus.Collector interface
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.dropped
+	ch <- c.writeErrors
+	ch <- c.queueDepth
+	ch <- c.queueSize
+}
+
+// Collect implements the prometheus.Collector interface
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	for _, l := range levels {
+		ch <- prometheus.MustNewConstMetric(c.entries, prometheus.CounterValue,
+			float64(s.Written[l]), strings.ToLower(l.String()))
+	}
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(s.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.writeErrors, prometheus.CounterValue, float64(s.WriteErrors))
+	ch <- prometheus.MustNewConstMetric(c.queueDepth, prometheus.GaugeValue, float64(s.QueueDepth))
+	ch <- prometheus.MustNewConstMetric(c.queueSize, prometheus.GaugeValue, float64(s.QueueSize))
+}
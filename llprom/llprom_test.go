@@ -0,0 +1,48 @@
+package llprom
+
+import (
+	"strings"
+	. "testing"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *T) {
+	l := llog.New(llog.WithOutput(nil), llog.WithBufferSize(4))
+	l.Info("foo")
+	l.Info("bar")
+	l.Error("baz")
+	l.Close()
+	l.Info("dropped")
+
+	c := NewCollector(l.Stats, prometheus.Labels{"logger": "test"})
+	err := testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP llog_dropped_entries_total Number of log entries which were logged but never written.
+# TYPE llog_dropped_entries_total counter
+llog_dropped_entries_total{logger="test"} 1
+# HELP llog_entries_total Number of log entries written, by level.
+# TYPE llog_entries_total counter
+llog_entries_total{level="debug",logger="test"} 0
+llog_entries_total{level="error",logger="test"} 1
+llog_entries_total{level="fatal",logger="test"} 0
+llog_entries_total{level="info",logger="test"} 2
+llog_entries_total{level="warn",logger="test"} 0
+# HELP llog_queue_depth Number of log entries waiting to be written.
+# TYPE llog_queue_depth gauge
+llog_queue_depth{logger="test"} 0
+# HELP llog_queue_size Size of the buffer log entries wait to be written in.
+# TYPE llog_queue_size gauge
+llog_queue_size{logger="test"} 4
+# HELP llog_write_errors_total Number of times a log entry couldn't be written to an output.
+# TYPE llog_write_errors_total counter
+llog_write_errors_total{logger="test"} 0
+`))
+	assert.NoError(t, err)
+
+	// the package-level stats by default
+	require.NoError(t, prometheus.NewPedanticRegistry().Register(NewCollector(nil)))
+}
@@ -0,0 +1,184 @@
+package llsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+var (
+	errNamedArgs = errors.New("llsql: underlying driver does not support named args")
+	errIsolation = errors.New("llsql: underlying driver does not support non-default isolation level")
+	errReadOnly  = errors.New("llsql: underlying driver does not support read-only transactions")
+)
+
+// conn implements all of the optional interfaces a driver.Conn can, falling
+// back to what database/sql would do if the underlying Conn doesn't implement
+// them
+type conn struct {
+	driver.Conn
+	opts Options
+}
+
+// Prepare implements the driver.Conn interface
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements the driver.ConnPrepareContext interface
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var s driver.Stmt
+	var err error
+	if cpc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = cpc.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: s, conn: c, query: query, opts: c.opts}, nil
+}
+
+// BeginTx implements the driver.ConnBeginTx interface
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if cbt, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return cbt.BeginTx(ctx, opts)
+	}
+	// the same checks database/sql makes when the Conn isn't wrapped
+	if opts.Isolation != 0 {
+		return nil, errIsolation
+	} else if opts.ReadOnly {
+		return nil, errReadOnly
+	} else if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Conn.Begin()
+}
+
+// ExecContext implements the driver.ExecerContext interface
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := ec.ExecContext(ctx, query, args)
+	c.opts.log(ctx, query, args, start, err)
+	return res, err
+}
+
+// QueryContext implements the driver.QueryerContext interface
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := qc.QueryContext(ctx, query, args)
+	c.opts.log(ctx, query, args, start, err)
+	return rows, err
+}
+
+// Ping implements the driver.Pinger interface
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements the driver.SessionResetter interface
+func (c *conn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.Conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements the driver.Validator interface
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue implements the driver.NamedValueChecker interface
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	driver.Stmt
+	conn  *conn // which prepared it
+	query string
+	opts  Options
+}
+
+// ExecContext implements the driver.StmtExecContext interface
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var res driver.Result
+	var err error
+	if sec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = sec.ExecContext(ctx, args)
+	} else {
+		var vals []driver.Value
+		if vals, err = namedValuesToValues(args); err == nil {
+			res, err = s.Stmt.Exec(vals)
+		}
+	}
+	s.opts.log(ctx, s.query, args, start, err)
+	return res, err
+}
+
+// QueryContext implements the driver.StmtQueryContext interface
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if sqc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = sqc.QueryContext(ctx, args)
+	} else {
+		var vals []driver.Value
+		if vals, err = namedValuesToValues(args); err == nil {
+			rows, err = s.Stmt.Query(vals)
+		}
+	}
+	s.opts.log(ctx, s.query, args, start, err)
+	return rows, err
+}
+
+// CheckNamedValue implements the driver.NamedValueChecker interface. Since
+// database/sql only falls back to the Conn's checker if the Stmt doesn't have
+// one, that's done here instead.
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+// ColumnConverter implements the driver.ColumnConverter interface
+func (s *stmt) ColumnConverter(idx int) driver.ValueConverter {
+	if cc, ok := s.Stmt.(driver.ColumnConverter); ok {
+		return cc.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	vals := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errNamedArgs
+		}
+		vals[i] = arg.Value
+	}
+	return vals, nil
+}
@@ -0,0 +1,158 @@
+// Package llsql wraps database/sql drivers so that every query they perform is
+// logged via llog, along with its args, duration, and error (if any).
+//
+// Examples:
+//
+//	sql.Register("llpostgres", llsql.WrapDriver(&pq.Driver{}, llsql.Options{
+//		SlowThreshold: time.Second,
+//	}))
+//	db, err := sql.Open("llpostgres", dsn)
+//
+//	db := sql.OpenDB(llsql.WrapConnector(connector, llsql.Options{}))
+package llsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/levenlabs/go-llog"
+)
+
+// Options describe how queries should be logged. The zero value logs every
+// query at DebugLevel with its args
+type Options struct {
+	// Level is the level successful queries are logged at
+	Level llog.Level
+
+	// SlowThreshold, if non-zero, causes successful queries which take at least
+	// this long to be logged at WarnLevel instead of Level
+	SlowThreshold time.Duration
+
+	// RedactArgs causes the value of every arg to be replaced with
+	// llog.Redacted when logging
+	RedactArgs bool
+}
+
+func (o Options) log(ctx context.Context, query string, args []driver.NamedValue, start time.Time, err error) {
+	// ErrSkip isn't really an error, it only tells database/sql to try a
+	// different method, which will end up being logged itself
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+
+	took := time.Since(start)
+	kv := llog.KV{
+		"query":    query,
+		"duration": took,
+	}
+	if len(args) > 0 {
+		vals := make([]interface{}, len(args))
+		for i := range args {
+			if o.RedactArgs {
+				vals[i] = llog.Redacted
+			} else {
+				vals[i] = args[i].Value
+			}
+		}
+		kv["args"] = vals
+	}
+
+	l := llog.CtxLogger(ctx)
+	switch {
+	case err != nil:
+		l.Error("Error performing sql query", kv, llog.Err(err))
+	case o.SlowThreshold > 0 && took >= o.SlowThreshold:
+		l.Warn("Slow sql query", kv)
+	default:
+		l.Log(o.Level, "Performed sql query", kv)
+	}
+}
+
+type wrappedDriver struct {
+	driver.Driver
+	opts Options
+}
+
+// WrapDriver returns a driver.Driver which wraps the given one, logging all
+// queries performed with it according to the given Options. The returned
+// Driver can be passed to sql.Register.
+func WrapDriver(d driver.Driver, opts Options) driver.Driver {
+	return wrappedDriver{Driver: d, opts: opts}
+}
+
+// Open implements the driver.Driver interface
+func (wd wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := wd.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, opts: wd.opts}, nil
+}
+
+// OpenConnector implements the driver.DriverContext interface
+func (wd wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := wd.Driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return wrappedConnector{Connector: c, driver: wd}, nil
+	}
+	return dsnConnector{name: name, driver: wd}, nil
+}
+
+type dsnConnector struct {
+	name   string
+	driver wrappedDriver
+}
+
+// Connect implements the driver.Connector interface
+func (dc dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return dc.driver.Open(dc.name)
+}
+
+// Driver implements the driver.Connector interface
+func (dc dsnConnector) Driver() driver.Driver {
+	return dc.driver
+}
+
+type wrappedConnector struct {
+	driver.Connector
+	driver wrappedDriver
+}
+
+// WrapConnector returns a driver.Connector which wraps the given one, logging
+// all queries performed with it according to the given Options. The returned
+// Connector can be passed to sql.OpenDB.
+func WrapConnector(c driver.Connector, opts Options) driver.Connector {
+	return wrappedConnector{
+		Connector: c,
+		driver:    wrappedDriver{Driver: c.Driver(), opts: opts},
+	}
+}
+
+// Connect implements the driver.Connector interface
+func (wc wrappedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c, err := wc.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, opts: wc.driver.opts}, nil
+}
+
+// Driver implements the driver.Connector interface
+func (wc wrappedConnector) Driver() driver.Driver {
+	return wc.driver
+}
+
+// Close closes the wrapped Connector, if it implements io.Closer, so that
+// sql.DB.Close closes it as it would if it weren't wrapped
+func (wc wrappedConnector) Close() error {
+	if c, ok := wc.Connector.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package llsql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	. "testing"
+	"time"
+
+	"github.com/levenlabs/go-llog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDriver's Conns only implement the required methods, so that the fallback
+// paths get exercised. The query "FAIL" returns an error, and "SLOW" sleeps
+// for 10ms.
+type testDriver struct{}
+
+func (testDriver) Open(string) (driver.Conn, error) { return testConn{}, nil }
+
+type testConn struct{}
+
+func (testConn) Prepare(query string) (driver.Stmt, error) { return testStmt(query), nil }
+func (testConn) Close() error                              { return nil }
+func (testConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type testStmt string
+
+func (s testStmt) Close() error  { return nil }
+func (s testStmt) NumInput() int { return -1 }
+
+func (s testStmt) Exec([]driver.Value) (driver.Result, error) {
+	if s == "FAIL" {
+		return nil, errors.New("failed")
+	} else if s == "SLOW" {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if _, err := s.Exec(args); err != nil {
+		return nil, err
+	}
+	return testRows{}, nil
+}
+
+type testRows struct{}
+
+func (testRows) Columns() []string         { return nil }
+func (testRows) Close() error              { return nil }
+func (testRows) Next([]driver.Value) error { return io.EOF }
+
+// testCtxConn implements the context methods directly on the Conn
+type testCtxConn struct {
+	testConn
+}
+
+func (testCtxConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	return testStmt(query).Exec(nil)
+}
+
+func (testCtxConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	return testStmt(query).Query(nil)
+}
+
+type testConnector struct{}
+
+func (testConnector) Connect(context.Context) (driver.Conn, error) { return testCtxConn{}, nil }
+func (testConnector) Driver() driver.Driver                        { return testDriver{} }
+
+// testClosingConnector records whether it's been closed
+type testClosingConnector struct {
+	testConnector
+	closed bool
+}
+
+func (c *testClosingConnector) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestWrapConnectorClose(t *T) {
+	c := new(testClosingConnector)
+	db := sql.OpenDB(WrapConnector(c, Options{}))
+	require.NoError(t, db.Close())
+	assert.True(t, c.closed)
+}
+
+// testCheckerConn accepts []int args, which database/sql's default conversion
+// rejects
+type testCheckerConn struct {
+	testConn
+}
+
+func (testCheckerConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.([]int); ok {
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+type testCheckerConnector struct{}
+
+func (testCheckerConnector) Connect(context.Context) (driver.Conn, error) {
+	return testCheckerConn{}, nil
+}
+func (testCheckerConnector) Driver() driver.Driver { return testDriver{} }
+
+func TestStmtCheckNamedValue(t *T) {
+	db := sql.OpenDB(WrapConnector(testCheckerConnector{}, Options{}))
+	defer db.Close()
+
+	stmt, err := db.Prepare("INSERT")
+	require.NoError(t, err)
+	defer stmt.Close()
+	_, err = stmt.Exec([]int{1, 2})
+	assert.NoError(t, err)
+}
+
+func TestBeginTx(t *T) {
+	db := sql.OpenDB(WrapConnector(testConnector{}, Options{}))
+	defer db.Close()
+
+	_, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+	assert.Equal(t, errIsolation, err)
+	_, err = db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	assert.Equal(t, errReadOnly, err)
+
+	// testConn's Begin always fails, so a cancelled Context must be checked
+	// before it's called
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = db.BeginTx(ctx, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = db.BeginTx(context.Background(), nil)
+	assert.EqualError(t, err, "not supported")
+}
+
+func TestWrap(t *T) {
+	buf := new(bytes.Buffer)
+	llog.Out = buf
+	llog.SetLevel(llog.DebugLevel)
+	defer llog.SetLevel(llog.InfoLevel)
+
+	assertOut := func(expected string) {
+		llog.Flush()
+		out, err := buf.ReadString('\n')
+		require.NoError(t, err)
+		assert.Regexp(t, regexp.MustCompile(expected), out)
+		assert.Zero(t, buf.Len())
+	}
+
+	sql.Register("lltest", WrapDriver(testDriver{}, Options{SlowThreshold: 5 * time.Millisecond}))
+	plainDB, err := sql.Open("lltest", "")
+	require.NoError(t, err)
+	defer plainDB.Close()
+
+	ctxDB := sql.OpenDB(WrapConnector(testConnector{}, Options{Level: llog.InfoLevel, RedactArgs: true}))
+	defer ctxDB.Close()
+
+	for _, db := range []*sql.DB{plainDB, ctxDB} {
+		ctx := llog.CtxWithKV(context.Background(), llog.KV{"a": "a"})
+		_, err = db.ExecContext(ctx, "INSERT", 1, "foo")
+		require.NoError(t, err)
+		if db == plainDB {
+			assertOut(`^~ DEBUG -- Performed sql query -- a="a" args="\[1 foo\]" duration="[^"]+" query="INSERT"\n$`)
+		} else {
+			assertOut(`^~ INFO -- Performed sql query -- a="a" args="\[\[REDACTED\] \[REDACTED\]\]" duration="[^"]+" query="INSERT"\n$`)
+		}
+
+		rows, err := db.Query("FAIL")
+		require.Error(t, err)
+		assert.Nil(t, rows)
+		assertOut(`^~ ERROR -- Error performing sql query -- duration="[^"]+" err="failed" errType="\*errors.errorString" query="FAIL"\n$`)
+
+		_, err = db.Exec("SLOW")
+		require.NoError(t, err)
+		if db == plainDB {
+			assertOut(`^~ WARN -- Slow sql query -- duration="[^"]+" query="SLOW"\n$`)
+		} else {
+			assertOut(`^~ INFO -- Performed sql query -- duration="[^"]+" query="SLOW"\n$`)
+		}
+	}
+}
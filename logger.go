@@ -1,11 +1,153 @@
 package llog
 
 import (
+	"bytes"
+	"context"
 	"io"
 	"log"
 	"strings"
+	"sync"
 )
 
+// Logger writes entries which all have a common set of KV bound to them, in
+// addition to whatever KV is passed in to each individual call. A Logger is
+// safe to use from multiple go-routines. The zero value has no bound KV, and
+// writes entries the same way as the package-level functions do, see New for
+// creating a Logger which doesn't.
+type Logger struct {
+	kv    KV
+	enc   *encodedKV // caches the encoding of kv, nil if kv is
+	procs []Processor
+	core  *core // nil means globalCore
+}
+
+// With returns a Logger which has the Merge of the given KVs bound to it
+func With(kvs ...KV) *Logger {
+	return new(Logger).With(kvs...)
+}
+
+// With returns a child of the Logger being called on, which has the given KVs
+// Merge'd on top of the KV already bound to its parent. The parent is
+// unaffected.
+func (l *Logger) With(kvs ...KV) *Logger {
+	kv := Merge(append([]KV{l.kv}, kvs...)...)
+	return &Logger{
+		kv:    kv,
+		enc:   &encodedKV{kv: kv},
+		procs: l.procs,
+		core:  l.core,
+	}
+}
+
+// WithProcessors returns a child of the Logger being called on, which will run
+// every entry it writes through the given Processors, after any Processors its
+// parent already has. The parent is unaffected.
+func (l *Logger) WithProcessors(ps ...Processor) *Logger {
+	return &Logger{
+		kv:    l.kv,
+		enc:   l.enc,
+		procs: append(l.procs[:len(l.procs):len(l.procs)], ps...),
+		core:  l.core,
+	}
+}
+
+// KV returns a copy of the KV bound to the Logger
+func (l *Logger) KV() KV {
+	return l.kv.Copy()
+}
+
+func (l *Logger) getCore() *core {
+	if l.core == nil {
+		return globalCore
+	}
+	return l.core
+}
+
+func (l *Logger) logEntry(lvl Level, msg string, kvs []KV, block bool) {
+	l.getCore().logEntry(lvl, msg, l.enc, kvs, nil, l.procs, block)
+}
+
+// Debug is like the package-level Debug, but includes the Logger's KV
+func (l *Logger) Debug(msg string, kv ...KV) {
+	l.logEntry(DebugLevel, msg, kv, BlockByDefault)
+}
+
+// Info is like the package-level Info, but includes the Logger's KV
+func (l *Logger) Info(msg string, kv ...KV) {
+	l.logEntry(InfoLevel, msg, kv, BlockByDefault)
+}
+
+// Warn is like the package-level Warn, but includes the Logger's KV
+func (l *Logger) Warn(msg string, kv ...KV) {
+	l.logEntry(WarnLevel, msg, kv, BlockByDefault)
+}
+
+// Error is like the package-level Error, but includes the Logger's KV
+func (l *Logger) Error(msg string, kv ...KV) {
+	l.logEntry(ErrorLevel, msg, kv, BlockByDefault)
+}
+
+// Fatal is like the package-level Fatal, but includes the Logger's KV
+func (l *Logger) Fatal(msg string, kv ...KV) {
+	l.logEntry(FatalLevel, msg, kv, true)
+	fatal(msg, append([]KV{l.kv}, kv...))
+}
+
+// Log writes an entry of the given level, including the Logger's KV. Logging
+// at FatalLevel behaves the same as calling Fatal
+func (l *Logger) Log(lvl Level, msg string, kv ...KV) {
+	if lvl == FatalLevel {
+		l.Fatal(msg, kv...)
+		return
+	}
+	l.logEntry(lvl, msg, kv, BlockByDefault)
+}
+
+// Enabled returns whether an entry of the given level would be written by the
+// Logger, see the package-level Enabled
+func (l *Logger) Enabled(lvl Level) bool {
+	if c := l.getCore(); !c.global {
+		return lvl >= c.level
+	}
+	return Enabled(lvl)
+}
+
+// Flush is like the package-level Flush, but flushes wherever the Logger
+// writes to
+func (l *Logger) Flush() {
+	l.getCore().waitFlush()
+}
+
+// Close is like the package-level Close, but for a Logger created by New. It
+// stops the Logger's go-routine, after which the Logger and all of its children
+// drop any entries logged to them. On any other Logger it's the same as the
+// package-level Close.
+func (l *Logger) Close() {
+	if l.core == nil {
+		Close()
+		return
+	}
+	l.core.close()
+}
+
+// Drain is like Close, but gives up waiting once the Context is done, see the
+// package-level Drain
+func (l *Logger) Drain(ctx context.Context) error {
+	return drain(ctx, l.Close)
+}
+
+// Stats is like the package-level Stats, but for the go-routine which writes
+// the Logger's entries
+func (l *Logger) Stats() LoggerStats {
+	return l.getCore().stats()
+}
+
+// QueueDepth is like the package-level QueueDepth, but for the go-routine which
+// writes the Logger's entries
+func (l *Logger) QueueDepth() (n, size int) {
+	return l.getCore().queueDepth()
+}
+
 type llogWriter struct {
 	fn      LogFunc
 	kv      KV
@@ -41,6 +183,13 @@ func NewLogger(lvl Level, kv KV, filters ...func(string) (string, error)) *log.L
 	return newErrorLogger(logFuncFromLevel(lvl), kv, filters)
 }
 
+// StdLogger returns an instance of log.Logger which logs every message written
+// to it as an llog entry of the given level. It's useful for anything which
+// expects a standard logger, like http.Server's ErrorLog.
+func StdLogger(lvl Level) *log.Logger {
+	return NewLogger(lvl, nil)
+}
+
 func newErrorLogger(fn LogFunc, kv KV, filters []func(string) (string, error)) *log.Logger {
 	return log.New(newWriter(fn, kv, filters...), "", 0)
 }
@@ -60,3 +209,51 @@ func newWriter(fn LogFunc, kv KV, filters ...func(string) (string, error)) io.Wr
 		filters: filters,
 	}
 }
+
+type lineWriter struct {
+	fn  LogFunc
+	l   sync.Mutex
+	buf []byte
+}
+
+// WriterLevel returns an io.WriteCloser which splits everything written to it
+// on newlines and logs each line as an entry with the given level. Partial
+// lines are buffered until their newline is written, or until Close is called.
+// This is useful for capturing the output of a subprocess, e.g. by setting it
+// as the Stdout of an exec.Cmd.
+func WriterLevel(lvl Level) io.WriteCloser {
+	return &lineWriter{fn: logFuncFromLevel(lvl)}
+}
+
+// Write implements the io.Writer interface
+func (lw *lineWriter) Write(b []byte) (int, error) {
+	lw.l.Lock()
+	defer lw.l.Unlock()
+	lw.buf = append(lw.buf, b...)
+	for {
+		i := bytes.IndexByte(lw.buf, '\n')
+		if i < 0 {
+			break
+		}
+		lw.logLine(lw.buf[:i])
+		lw.buf = lw.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Close implements the io.Closer interface. It logs whatever partial line is
+// still buffered
+func (lw *lineWriter) Close() error {
+	lw.l.Lock()
+	defer lw.l.Unlock()
+	lw.logLine(lw.buf)
+	lw.buf = nil
+	return nil
+}
+
+func (lw *lineWriter) logLine(b []byte) {
+	// ignore empty lines, and the carriage return of windows-style lines
+	if line := string(bytes.TrimRight(b, "\r")); line != "" {
+		lw.fn(line)
+	}
+}
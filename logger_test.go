@@ -1,6 +1,7 @@
 package llog
 
 import (
+	"bytes"
 	"net"
 	"net/http"
 	"sync"
@@ -38,3 +39,62 @@ func TestLLogErrorLogger(t *T) {
 	s.Close()
 	wg.Wait()
 }
+
+func TestStdLogger(t *T) {
+	oldOut := Out
+	defer func() {
+		Out = oldOut
+	}()
+	buf := bytes.NewBuffer(make([]byte, 0, 128))
+	Out = buf
+
+	SetLevel(InfoLevel)
+	StdLogger(DebugLevel).Print("foo")
+	StdLogger(WarnLevel).Printf("bar %d", 1)
+	Flush()
+	assert.Equal(t, "~ WARN -- bar 1\n", buf.String())
+}
+
+func TestWriterLevel(t *T) {
+	oldOut := Out
+	defer func() {
+		Out = oldOut
+	}()
+	buf := bytes.NewBuffer(make([]byte, 0, 128))
+	Out = buf
+
+	SetLevel(InfoLevel)
+	w := WriterLevel(InfoLevel)
+	_, err := w.Write([]byte("foo\nba"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("r\r\n\nbaz"))
+	require.NoError(t, err)
+	Flush()
+	assert.Equal(t, "~ INFO -- foo\n~ INFO -- bar\n", buf.String())
+
+	require.NoError(t, w.Close())
+	Flush()
+	assert.Equal(t, "~ INFO -- foo\n~ INFO -- bar\n~ INFO -- baz\n", buf.String())
+}
+
+func TestLoggerWith(t *T) {
+	oldOut := Out
+	defer func() {
+		Out = oldOut
+	}()
+	buf := bytes.NewBuffer(make([]byte, 0, 128))
+	Out = buf
+
+	l := With(KV{"a": "a"})
+	l2 := l.With(KV{"b": "b"}, KV{"a": "aa"})
+	assert.Equal(t, KV{"a": "a"}, l.KV())
+	assert.Equal(t, KV{"a": "aa", "b": "b"}, l2.KV())
+
+	SetLevel(InfoLevel)
+	l.Debug("foo")
+	l.Info("foo")
+	l2.Warn("bar", KV{"c": "c"})
+	l2.Log(ErrorLevel, "baz")
+	Flush()
+	assert.Equal(t, "~ INFO -- foo -- a=\"a\"\n~ WARN -- bar -- a=\"aa\" b=\"b\" c=\"c\"\n~ ERROR -- baz -- a=\"aa\" b=\"b\"\n", buf.String())
+}
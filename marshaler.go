@@ -0,0 +1,68 @@
+package llog
+
+import "fmt"
+
+// Marshaler is implemented by types which control how they're logged. When a
+// value in an entry's KV implements it the result of MarshalLog is written in
+// its place, by both the TextFormatter (which flattens it if it's nested) and
+// the JSONFormatter. This is useful for types whose fields would otherwise be
+// written by fmt or encoding/json, including pointers and unexported fields.
+//
+//	func (u *User) MarshalLog() interface{} {
+//		return llog.KV{"id": u.ID, "name": u.Name}
+//	}
+//
+// MarshalLog shouldn't return another Marshaler.
+type Marshaler interface {
+	MarshalLog() interface{}
+}
+
+// marshalLog returns the result of the Marshaler's MarshalLog, or a
+// description of the panic if it panics. If the result is also a Marshaler its
+// string form is returned instead, rather than recursing.
+func marshalLog(m Marshaler) (v interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			v = fmt.Sprintf("PANIC=%v", r)
+		}
+	}()
+	v = m.MarshalLog()
+	if _, ok := v.(Marshaler); ok {
+		v = fmt.Sprint(v)
+	}
+	return v
+}
+
+// resolveMarshalers returns the value with it, and any values nested within it
+// in KVs, replaced by the result of MarshalLog if they're Marshalers. KVs are
+// copied rather than modified.
+func resolveMarshalers(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case Marshaler:
+		return resolveMarshalers(marshalLog(vv))
+	case KV:
+		if !hasMarshalers(vv) {
+			return vv
+		}
+		kv := make(KV, len(vv))
+		for k, vvv := range vv {
+			kv[k] = resolveMarshalers(vvv)
+		}
+		return kv
+	}
+	return v
+}
+
+func hasMarshalers(kv KV) bool {
+	for _, v := range kv {
+		switch vv := v.(type) {
+		case Marshaler:
+			return true
+		case KV:
+			if hasMarshalers(vv) {
+				return true
+			}
+		}
+	}
+	return false
+}
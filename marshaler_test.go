@@ -0,0 +1,40 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type marshalerUser struct {
+	id       int
+	password string
+}
+
+func (u *marshalerUser) MarshalLog() interface{} {
+	return KV{"id": u.id}
+}
+
+type marshalerID int
+
+func (id marshalerID) MarshalLog() interface{} {
+	return "u-" + textValue(int(id))
+}
+
+func TestMarshaler(t *T) {
+	u := &marshalerUser{id: 1, password: "hunter2"}
+	e := Entry{Level: InfoLevel, Msg: "foo", KV: KV{"user": u, "owner": marshalerID(2), "nested": KV{"user": u}}}
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, TextFormatter{}.Format(buf, e, false))
+	assert.Equal(t, `~ INFO -- foo -- nested.user.id="1" owner="u-2" user.id="1"`+"\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, TextFormatter{NoFlatten: true}.Format(buf, e, false))
+	assert.Equal(t, `~ INFO -- foo -- nested="map[user:map[id:1]]" owner="u-2" user="map[id:1]"`+"\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, JSONFormatter{}.Format(buf, e, false))
+	assert.Equal(t, `{"level":"INFO","msg":"foo","nested":{"user":{"id":1}},"owner":"u-2","user":{"id":1}}`+"\n", buf.String())
+}
@@ -0,0 +1,89 @@
+package llog
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+type nilMode int
+
+const (
+	nilString nilMode = iota
+	nilNull
+	nilDrop
+	nilReplace
+)
+
+// NilPolicy determines how nil values in an entry's KV, including nil
+// pointers, maps, and slices, are written. See SetNilPolicy.
+type NilPolicy struct {
+	mode        nilMode
+	replacement interface{}
+}
+
+// All the NilPolicys which don't need a replacement value
+var (
+	// NilString writes nil values as "<nil>" in text, and null in JSON. This
+	// is the default.
+	NilString = NilPolicy{}
+
+	// NilNull writes nil values as an unquoted null, in both text and JSON
+	NilNull = NilPolicy{mode: nilNull}
+
+	// NilDrop removes keys with nil values from the entry
+	NilDrop = NilPolicy{mode: nilDrop}
+)
+
+// NilReplace returns a NilPolicy which replaces nil values with the given one
+func NilReplace(v interface{}) NilPolicy {
+	return NilPolicy{mode: nilReplace, replacement: v}
+}
+
+// nilPolicy holds a *NilPolicy, or nil if it hasn't been set
+var nilPolicy atomic.Value
+
+func getNilPolicy() NilPolicy {
+	if p, _ := nilPolicy.Load().(*NilPolicy); p != nil {
+		return *p
+	}
+	return NilString
+}
+
+// SetNilPolicy sets how nil values in entries' KV are written, see NilPolicy.
+// Only values directly in an entry's KV are affected, not ones nested within
+// other values.
+func SetNilPolicy(p NilPolicy) {
+	nilPolicy.Store(&p)
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// applyNilPolicy modifies the entry's KV according to the NilPolicy
+func applyNilPolicy(kv KV) {
+	p := getNilPolicy()
+	if p.mode == nilString {
+		return
+	}
+	for k, v := range kv {
+		if !isNil(v) {
+			continue
+		}
+		switch p.mode {
+		case nilNull:
+			kv[k] = nil
+		case nilDrop:
+			delete(kv, k)
+		case nilReplace:
+			kv[k] = p.replacement
+		}
+	}
+}
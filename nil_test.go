@@ -0,0 +1,30 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNilPolicy(t *T) {
+	defer SetNilPolicy(NilString)
+
+	assertPolicy := func(p NilPolicy, text, json string) {
+		SetNilPolicy(p)
+		textBuf, jsonBuf := new(bytes.Buffer), new(bytes.Buffer)
+		textL := New(WithOutput(textBuf), WithSynchronous(true))
+		jsonL := New(WithOutput(jsonBuf), WithFormatter(JSONFormatter{}), WithSynchronous(true))
+		var nilPtr *int
+		for _, l := range []*Logger{textL, jsonL} {
+			l.Info("foo", KV{"a": nil, "b": nilPtr, "c": 1})
+		}
+		assert.Equal(t, "~ INFO -- foo -- "+text+"\n", textBuf.String())
+		assert.Equal(t, `{"level":"INFO","msg":"foo",`+json+"}\n", jsonBuf.String())
+	}
+
+	assertPolicy(NilString, `a="<nil>" b="<nil>" c="1"`, `"a":null,"b":null,"c":1`)
+	assertPolicy(NilNull, `a=null b=null c="1"`, `"a":null,"b":null,"c":1`)
+	assertPolicy(NilDrop, `c="1"`, `"c":1`)
+	assertPolicy(NilReplace("-"), `a="-" b="-" c="1"`, `"a":"-","b":"-","c":1`)
+}
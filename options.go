@@ -0,0 +1,125 @@
+package llog
+
+import (
+	"io"
+	"time"
+)
+
+// Option configures a Logger created by New
+type Option func(*core)
+
+// WithOutput sets the io.Writer the Logger writes entries to. Defaults to
+// Stdout. If an error occurs while writing the write error handler is called,
+// see WithWriteErrorHandler.
+func WithOutput(w io.Writer) Option {
+	return func(c *core) { c.out = w }
+}
+
+// WithLevel sets the minimum level of entries the Logger writes. Defaults to
+// InfoLevel.
+func WithLevel(l Level) Option {
+	return func(c *core) { c.level = l }
+}
+
+// WithFormatter sets the Formatter the Logger writes entries with. Defaults to
+// TextFormatter.
+func WithFormatter(f Formatter) Option {
+	return func(c *core) { c.formatter = f }
+}
+
+// WithTimestamps sets whether the Logger displays timestamps. Defaults to
+// false.
+func WithTimestamps(display bool) Option {
+	return func(c *core) { c.displayTS = display }
+}
+
+// WithHooks adds Hooks which will be fired for every entry the Logger writes
+// of their Level or above
+func WithHooks(hs ...Hook) Option {
+	return func(c *core) {
+		c.hooks = append(c.hooks[:len(c.hooks):len(c.hooks)], hs...)
+	}
+}
+
+// WithWriteErrorHandler sets the function which is called when the Logger can't
+// write an entry, see OnWriteError. By default an error, and the entry itself,
+// are written to Stdout.
+func WithWriteErrorHandler(fn func(err error, e Entry)) Option {
+	fn = writeErrorHandler(fn)
+	return func(c *core) { c.onWriteError = fn }
+}
+
+// WithClock sets the function used to get the time the Logger's entries are
+// timestamped with, see SetClock. Defaults to the package's clock.
+func WithClock(fn func() time.Time) Option {
+	return func(c *core) { c.clock = fn }
+}
+
+// WithDeterministic enables deterministic output for the Logger, see
+// SetDeterministic
+func WithDeterministic() Option {
+	return func(c *core) { c.deterministic = true }
+}
+
+// WithBufferSize sets the number of entries which can be waiting to be written
+// before the Logger blocks, see SetBufferSize. Defaults to zero.
+func WithBufferSize(n int) Option {
+	return func(c *core) { c.bufSize = n }
+}
+
+// WithShards sets the number of shards which the Logger queues entries in, see
+// SetShards. Defaults to one.
+func WithShards(n int) Option {
+	return func(c *core) { c.shards = n }
+}
+
+// WithBatchWrites sets whether the Logger writes entries which are queued at
+// the same time using a single Write, see SetBatchWrites. Defaults to false.
+func WithBatchWrites(on bool) Option {
+	return func(c *core) { c.batch.enabled = on }
+}
+
+// WithRateLimit sets the maximum rate at which the Logger writes entries, see
+// SetRateLimit. Defaults to no limit.
+func WithRateLimit(rl RateLimit) Option {
+	return func(c *core) { c.rate = newRateLimiter(rl) }
+}
+
+// WithSynchronous sets whether the Logger writes entries from within the log
+// call, rather than from a separate go-routine, see SetSynchronous. Defaults to
+// false.
+func WithSynchronous(on bool) Option {
+	return func(c *core) { c.synchronous = on }
+}
+
+// New returns a Logger whose output, level, formatting, and hooks are
+// configured by the given Options, rather than by the package's variables and
+// functions (Out, SetLevel, OutFormatter, AddHook, AddSink, etc...), none of
+// which affect it. It has its own go-routine for writing entries (unless it's
+// synchronous), so it doesn't contend with any other Logger.
+//
+// What's done to each entry's KV is still configured package-wide, and applies
+// to every Logger: the global KV (SetGlobalKV), strict validation (SetStrict),
+// the ReservedKeyPolicy, the NilPolicy, redaction and scrubbing, size limits,
+// message templates, and caller annotation and stack traces.
+//
+//	l := llog.New(
+//		llog.WithOutput(os.Stderr),
+//		llog.WithLevel(llog.DebugLevel),
+//		llog.WithFormatter(llog.JSONFormatter{}),
+//	)
+//	l.Info("Something important has occurred")
+//
+// Children of the returned Logger, created using With or WithProcessors, write
+// entries the same way it does.
+func New(opts ...Option) *Logger {
+	c := newCore(false)
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.formatter == nil {
+		c.formatter = TextFormatter{}
+	}
+	c.start()
+	return &Logger{core: c}
+}
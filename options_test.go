@@ -0,0 +1,185 @@
+package llog
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *T) {
+	oldOut := Out
+	defer func() { Out = oldOut }()
+	globalBuf := new(bytes.Buffer)
+	Out = globalBuf
+
+	buf := new(bytes.Buffer)
+	var fired []string
+	l := New(
+		WithOutput(buf),
+		WithLevel(DebugLevel),
+		WithFormatter(JSONFormatter{}),
+		WithHooks(NewHook(WarnLevel, func(e Entry) { fired = append(fired, e.Msg) })),
+	)
+	l.Debug("foo")
+	l.With(KV{"a": 1}).Warn("bar")
+	l.Flush()
+	assert.Equal(t, `{"level":"DEBUG","msg":"foo"}`+"\n"+`{"level":"WARN","msg":"bar","a":1}`+"\n", buf.String())
+	assert.Equal(t, []string{"bar"}, fired)
+
+	// the package-level configuration shouldn't affect it, or be affected
+	SetLevel(ErrorLevel)
+	defer SetLevel(InfoLevel)
+	buf.Reset()
+	l.Info("baz")
+	l.Flush()
+	Flush()
+	assert.Equal(t, `{"level":"INFO","msg":"baz"}`+"\n", buf.String())
+	assert.Empty(t, globalBuf.String())
+
+	// defaults
+	buf.Reset()
+	l = New(WithOutput(buf))
+	l.Debug("foo")
+	l.Info("bar")
+	l.Flush()
+	assert.Equal(t, "~ INFO -- bar\n", buf.String())
+}
+
+// blockingWriter blocks every Write until its channel is closed
+type blockingWriter chan struct{}
+
+func (bw blockingWriter) Write(b []byte) (int, error) {
+	<-bw
+	return len(b), nil
+}
+
+func TestWithBufferSize(t *T) {
+	bw := make(blockingWriter)
+	l := New(WithOutput(bw), WithBufferSize(4))
+	n, size := l.QueueDepth()
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 4, size)
+
+	// the first entry is picked up by the writer, which then blocks, and the
+	// rest should be buffered without the logging blocking
+	for i := 0; i < 4; i++ {
+		l.Info("foo")
+	}
+	assert.Eventually(t, func() bool {
+		n, _ := l.QueueDepth()
+		return n == 3
+	}, time.Second, time.Millisecond)
+
+	close(bw)
+	l.Flush()
+	n, _ = l.QueueDepth()
+	assert.Equal(t, 0, n)
+}
+
+func TestWithSynchronous(t *T) {
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithSynchronous(true))
+	l.Info("foo")
+	// no Flush should be needed
+	assert.Equal(t, "~ INFO -- foo\n", buf.String())
+	n, size := l.QueueDepth()
+	assert.Zero(t, n)
+	assert.Zero(t, size)
+}
+
+func TestClose(t *T) {
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithBufferSize(10))
+	for i := 0; i < 5; i++ {
+		l.Info("foo")
+	}
+	l.Close()
+	assert.Equal(t, 5, bytes.Count(buf.Bytes(), []byte("\n")))
+
+	// entries after Close are dropped, and nothing should block
+	l.Info("bar")
+	l.With(KV{"a": 1}).Info("bar")
+	l.Flush()
+	l.Close()
+	assert.Equal(t, 5, bytes.Count(buf.Bytes(), []byte("\n")))
+
+	// a Logger which was never started
+	buf.Reset()
+	l = New(WithOutput(buf), WithSynchronous(true))
+	l.Info("foo")
+	l.Close()
+	l.Info("bar")
+	assert.Equal(t, "~ INFO -- foo\n", buf.String())
+}
+
+func TestEntryTime(t *T) {
+	// entries which are backed up should still have the time they were logged
+	bw := make(blockingWriter)
+	var times []time.Time
+	l := New(
+		WithOutput(bw),
+		WithBufferSize(2),
+		WithHooks(NewHook(DebugLevel, func(e Entry) { times = append(times, e.Time) })),
+	)
+	start := time.Now()
+	l.Info("foo")
+	l.Info("bar")
+	end := time.Now()
+	time.Sleep(20 * time.Millisecond)
+	close(bw)
+	l.Flush()
+	require.Len(t, times, 2)
+	for _, tt := range times {
+		assert.False(t, tt.Before(start))
+		assert.False(t, tt.After(end))
+	}
+}
+
+func TestDrain(t *T) {
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithBufferSize(10))
+	l.Info("foo")
+	require.NoError(t, l.Drain(context.Background()))
+	assert.Equal(t, "~ INFO -- foo\n", buf.String())
+
+	bw := make(blockingWriter)
+	l = New(WithOutput(bw), WithBufferSize(10))
+	l.Info("foo")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.Drain(ctx))
+
+	// new entries aren't accepted, even though draining hasn't finished
+	l.Info("bar")
+	assert.Equal(t, uint64(1), l.Stats().Dropped)
+	close(bw)
+	l.Close()
+}
+
+func TestWithShards(t *T) {
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithShards(4), WithBufferSize(8))
+	_, size := l.QueueDepth()
+	assert.Equal(t, 32, size)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Info("foo")
+			}
+		}()
+	}
+	wg.Wait()
+	l.Flush()
+	assert.Equal(t, 800, bytes.Count(buf.Bytes(), []byte("\n")))
+	n, _ := l.QueueDepth()
+	assert.Zero(t, n)
+}
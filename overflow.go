@@ -0,0 +1,405 @@
+package llog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// overflowSegmentSize is the size past which a segment file is closed and the
+// next one started, so that replayed segments can be removed promptly
+const overflowSegmentSize = 4 << 20
+
+// overflowSegmentExt is the extension of segment files, whose names are their
+// sequence numbers
+const overflowSegmentExt = ".overflow"
+
+// overflowFirstSeq is the sequence number of the first segment in an empty
+// directory. It leaves room for segments to be created before it, see Close.
+const overflowFirstSeq = 1 << 32
+
+// errOverflowClosed is returned when writing to a closed OverflowSink
+var errOverflowClosed = errors.New("llog: OverflowSink is closed")
+
+// OverflowSink is a Sink which writes entries to another Sink from a go-routine
+// of its own, via an in-memory buffer. Whenever the buffer is full, e.g.
+// because the other Sink has been failing during a collector outage, entries
+// are instead appended to segment files in a directory, and replayed in order
+// once the buffer has been written. This bounds the memory used by entries
+// which can't be written yet, without dropping them or blocking logging.
+//
+// Failed writes to the other Sink are retried until they succeed, with
+// exponential backoff up to one second. Segments left in the directory by a
+// previous process are replayed first. If the process exits part way through
+// replaying a segment the whole segment is replayed again next time, so some
+// entries may be written twice.
+//
+// Entries are written to segments as JSON, so the KV values of replayed entries
+// are as they were decoded from it, e.g. errors become strings and numbers
+// become float64s.
+type OverflowSink struct {
+	sink    Sink
+	dir     string
+	memCh   chan Entry // nil if wal
+	flushCh chan struct{}
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+
+	// wal is set by NewWALSink, walCh is sent on after every entry is appended
+	// to a segment
+	wal   bool
+	walCh chan struct{}
+
+	// held is the entry taken from memCh which the go-routine was retrying
+	// when it was stopped, if heldOK
+	held   Entry
+	heldOK bool
+
+	lock     sync.Mutex
+	segs     []uint64 // sequence numbers of the segments yet to be replayed
+	file     *os.File // the last segment, if it's still being appended to
+	fileSize int
+	closed   bool
+}
+
+// NewOverflowSink returns an OverflowSink which writes to the given Sink,
+// buffering up to bufSize entries in memory, and storing segments in dir,
+// which is created if it doesn't exist. It must be closed with Close.
+func NewOverflowSink(s Sink, dir string, bufSize int) (*OverflowSink, error) {
+	return newOverflowSink(s, dir, make(chan Entry, bufSize))
+}
+
+// NewWALSink returns an OverflowSink which appends every entry to a segment in
+// dir, a write-ahead log, and syncs it to disk before WriteEntry returns. Its
+// go-routine writes the entries in each segment to the given Sink, and removes
+// the segment once they've all been written successfully. Entries are therefore
+// delivered at least once, even if the process crashes, for Sinks which mustn't
+// lose any, e.g. audit Sinks (see SetAuditSinks).
+//
+// It must be closed with Close. Whatever wasn't delivered by then is replayed by
+// the next OverflowSink using the same directory.
+func NewWALSink(s Sink, dir string) (*OverflowSink, error) {
+	return newOverflowSink(s, dir, nil)
+}
+
+func newOverflowSink(s Sink, dir string, memCh chan Entry) (*OverflowSink, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	names, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	o := &OverflowSink{
+		sink:    s,
+		dir:     dir,
+		memCh:   memCh,
+		flushCh: make(chan struct{}, 1),
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
+		wal:     memCh == nil,
+		walCh:   make(chan struct{}, 1),
+	}
+	for _, name := range names {
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name.Name(), overflowSegmentExt), 10, 64)
+		if err == nil && strings.HasSuffix(name.Name(), overflowSegmentExt) {
+			o.segs = append(o.segs, seq)
+		}
+	}
+	sort.Slice(o.segs, func(i, j int) bool { return o.segs[i] < o.segs[j] })
+	go o.run()
+	return o, nil
+}
+
+// WriteEntry implements the Sink interface. It only returns an error if the
+// entry couldn't be appended to a segment, or synced if the OverflowSink was returned by NewWALSink.
+func (o *OverflowSink) WriteEntry(e Entry) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.closed {
+		return errOverflowClosed
+	} else if len(o.segs) == 0 && !o.wal {
+		// once entries are being appended to segments they all must be, so
+		// that they stay in order
+		select {
+		case o.memCh <- e:
+			return nil
+		default:
+		}
+	}
+	if o.file == nil || o.fileSize >= overflowSegmentSize {
+		seq := uint64(overflowFirstSeq)
+		if len(o.segs) > 0 {
+			seq = o.segs[len(o.segs)-1] + 1
+		}
+		if err := o.openSegment(seq); err != nil {
+			return err
+		}
+		o.segs = append(o.segs, seq)
+	}
+	n, err := o.file.Write(appendOverflowEntry(nil, e))
+	o.fileSize += n
+	if o.wal {
+		if err == nil {
+			err = o.file.Sync()
+		}
+		select {
+		case o.walCh <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+// Flush syncs the segment being appended to, if any, and has the other Sink
+// flushed once the OverflowSink's go-routine isn't busy writing to it
+func (o *OverflowSink) Flush() {
+	o.lock.Lock()
+	if o.file != nil {
+		o.file.Sync()
+	}
+	o.lock.Unlock()
+	select {
+	case o.flushCh <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the OverflowSink's go-routine, and appends the entries still in
+// memory to a segment, so that they're replayed by the next OverflowSink using
+// the same directory. The other Sink is then flushed, and closed if it has a
+// Close method.
+func (o *OverflowSink) Close() error {
+	o.lock.Lock()
+	if o.closed {
+		o.lock.Unlock()
+		return errOverflowClosed
+	}
+	o.closed = true
+	o.lock.Unlock()
+	close(o.stopCh)
+	<-o.doneCh
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	var err error
+	var es []Entry
+	if o.heldOK {
+		es = append(es, o.held)
+	}
+	for len(o.memCh) > 0 {
+		es = append(es, <-o.memCh)
+	}
+	if len(es) > 0 {
+		err = o.prependSegment(es)
+	}
+	if o.file != nil {
+		if cerr := o.file.Close(); err == nil {
+			err = cerr
+		}
+		o.file = nil
+	}
+	flushWriter(o.sink)
+	if c, ok := o.sink.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// prependSegment writes the entries to a segment before all others, since
+// entries in memory are always older than those in segments. Must be called
+// with lock held.
+func (o *OverflowSink) prependSegment(es []Entry) error {
+	seq := uint64(overflowFirstSeq)
+	if len(o.segs) > 0 {
+		seq = o.segs[0] - 1
+	}
+	if err := o.openSegment(seq); err != nil {
+		return err
+	}
+	var buf []byte
+	for _, e := range es {
+		buf = appendOverflowEntry(buf, e)
+	}
+	_, err := o.file.Write(buf)
+	return err
+}
+
+// openSegment creates the segment with the given sequence number, and makes
+// it the one being appended to. Must be called with lock held.
+func (o *OverflowSink) openSegment(seq uint64) error {
+	if o.file != nil {
+		if err := o.file.Close(); err != nil {
+			return err
+		}
+		o.file = nil
+	}
+	f, err := os.OpenFile(o.segmentPath(seq), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	o.file, o.fileSize = f, 0
+	if o.wal {
+		// the segment's directory entry must be synced too, for it to
+		// survive a crash
+		syncDir(o.dir)
+	}
+	return nil
+}
+
+func (o *OverflowSink) segmentPath(seq uint64) string {
+	return filepath.Join(o.dir, fmt.Sprintf("%020d%s", seq, overflowSegmentExt))
+}
+
+func (o *OverflowSink) run() {
+	defer close(o.doneCh)
+	for {
+		// the buffer is always written first, since its entries are older
+		// than any in segments
+		select {
+		case e := <-o.memCh:
+			if !o.write(e) {
+				o.held, o.heldOK = e, true
+				return
+			}
+			continue
+		default:
+		}
+		if seq, ok := o.nextSegment(); ok {
+			if !o.replay(seq) {
+				return
+			}
+			continue
+		}
+		select {
+		case e := <-o.memCh:
+			if !o.write(e) {
+				o.held, o.heldOK = e, true
+				return
+			}
+		case <-o.walCh:
+		case <-o.flushCh:
+			flushWriter(o.sink)
+		case <-o.stopCh:
+			return
+		}
+	}
+}
+
+// write writes the entry to the other Sink, retrying until it succeeds. It
+// returns false if the OverflowSink was closed first.
+func (o *OverflowSink) write(e Entry) bool {
+	backoff := 100 * time.Millisecond
+	for o.sink.WriteEntry(e) != nil {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-o.stopCh:
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > time.Second {
+			backoff = time.Second
+		}
+	}
+	return true
+}
+
+// nextSegment returns the first segment yet to be replayed, closing it first
+// if it's still being appended to, so that new entries go to the next one
+func (o *OverflowSink) nextSegment() (uint64, bool) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if len(o.segs) == 0 {
+		return 0, false
+	}
+	if len(o.segs) == 1 && o.file != nil {
+		o.file.Close()
+		o.file = nil
+	}
+	return o.segs[0], true
+}
+
+// replay writes every entry in the segment to the other Sink, then removes it.
+// Lines which can't be decoded, e.g. one only partially written when a previous
+// process crashed, are skipped. It returns false if the OverflowSink was
+// closed first.
+func (o *OverflowSink) replay(seq uint64) bool {
+	path := o.segmentPath(seq)
+	f, err := os.Open(path)
+	if err == nil {
+		defer f.Close()
+		r := bufio.NewReader(f)
+		for {
+			line, err := r.ReadBytes('\n')
+			if e, ok := decodeOverflowEntry(line); ok && !o.write(e) {
+				return false
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+	os.Remove(path)
+	o.lock.Lock()
+	o.segs = o.segs[1:]
+	o.lock.Unlock()
+	return true
+}
+
+// syncDir syncs the directory, if the platform allows it (e.g. Windows doesn't)
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// overflowEntry is how entries are encoded in segments
+type overflowEntry struct {
+	Level Level     `json:"level"`
+	Time  time.Time `json:"time"`
+	Msg   string    `json:"msg"`
+	KV    KV        `json:"kv"`
+}
+
+// appendOverflowEntry appends the entry to buf as a line of JSON. KV values
+// are encoded the same way the JSONFormatter would encode them.
+func appendOverflowEntry(buf []byte, e Entry) []byte {
+	buf = append(buf, `{"level":`...)
+	buf = strconv.AppendInt(buf, int64(e.Level), 10)
+	buf = append(buf, `,"time":"`...)
+	buf = e.Time.AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, `","msg":`...)
+	buf = appendJSONString(buf, e.Msg)
+	buf = append(buf, `,"kv":{`...)
+	first := true
+	for k, v := range e.KV {
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+		buf = appendJSONKey(buf, k)
+		buf = appendJSON(buf, v)
+	}
+	return append(buf, "}}\n"...)
+}
+
+func decodeOverflowEntry(line []byte) (Entry, bool) {
+	var oe overflowEntry
+	if len(line) == 0 || json.Unmarshal(line, &oe) != nil {
+		return Entry{}, false
+	}
+	return Entry{Level: oe.Level, Time: oe.Time, Msg: oe.Msg, KV: oe.KV}, true
+}
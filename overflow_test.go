@@ -0,0 +1,146 @@
+package llog
+
+import (
+	"errors"
+	"os"
+	"sync"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outageSink fails every write while it's down
+type outageSink struct {
+	lock sync.Mutex
+	down bool
+	msgs []string
+}
+
+func (s *outageSink) WriteEntry(e Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.down {
+		return errors.New("unavailable")
+	}
+	s.msgs = append(s.msgs, e.Msg)
+	return nil
+}
+
+func (s *outageSink) setDown(down bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.down = down
+}
+
+func (s *outageSink) getMsgs() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string(nil), s.msgs...)
+}
+
+func TestOverflowSink(t *T) {
+	dir := t.TempDir()
+	s := &outageSink{down: true}
+	ovs, err := NewOverflowSink(s, dir, 2)
+	require.NoError(t, err)
+
+	now := time.Now()
+	msgs := []string{"a", "b", "c", "d", "e", "f"}
+	for _, msg := range msgs {
+		e := Entry{Level: InfoLevel, Time: now, Msg: msg, KV: KV{"n": 1, "err": errors.New("foo")}}
+		require.NoError(t, ovs.WriteEntry(e))
+	}
+	ovs.Flush()
+	// at most 3 entries are held in memory, the rest are in a segment
+	names, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, names, 1)
+	assert.Empty(t, s.getMsgs())
+
+	s.setDown(false)
+	assert.Eventually(t, func() bool { return len(s.getMsgs()) == len(msgs) }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, msgs, s.getMsgs())
+	names, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	// once the segments have been replayed entries go through memory again
+	require.NoError(t, ovs.WriteEntry(Entry{Msg: "g"}))
+	assert.Eventually(t, func() bool { return len(s.getMsgs()) == len(msgs)+1 }, time.Second, 10*time.Millisecond)
+	require.NoError(t, ovs.Close())
+	assert.Equal(t, errOverflowClosed, ovs.WriteEntry(Entry{Msg: "h"}))
+}
+
+func TestOverflowSinkClose(t *T) {
+	dir := t.TempDir()
+	s := &outageSink{down: true}
+	ovs, err := NewOverflowSink(s, dir, 2)
+	require.NoError(t, err)
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(t, ovs.WriteEntry(Entry{Msg: msg, KV: KV{"msg": msg}}))
+	}
+	require.NoError(t, ovs.Close())
+	assert.Empty(t, s.getMsgs())
+
+	// the entries still in memory are replayed before those in segments
+	s = new(outageSink)
+	ovs, err = NewOverflowSink(s, dir, 2)
+	require.NoError(t, err)
+	defer ovs.Close()
+	assert.Eventually(t, func() bool { return len(s.getMsgs()) == 5 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, s.getMsgs())
+}
+
+func TestOverflowEntry(t *T) {
+	e := Entry{
+		Level: WarnLevel,
+		Time:  time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		Msg:   "some \"message\"",
+		KV:    KV{"n": 1, "err": errors.New("foo"), "nested": KV{"a": "b"}},
+	}
+	b := appendOverflowEntry(nil, e)
+	assert.Equal(t, byte('\n'), b[len(b)-1])
+	got, ok := decodeOverflowEntry(b)
+	require.True(t, ok)
+	assert.Equal(t, Entry{
+		Level: WarnLevel,
+		Time:  e.Time,
+		Msg:   e.Msg,
+		KV:    KV{"n": float64(1), "err": "foo", "nested": map[string]interface{}{"a": "b"}},
+	}, got)
+
+	_, ok = decodeOverflowEntry(b[:len(b)/2])
+	assert.False(t, ok)
+}
+
+func TestWALSink(t *T) {
+	dir := t.TempDir()
+	s := &outageSink{down: true}
+	ws, err := NewWALSink(s, dir)
+	require.NoError(t, err)
+
+	// every entry is on disk by the time WriteEntry returns
+	require.NoError(t, ws.WriteEntry(Entry{Msg: "a"}))
+	require.NoError(t, ws.WriteEntry(Entry{Msg: "b"}))
+	names, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.NotEmpty(t, names)
+	require.NoError(t, ws.Close())
+	assert.Empty(t, s.getMsgs())
+
+	// undelivered entries are replayed on restart, and only removed once
+	// they've been written
+	s.setDown(false)
+	ws, err = NewWALSink(s, dir)
+	require.NoError(t, err)
+	require.NoError(t, ws.WriteEntry(Entry{Msg: "c"}))
+	assert.Eventually(t, func() bool { return len(s.getMsgs()) == 3 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"a", "b", "c"}, s.getMsgs())
+	assert.Eventually(t, func() bool {
+		names, err := os.ReadDir(dir)
+		return err == nil && len(names) == 0
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, ws.Close())
+}
@@ -0,0 +1,111 @@
+package llog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// safeFormat formats the entry with the Formatter, recovering if it panics,
+// e.g. because of a value whose String or MarshalJSON method panics, so that a
+// single bad value can't kill the go-routine which writes every entry. See
+// formatRecovered for what's written instead.
+func safeFormat(w io.Writer, f Formatter, e Entry, ts bool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = formatRecovered(w, f, e, ts, r)
+		}
+	}()
+	return f.Format(w, e, ts)
+}
+
+// tryFormat formats the entry with the Formatter, returning what it panicked
+// with, if it did
+func tryFormat(w io.Writer, f Formatter, e Entry, ts bool) (r interface{}, err error) {
+	defer func() {
+		r = recover()
+	}()
+	return nil, f.Format(w, e, ts)
+}
+
+// formatRecovered is called once formatting the entry has panicked with r. It
+// writes an Error entry describing the panic, including which keys' values
+// panicked when formatted on their own, if any. If there were any the entry is
+// then written with each of their values replaced by a description of its
+// panic, like Lazy values which panic are.
+func formatRecovered(w io.Writer, f Formatter, e Entry, ts bool, r interface{}) error {
+	var bad []string
+	var fixed KV
+	for k, v := range e.KV {
+		kr, _ := tryFormat(io.Discard, f, Entry{Level: e.Level, Time: e.Time, KV: KV{k: v}}, ts)
+		if kr == nil {
+			continue
+		}
+		if fixed == nil {
+			fixed = e.KV.Copy()
+		}
+		fixed[k] = fmt.Sprintf("PANIC=%v", kr)
+		bad = append(bad, k)
+	}
+	sort.Strings(bad)
+
+	kv := KV{"panic": fmt.Sprint(r), "entryMsg": e.Msg}
+	if len(bad) > 0 {
+		kv["keys"] = bad
+	}
+	erre := Entry{
+		Level: ErrorLevel,
+		Time:  e.Time,
+		Msg:   "Recovered from panic while formatting entry",
+		KV:    kv,
+	}
+	if r, err := tryFormat(w, f, erre, ts); r != nil {
+		return fmt.Errorf("formatter panicked: %v", r)
+	} else if err != nil || fixed == nil {
+		return err
+	}
+
+	// the bound KV's cached encoding may include the bad values
+	e.KV, e.bound = fixed, nil
+	r, err := tryFormat(w, f, e, ts)
+	if r != nil {
+		return fmt.Errorf("formatter panicked: %v", r)
+	}
+	return err
+}
+
+// fireHook fires the Hook, writing an Error entry describing the panic if it
+// panics. Shouldn't be called outside the main loop.
+func (c *core) fireHook(h Hook, e Entry) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		out, f, ts := c.output()
+		if out == nil {
+			return
+		}
+		erre := Entry{
+			Level: ErrorLevel,
+			Time:  e.Time,
+			Msg:   "Recovered from panic in Hook",
+			KV:    KV{"panic": fmt.Sprint(r), "hook": fmt.Sprintf("%T", h), "entryMsg": e.Msg},
+		}
+		if err := c.format(out, f, erre, ts); err != nil {
+			c.writeError(&WriteError{Err: err}, erre)
+		}
+	}()
+	h.Fire(e)
+}
+
+// writeSink writes the entry to the Sink, returning an error rather than
+// panicking if it panics
+func writeSink(s Sink, e Entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sink panicked: %v", r)
+		}
+	}()
+	return s.WriteEntry(e)
+}
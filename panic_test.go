@@ -0,0 +1,87 @@
+package llog
+
+import (
+	"bytes"
+	"io"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type panicStringer struct{}
+
+func (panicStringer) String() string { panic("bad String") }
+
+type panicJSON struct{}
+
+func (panicJSON) MarshalJSON() ([]byte, error) { panic("bad MarshalJSON") }
+
+type panicFormatter struct{}
+
+func (panicFormatter) Format(w io.Writer, e Entry, ts bool) error { panic("bad Format") }
+
+func TestFormatPanic(t *T) {
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithSynchronous(true), WithFormatter(JSONFormatter{}))
+	defer l.Close()
+
+	l.Info("foo", KV{"ok": 1, "bad": panicJSON{}})
+	assert.Equal(t, `{"level":"ERROR","msg":"Recovered from panic while formatting entry","entryMsg":"foo","keys":["bad"],"panic":"bad MarshalJSON"}`+"\n"+
+		`{"level":"INFO","msg":"foo","bad":"PANIC=bad MarshalJSON","ok":1}`+"\n", buf.String())
+
+	// the bad value may be bound to the Logger
+	buf.Reset()
+	l.With(KV{"bad": panicJSON{}}).Info("bar")
+	assert.Contains(t, buf.String(), `{"level":"INFO","msg":"bar","bad":"PANIC=bad MarshalJSON"}`+"\n")
+
+	// logging carries on as usual afterwards
+	buf.Reset()
+	l.Info("baz")
+	assert.Equal(t, `{"level":"INFO","msg":"baz"}`+"\n", buf.String())
+}
+
+func TestFormatPanicStringer(t *T) {
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithSynchronous(true))
+	defer l.Close()
+	l.Info("foo", KV{"bad": panicStringer{}})
+	// fmt recovers from the panic itself
+	assert.Equal(t, `~ INFO -- foo -- bad="%!v(PANIC=String method: bad String)"`+"\n", buf.String())
+}
+
+func TestFormatPanicFormatter(t *T) {
+	var errs []error
+	l := New(
+		WithOutput(new(bytes.Buffer)),
+		WithSynchronous(true),
+		WithFormatter(panicFormatter{}),
+		WithWriteErrorHandler(func(err error, e Entry) { errs = append(errs, err) }),
+	)
+	defer l.Close()
+	l.Info("foo")
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "could not write to Out: formatter panicked: bad Format")
+}
+
+func TestHookPanic(t *T) {
+	buf := new(bytes.Buffer)
+	l := New(
+		WithOutput(buf),
+		WithSynchronous(true),
+		WithHooks(NewHook(InfoLevel, func(e Entry) { panic("bad Hook") })),
+	)
+	defer l.Close()
+	l.Info("foo")
+	assert.Equal(t, "~ ERROR -- Recovered from panic in Hook -- entryMsg=\"foo\" hook=\"llog.hookFunc\" panic=\"bad Hook\"\n~ INFO -- foo\n", buf.String())
+}
+
+type panicSink struct{}
+
+func (panicSink) WriteEntry(e Entry) error { panic("bad Sink") }
+
+func TestWriteSinkPanic(t *T) {
+	assert.EqualError(t, writeSink(panicSink{}, Entry{}), "sink panicked: bad Sink")
+	assert.NoError(t, writeSink(&flakySink{}, Entry{}))
+	assert.EqualError(t, writeSink(&flakySink{fails: 1}, Entry{}), "unavailable")
+}
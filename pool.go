@@ -0,0 +1,75 @@
+package llog
+
+import (
+	"sort"
+	"sync"
+)
+
+// Pools of the scratch space needed for formatting entries, so that writing
+// an entry doesn't need to allocate any. Anything which has grown unusually
+// large isn't returned to its pool, so that one huge entry doesn't pin its
+// memory forever.
+
+const maxPooledBuf = 64 << 10
+const maxPooledKeys = 256
+
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+func getBuf() *[]byte {
+	return bufPool.Get().(*[]byte)
+}
+
+func putBuf(b *[]byte) {
+	if cap(*b) > maxPooledBuf {
+		return
+	}
+	*b = (*b)[:0]
+	bufPool.Put(b)
+}
+
+var keysPool = sync.Pool{
+	New: func() interface{} {
+		keys := make([]string, 0, 16)
+		return &keys
+	},
+}
+
+// getKeys returns the keys of the KV, sorted if sorted is true. The returned
+// slice should be given to putKeys once it's no longer needed.
+func getKeys(kv KV, sorted bool) *[]string {
+	keys := keysPool.Get().(*[]string)
+	for k := range kv {
+		*keys = append(*keys, k)
+	}
+	if prio := getPriorityKeys(); len(prio) > 0 {
+		ks := *keys
+		rank := func(k string) int {
+			if r, ok := prio[k]; ok {
+				return r
+			}
+			return len(prio)
+		}
+		sort.SliceStable(ks, func(i, j int) bool {
+			if ri, rj := rank(ks[i]), rank(ks[j]); ri != rj {
+				return ri < rj
+			}
+			return sorted && ks[i] < ks[j]
+		})
+	} else if sorted {
+		sort.Strings(*keys)
+	}
+	return keys
+}
+
+func putKeys(keys *[]string) {
+	if cap(*keys) > maxPooledKeys {
+		return
+	}
+	*keys = (*keys)[:0]
+	keysPool.Put(keys)
+}
@@ -0,0 +1,22 @@
+package llog
+
+import (
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetKeys(t *T) {
+	kv := KV{"b": 1, "c": 2, "a": 3}
+	keys := getKeys(kv, true)
+	assert.Equal(t, []string{"a", "b", "c"}, *keys)
+	putKeys(keys)
+
+	keys = getKeys(kv, false)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, *keys)
+	putKeys(keys)
+
+	keys = getKeys(nil, true)
+	assert.Empty(t, *keys)
+	putKeys(keys)
+}
@@ -0,0 +1,41 @@
+package llog
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync/atomic"
+)
+
+// pprofLabelKeys holds a []string of the pprof labels to include as KV
+var pprofLabelKeys atomic.Value
+
+// SetCtxPprofLabels sets the pprof labels which CtxKV, and so CtxLogger,
+// includes as KV when the Context it's given has them (see pprof.Do and
+// pprof.WithLabels). This lets code which already labels its Contexts for
+// profiling get the same context on its entries without also calling
+// CtxWithKV. A label doesn't override a key from CtxWithKV or a Correlator.
+// Calling it with no keys disables it.
+//
+// Only the labels of the Context are read, not those of the calling
+// go-routine (see pprof.SetGoroutineLabels), since the runtime provides no
+// supported way of reading those. Entries logged without the Context, e.g.
+// with the package-level functions, don't include any labels.
+func SetCtxPprofLabels(keys ...string) {
+	pprofLabelKeys.Store(keys)
+}
+
+// pprofLabelsKV returns the chosen pprof labels of the Context, or nil if it
+// has none of them
+func pprofLabelsKV(ctx context.Context) KV {
+	keys, _ := pprofLabelKeys.Load().([]string)
+	var kv KV
+	for _, k := range keys {
+		if v, ok := pprof.Label(ctx, k); ok {
+			if kv == nil {
+				kv = KV{}
+			}
+			kv[k] = v
+		}
+	}
+	return kv
+}
@@ -0,0 +1,33 @@
+package llog
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCtxPprofLabels(t *T) {
+	SetCtxPprofLabels("requestID", "job")
+	defer SetCtxPprofLabels()
+
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithSynchronous(true))
+	ctx := CtxWithLogger(context.Background(), l)
+	CtxLogger(ctx).Info("none")
+	ctx = pprof.WithLabels(ctx, pprof.Labels("requestID", "abc", "other", "x"))
+	pprof.Do(ctx, pprof.Labels("job", "sync"), func(ctx context.Context) {
+		CtxLogger(ctx).Info("labeled")
+		CtxLogger(CtxWithKV(ctx, KV{"job": "mine"})).Info("override")
+		// the go-routine's labels can't be read without the Context
+		l.Info("no ctx")
+	})
+	assert.Equal(t,
+		"~ INFO -- none\n"+
+			"~ INFO -- labeled -- job=\"sync\" requestID=\"abc\"\n"+
+			"~ INFO -- override -- job=\"mine\" requestID=\"abc\"\n"+
+			"~ INFO -- no ctx\n",
+		buf.String())
+}
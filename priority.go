@@ -0,0 +1,29 @@
+package llog
+
+import "sync/atomic"
+
+// priorityKeys holds a map[string]int of the priority keys to their position
+var priorityKeys atomic.Value
+
+// SetPriorityKeys sets keys which are written before all others, in the given
+// order, by both the TextFormatter and JSONFormatter. Keys which aren't given
+// are written after them as usual. This makes important keys easy to find when
+// scanning entries, e.g.
+//
+//	llog.SetPriorityKeys("err", "requestID")
+//
+// Calling it with no keys disables it.
+func SetPriorityKeys(keys ...string) {
+	m := make(map[string]int, len(keys))
+	for i, k := range keys {
+		if _, ok := m[k]; !ok {
+			m[k] = i
+		}
+	}
+	priorityKeys.Store(m)
+}
+
+func getPriorityKeys() map[string]int {
+	m, _ := priorityKeys.Load().(map[string]int)
+	return m
+}
@@ -0,0 +1,27 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityKeys(t *T) {
+	SetPriorityKeys("err", "requestID")
+	defer SetPriorityKeys()
+
+	e := Entry{Level: InfoLevel, Msg: "foo", KV: KV{"a": 1, "requestID": "x", "z": 2, "err": "bad"}}
+	buf := new(bytes.Buffer)
+	require.NoError(t, TextFormatter{}.Format(buf, e, false))
+	require.NoError(t, JSONFormatter{}.Format(buf, e, false))
+	assert.Equal(t,
+		"~ INFO -- foo -- err=\"bad\" requestID=\"x\" a=\"1\" z=\"2\"\n"+
+			`{"level":"INFO","msg":"foo","err":"bad","requestID":"x","a":1,"z":2}`+"\n",
+		buf.String())
+
+	keys := getKeys(KV{"a": 1, "requestID": "x"}, false)
+	assert.Equal(t, "requestID", (*keys)[0])
+	putKeys(keys)
+}
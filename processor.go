@@ -0,0 +1,77 @@
+package llog
+
+import "sync"
+
+// Processor is called with an entry before it is written, and returns the
+// entry which should actually be written in its place, or false if the entry
+// should be dropped entirely. Processors can be used to add, remove, or
+// rewrite the KV of entries, or to filter them, without modifying the places
+// they are logged from.
+//
+// Processors are called after level filtering has happened, from the same
+// go-routine which writes to Out. The KV of the given Entry belongs only to
+// that entry, so it may be modified in place.
+type Processor func(Entry) (Entry, bool)
+
+var processors []Processor
+var processorsLock sync.RWMutex
+
+// AddProcessor registers a Processor which every entry will be run through.
+// Processors are run in the order they were added, after any Processors of the
+// Logger which wrote the entry.
+func AddProcessor(p Processor) {
+	processorsLock.Lock()
+	defer processorsLock.Unlock()
+	// copy so that a slice being read by processEntry is never modified
+	processors = append(processors[:len(processors):len(processors)], p)
+}
+
+func getProcessors() []Processor {
+	processorsLock.RLock()
+	defer processorsLock.RUnlock()
+	return processors
+}
+
+// processEntry runs the entry through the Processors of its Logger, and then
+// the global ones
+func processEntry(e Entry, procs, global []Processor) (Entry, bool) {
+	var ok bool
+	for _, pp := range [2][]Processor{procs, global} {
+		for _, p := range pp {
+			if e, ok = p(e); !ok {
+				return e, false
+			}
+		}
+	}
+	return e, true
+}
+
+// AllowKeys returns a Processor which removes every key from an entry's KV
+// other than the given ones. Combined with Logger.WithProcessors this can be
+// used to strip noisy or oversized keys from some Loggers' output while
+// leaving others alone.
+func AllowKeys(keys ...string) Processor {
+	allowed := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		allowed[k] = true
+	}
+	return func(e Entry) (Entry, bool) {
+		for k := range e.KV {
+			if !allowed[k] {
+				delete(e.KV, k)
+			}
+		}
+		return e, true
+	}
+}
+
+// DenyKeys returns a Processor which removes the given keys from an entry's
+// KV. See AllowKeys.
+func DenyKeys(keys ...string) Processor {
+	return func(e Entry) (Entry, bool) {
+		for _, k := range keys {
+			delete(e.KV, k)
+		}
+		return e, true
+	}
+}
@@ -0,0 +1,56 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessor(t *T) {
+	oldOut := Out
+	defer func() {
+		Out = oldOut
+		processors = nil
+	}()
+	buf := bytes.NewBuffer(make([]byte, 0, 128))
+	Out = buf
+
+	AddProcessor(func(e Entry) (Entry, bool) {
+		if e.Msg == "drop" {
+			return e, false
+		}
+		e.KV["global"] = true
+		return e, true
+	})
+	l := With(KV{"a": "a"}).WithProcessors(func(e Entry) (Entry, bool) {
+		e.Msg = "[l] " + e.Msg
+		e.KV["global"] = false // should be overwritten by the global one
+		return e, true
+	})
+	l2 := l.WithProcessors(func(e Entry) (Entry, bool) {
+		delete(e.KV, "a")
+		return e, true
+	})
+
+	SetLevel(InfoLevel)
+	Info("foo")
+	Info("drop")
+	l.Info("bar")
+	l2.Info("baz")
+	Flush()
+	assert.Equal(t, "~ INFO -- foo -- global=\"true\"\n"+
+		"~ INFO -- [l] bar -- a=\"a\" global=\"true\"\n"+
+		"~ INFO -- [l] baz -- global=\"true\"\n", buf.String())
+}
+
+func TestAllowDenyKeys(t *T) {
+	e := Entry{KV: KV{"a": 1, "b": 2, "c": 3}}
+	e, ok := AllowKeys("a", "c", "d")(e)
+	assert.True(t, ok)
+	assert.Equal(t, KV{"a": 1, "c": 3}, e.KV)
+
+	e, ok = DenyKeys("c", "d")(e)
+	assert.True(t, ok)
+	assert.Equal(t, KV{"a": 1}, e.KV)
+}
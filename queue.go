@@ -0,0 +1,151 @@
+package llog
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// entryQueue is a bounded lock-free queue of entries, which any number of log
+// calls push onto and the main loop takes off of. Pushing usually costs only a
+// couple of atomic operations, rather than the locking and go-routine handoff
+// which sending on a channel costs. Only once the queue is full do log calls
+// fall back to waiting on a sync.Cond for space.
+//
+// It's based on Dmitry Vyukov's bounded MPMC queue: each slot has a sequence
+// number which says whether it's free for the push at a particular position
+// (2*pos), or holds the entry for the take at a particular position (2*pos+1).
+// Doubling the positions allows for a queue of a single slot. Since there's
+// only one consumer the head needn't be claimed with a CAS.
+type entryQueue struct {
+	slots []queueSlot
+	tail  atomic.Uint64 // next position to push to
+	head  atomic.Uint64 // next position to take from, only modified by the consumer
+
+	// notifyCh is sent on, without blocking, after every push, so that the
+	// main loop can wait for entries in a select. It's shared by all of a
+	// core's shards.
+	notifyCh chan struct{}
+
+	// waiters is the number of log calls waiting for space, one of which is
+	// woken by each release signalling cond
+	waiters atomic.Int32
+	lock    sync.Mutex
+	cond    *sync.Cond
+}
+
+type queueSlot struct {
+	seq atomic.Uint64
+	e   entry
+}
+
+// newEntryQueue returns a queue with the given number of slots, which sends
+// on notifyCh after every push
+func newEntryQueue(size int, notifyCh chan struct{}) *entryQueue {
+	q := &entryQueue{
+		slots:    make([]queueSlot, size),
+		notifyCh: notifyCh,
+	}
+	for i := range q.slots {
+		q.slots[i].seq.Store(2 * uint64(i))
+	}
+	q.cond = sync.NewCond(&q.lock)
+	return q
+}
+
+// tryPush pushes the entry onto the queue, returning false if it's full
+func (q *entryQueue) tryPush(e entry) bool {
+	n := uint64(len(q.slots))
+	for {
+		pos := q.tail.Load()
+		s := &q.slots[pos%n]
+		switch seq := s.seq.Load(); {
+		case seq == 2*pos:
+			if q.tail.CompareAndSwap(pos, pos+1) {
+				s.e = e
+				s.seq.Store(2*pos + 1)
+				return true
+			}
+		case seq < 2*pos:
+			// the slot still holds the entry from the previous lap
+			return false
+		}
+		// another push claimed the position first
+	}
+}
+
+// push pushes the entry onto the queue, waiting for space if it's full, and
+// then wakes the consumer
+func (q *entryQueue) push(e entry) {
+	if !q.tryPush(e) {
+		// waiters is incremented before trying again, so that either release
+		// sees it and signals, or the retry sees the released slot
+		q.waiters.Add(1)
+		q.lock.Lock()
+		for !q.tryPush(e) {
+			q.cond.Wait()
+		}
+		q.lock.Unlock()
+		q.waiters.Add(-1)
+	}
+	select {
+	case q.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// peekTime returns the Time of the entry at the head of the queue, or false if
+// there isn't one ready. Only the go-routine which takes may call it.
+func (q *entryQueue) peekTime() (time.Time, bool) {
+	pos := q.head.Load()
+	s := &q.slots[pos%uint64(len(q.slots))]
+	if s.seq.Load() != 2*pos+1 {
+		return time.Time{}, false
+	}
+	return s.e.Time, true
+}
+
+// take returns the entry at the head of the queue and its position, or false
+// if there isn't one ready. The entry's slot isn't freed until release is
+// called with its position, so that a full queue stays full while its oldest
+// entry is being written. Only one go-routine may take at a time.
+func (q *entryQueue) take() (entry, uint64, bool) {
+	pos := q.head.Load()
+	s := &q.slots[pos%uint64(len(q.slots))]
+	if s.seq.Load() != 2*pos+1 {
+		return entry{}, 0, false
+	}
+	e := s.e
+	q.head.Store(pos + 1)
+	return e, pos, true
+}
+
+// release frees the slot of the entry taken from the given position
+func (q *entryQueue) release(pos uint64) {
+	s := &q.slots[pos%uint64(len(q.slots))]
+	s.e = entry{}
+	s.seq.Store(2 * (pos + uint64(len(q.slots))))
+	if q.waiters.Load() > 0 {
+		q.lock.Lock()
+		q.cond.Signal()
+		q.lock.Unlock()
+	}
+}
+
+// len returns the number of entries waiting to be taken
+func (q *entryQueue) len() int {
+	// head is loaded first, so that it can't have overtaken tail
+	head := q.head.Load()
+	return int(q.tail.Load() - head)
+}
+
+// shard returns which of n shards the calling go-routine should push to. It's
+// chosen at random, which unlike a shared counter costs no contention itself,
+// so that concurrent log calls rarely push to the same shard.
+func shard(n int) int {
+	if n == 1 {
+		return 0
+	}
+	return int(rand.Uint32N(uint32(n)))
+}
@@ -0,0 +1,85 @@
+package llog
+
+import (
+	"bytes"
+	"sync"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryQueue(t *T) {
+	for _, size := range []int{1, 2, 7} {
+		q := newEntryQueue(size, make(chan struct{}, 1))
+		assert.True(t, q.tryPush(entry{Entry: Entry{Msg: "a"}}))
+		assert.Equal(t, 1, q.len())
+
+		e, pos, ok := q.take()
+		assert.True(t, ok)
+		assert.Equal(t, "a", e.Msg)
+		assert.Equal(t, 0, q.len())
+		_, _, ok = q.take()
+		assert.False(t, ok)
+
+		// the taken entry's slot isn't free until it's released
+		for i := 1; i < size; i++ {
+			assert.True(t, q.tryPush(entry{}))
+		}
+		assert.False(t, q.tryPush(entry{}))
+		q.release(pos)
+		assert.True(t, q.tryPush(entry{}))
+		assert.Equal(t, size, q.len())
+	}
+}
+
+func TestEntryQueueConcurrent(t *T) {
+	const producers, perProducer = 8, 1000
+	for _, size := range []int{1, 16} {
+		q := newEntryQueue(size, make(chan struct{}, 1))
+		var wg sync.WaitGroup
+		for p := 0; p < producers; p++ {
+			wg.Add(1)
+			go func(p int) {
+				defer wg.Done()
+				for i := 0; i < perProducer; i++ {
+					q.push(entry{Entry: Entry{KV: KV{"p": p, "i": i}}})
+				}
+			}(p)
+		}
+
+		// each producer's entries must be taken in the order they were pushed
+		next := make([]int, producers)
+		for n := 0; n < producers*perProducer; {
+			e, pos, ok := q.take()
+			if !ok {
+				<-q.notifyCh
+				continue
+			}
+			p, i := e.KV["p"].(int), e.KV["i"].(int)
+			assert.Equal(t, next[p], i)
+			next[p] = i + 1
+			q.release(pos)
+			n++
+		}
+		wg.Wait()
+		assert.Equal(t, 0, q.len())
+	}
+}
+
+func TestDrainShards(t *T) {
+	buf := new(bytes.Buffer)
+	c := newCore(false)
+	c.out = buf
+	c.notifyCh = make(chan struct{}, 1)
+	c.queues = []*entryQueue{newEntryQueue(4, c.notifyCh), newEntryQueue(4, c.notifyCh)}
+
+	// entries are written in time order across shards
+	now := time.Now()
+	for i, msg := range []string{"a", "b", "c", "d"} {
+		e := entry{Entry: Entry{Level: InfoLevel, Msg: msg, Time: now.Add(time.Duration(i))}}
+		c.queues[(i/2+i%2)%2].push(e)
+	}
+	c.drain()
+	assert.Equal(t, "~ INFO -- a\n~ INFO -- b\n~ INFO -- c\n~ INFO -- d\n", buf.String())
+}
@@ -0,0 +1,126 @@
+package llog
+
+import (
+	"io"
+	"time"
+)
+
+// RateLimit describes the maximum rate at which entries are written, see
+// SetRateLimit. A limit of zero or less means no limit.
+type RateLimit struct {
+	// Entries is the maximum number of entries written per second
+	Entries float64
+
+	// Bytes is the maximum number of bytes written to Out per second
+	Bytes float64
+}
+
+// rateLimiter is a token bucket for each of a RateLimit's limits, holding up
+// to a second's worth of tokens. It's only accessed from the main loop.
+type rateLimiter struct {
+	limit          RateLimit
+	entries, bytes float64   // the tokens currently available
+	last           time.Time // when the buckets were last refilled
+	now            func() time.Time
+
+	// dropped is the number of entries dropped since droppedSince, which
+	// haven't been summarized yet
+	dropped      uint64
+	droppedSince time.Time
+
+	// out counts the bytes written to Out, when there's a byte limit
+	out byteCounter
+}
+
+// byteCounter counts the bytes written through it to w
+type byteCounter struct {
+	w io.Writer
+	n int
+}
+
+func (cw *byteCounter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += n
+	return n, err
+}
+
+// SetRateLimit sets a process-wide maximum rate at which entries are written,
+// as a safety valve so that a logging storm can't saturate the disk, or the
+// network bandwidth shared with production traffic. Entries over the limit are
+// dropped (see Stats), except for Fatal ones. Once entries are being written
+// again a Warn entry summarizing how many were dropped is written first:
+//
+//	~ WARN -- Dropped entries over the rate limit -- dropped="1520" since="2024-01-02 03:04:05 +0000 UTC"
+//
+// Bursts of up to a second's worth of either limit are allowed. Since an
+// entry's size isn't known until it's been written the byte limit is enforced
+// afterwards: once it's been exceeded entries are dropped until enough time has
+// passed to pay for the excess. A zero RateLimit, the default, removes the
+// limit.
+func SetRateLimit(rl RateLimit) {
+	globalCore.apply(func() { globalCore.rate = newRateLimiter(rl) })
+}
+
+// newRateLimiter returns a rateLimiter with full buckets, or nil if the
+// RateLimit has no limits
+func newRateLimiter(rl RateLimit) *rateLimiter {
+	if rl.Entries <= 0 && rl.Bytes <= 0 {
+		return nil
+	}
+	return &rateLimiter{limit: rl, entries: rl.Entries, bytes: rl.Bytes}
+}
+
+func (rl *rateLimiter) getNow() time.Time {
+	if rl.now != nil {
+		return rl.now()
+	}
+	return time.Now()
+}
+
+// allow returns whether an entry with the given time can be written, taking a
+// token for it if so. The buckets are refilled based on how much real time has
+// passed, rather than on entries' times, since a Clock or deterministic mode
+// may keep those from ever advancing.
+func (rl *rateLimiter) allow(t time.Time) bool {
+	now := rl.getNow()
+	if !rl.last.IsZero() {
+		elapsed := now.Sub(rl.last).Seconds()
+		rl.entries = min(rl.entries+elapsed*rl.limit.Entries, rl.limit.Entries)
+		rl.bytes = min(rl.bytes+elapsed*rl.limit.Bytes, rl.limit.Bytes)
+	}
+	rl.last = now
+	if (rl.limit.Entries > 0 && rl.entries < 1) || (rl.limit.Bytes > 0 && rl.bytes <= 0) {
+		if rl.dropped == 0 {
+			rl.droppedSince = t
+		}
+		rl.dropped++
+		return false
+	}
+	rl.entries--
+	return true
+}
+
+// rateLimited returns whether the entry should be dropped because of the rate
+// limit. If it's not, and entries were dropped beforehand, their summary is
+// written first. Shouldn't be called outside the main loop.
+func (c *core) rateLimited(e entry) bool {
+	if c.rate == nil || e.unlimited || e.Level == FatalLevel {
+		return false
+	} else if !c.rate.allow(e.Time) {
+		c.counters.dropped.Add(1)
+		return true
+	} else if c.rate.dropped > 0 {
+		summary := entry{
+			Entry: Entry{
+				Level: WarnLevel,
+				Time:  e.Time,
+				Msg:   "Dropped entries over the rate limit",
+				KV:    KV{"dropped": c.rate.dropped, "since": c.rate.droppedSince},
+			},
+			unlimited: true,
+		}
+		c.rate.dropped = 0
+		c.writeEntry(summary)
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	buf := new(bytes.Buffer)
+	l := New(
+		WithOutput(buf),
+		WithSynchronous(true),
+		WithClock(func() time.Time { return now }),
+		WithRateLimit(RateLimit{Entries: 2}),
+	)
+	defer l.Close()
+	// the clock never advances, the buckets are refilled from mono time
+	mono := time.Now()
+	l.core.rate.now = func() time.Time { return mono }
+
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		l.Info(msg)
+	}
+	assert.Equal(t, "~ INFO -- a\n~ INFO -- b\n", buf.String())
+	assert.Equal(t, uint64(2), l.Stats().Dropped)
+
+	// fatal entries are never dropped
+	buf.Reset()
+	l.core.writeEntry(entry{Entry: Entry{Level: FatalLevel, Time: now, Msg: "e"}})
+	assert.Equal(t, "~ FATAL -- e\n", buf.String())
+
+	buf.Reset()
+	mono = mono.Add(time.Second)
+	l.Info("f")
+	assert.Equal(t, "~ WARN -- Dropped entries over the rate limit -- dropped=\"2\" since=\"2024-01-02 03:04:05 +0000 UTC\"\n~ INFO -- f\n", buf.String())
+}
+
+func TestRateLimitBytes(t *T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	buf := new(bytes.Buffer)
+	l := New(
+		WithOutput(buf),
+		WithSynchronous(true),
+		WithClock(func() time.Time { return now }),
+		WithRateLimit(RateLimit{Bytes: 30}),
+	)
+	defer l.Close()
+	mono := time.Now()
+	l.core.rate.now = func() time.Time { return mono }
+
+	// each entry is 21 bytes, the one which exceeds the limit is still
+	// written, but those after it are dropped until it's been paid for
+	l.Info("0123456789")
+	l.Info("0123456789")
+	l.Info("0123456789")
+	assert.Equal(t, "~ INFO -- 0123456789\n~ INFO -- 0123456789\n", buf.String())
+
+	buf.Reset()
+	mono = mono.Add(time.Second / 4)
+	l.Info("foo")
+	assert.Empty(t, buf.String())
+	mono = mono.Add(time.Second)
+	l.Info("foo")
+	assert.Equal(t, "~ WARN -- Dropped entries over the rate limit -- dropped=\"2\" since=\"2024-01-02 03:04:05 +0000 UTC\"\n~ INFO -- foo\n", buf.String())
+}
@@ -0,0 +1,234 @@
+package llog
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redacted is the value which the values of redacted keys are replaced with
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedKeys are the keys which are redacted by default
+var DefaultRedactedKeys = []string{"password", "token", "authorization", "ssn"}
+
+var redactedKeys = keySet(DefaultRedactedKeys)
+var redactedKeysLock sync.RWMutex
+
+func keySet(keys []string) map[string]bool {
+	m := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		m[strings.ToLower(k)] = true
+	}
+	return m
+}
+
+// SetRedactedKeys sets the KV keys whose values will have Redacted written in
+// their place, replacing DefaultRedactedKeys. Keys are matched
+// case-insensitively. Redaction happens after all Processors have been run, so
+// it applies to every entry no matter where it was logged from. Calling this
+// with no keys disables redaction.
+func SetRedactedKeys(keys ...string) {
+	m := keySet(keys)
+	redactedKeysLock.Lock()
+	defer redactedKeysLock.Unlock()
+	redactedKeys = m
+}
+
+func redactEntry(e Entry) Entry {
+	redactedKeysLock.RLock()
+	keys := redactedKeys
+	redactedKeysLock.RUnlock()
+	if len(keys) == 0 {
+		return e
+	}
+	for k, v := range e.KV {
+		if keys[strings.ToLower(k)] {
+			e.KV[k] = Redacted
+			continue
+		}
+		if m, ok := v.(Marshaler); ok {
+			// resolved here once, rather than again by the Formatter
+			v = marshalLog(m)
+			e.KV[k] = v
+		}
+		if nv, changed := redactNested(keys, v); changed {
+			e.KV[k] = nv
+		}
+	}
+	return e
+}
+
+// redactNested returns the given value with the redacted keys nested within
+// it, at any depth, redacted, and whether anything was redacted. Values are
+// walked into the same way they're flattened, i.e. KVs, maps with string keys,
+// structs, and the results of Marshalers, as well as pointers to structs. Since
+// nested values belong to the caller rather than the entry, a value which
+// needs redacting is replaced by a KV of its contents rather than modified.
+func redactNested(keys map[string]bool, v interface{}) (KV, bool) {
+	if m, ok := v.(Marshaler); ok {
+		v = marshalLog(m)
+	}
+	switch v.(type) {
+	case fmt.Stringer, error:
+		// written using their own methods, even if they're pointers to
+		// structs
+		return nil, false
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+		v = rv.Elem().Interface()
+	}
+	if !isNested(v) {
+		return nil, false
+	}
+	kv := nestedKV(v)
+	var nkv KV
+	for k, vv := range kv {
+		var nv interface{}
+		if keys[strings.ToLower(k)] {
+			nv = Redacted
+		} else if nested, ok := redactNested(keys, vv); ok {
+			nv = nested
+		} else {
+			continue
+		}
+		if nkv == nil {
+			nkv = kv.Copy()
+		}
+		nkv[k] = nv
+	}
+	return nkv, nkv != nil
+}
+
+// Scrubber replaces every match of Regexp, in both the message and the string
+// form of the KV values of an entry, with Replacement. Replacement may refer to
+// submatches of Regexp, see regexp.Regexp.ReplaceAllString for the syntax.
+type Scrubber struct {
+	Regexp      *regexp.Regexp
+	Replacement string
+
+	// Match, if set, is called with each match of Regexp, and only those for
+	// which it returns true are replaced. It's for checks which can't be
+	// expressed as a regular expression.
+	Match func(string) bool
+}
+
+// Some commonly useful Scrubbers. None of them are enabled by default
+var (
+	// CreditCardScrubber replaces sequences of 13 to 19 digits, optionally
+	// separated by spaces or dashes, which is the shape of credit card numbers,
+	// if they pass the Luhn check which card numbers do. This leaves most other
+	// long numbers, like nanosecond timestamps and IDs, intact.
+	CreditCardScrubber = Scrubber{
+		Regexp:      regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Replacement: Redacted,
+		Match:       luhnValid,
+	}
+
+	// BearerTokenScrubber replaces the token in "Bearer <token>" strings, as
+	// found in Authorization headers
+	BearerTokenScrubber = Scrubber{
+		Regexp:      regexp.MustCompile(`(?i)\b(bearer\s+)[^\s"']+`),
+		Replacement: "${1}" + Redacted,
+	}
+
+	// EmailScrubber replaces the local part of email addresses, leaving the
+	// domain intact
+	EmailScrubber = Scrubber{
+		Regexp:      regexp.MustCompile(`[\w.%+-]+@([\w-]+(?:\.[\w-]+)+)`),
+		Replacement: Redacted + "@${1}",
+	}
+)
+
+var scrubbers []Scrubber
+var scrubbersLock sync.RWMutex
+
+// AddScrubber registers a Scrubber which will be applied to every entry, after
+// all Processors have been run. Scrubbers are applied in the order they were
+// added.
+func AddScrubber(s Scrubber) {
+	scrubbersLock.Lock()
+	defer scrubbersLock.Unlock()
+	// copy so that a slice being read by scrubEntry is never modified
+	scrubbers = append(scrubbers[:len(scrubbers):len(scrubbers)], s)
+}
+
+// SetScrubbers replaces all registered Scrubbers with the given ones. Calling it
+// with no Scrubbers removes them all.
+func SetScrubbers(ss ...Scrubber) {
+	scrubbersLock.Lock()
+	defer scrubbersLock.Unlock()
+	scrubbers = ss
+}
+
+func scrub(ss []Scrubber, str string) string {
+	for _, s := range ss {
+		if s.Match == nil {
+			str = s.Regexp.ReplaceAllString(str, s.Replacement)
+			continue
+		}
+		str = s.Regexp.ReplaceAllStringFunc(str, func(m string) string {
+			if !s.Match(m) {
+				return m
+			}
+			// matched again on its own, so that Replacement can refer to
+			// submatches
+			return s.Regexp.ReplaceAllString(m, s.Replacement)
+		})
+	}
+	return str
+}
+
+// luhnValid returns whether the digits in the string, ignoring anything else,
+// pass the Luhn checksum
+func luhnValid(str string) bool {
+	var sum, n int
+	for i := len(str) - 1; i >= 0; i-- {
+		if str[i] < '0' || str[i] > '9' {
+			continue
+		}
+		d := int(str[i] - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 0 && sum%10 == 0
+}
+
+func scrubEntry(e Entry) Entry {
+	scrubbersLock.RLock()
+	ss := scrubbers
+	scrubbersLock.RUnlock()
+	if len(ss) == 0 {
+		return e
+	}
+
+	e.Msg = scrub(ss, e.Msg)
+	for k, v := range e.KV {
+		vstr, ok := v.(string)
+		if !ok {
+			vstr = fmt.Sprint(v)
+		}
+		// only replace the value if something was scrubbed from it, so that
+		// it otherwise keeps its type
+		if scrubbed := scrub(ss, vstr); scrubbed != vstr {
+			e.KV[k] = scrubbed
+		}
+	}
+	return e
+}
+
+// Sanitize returns the entry with the redacted keys (see SetRedactedKeys) and
+// scrubbers (see AddScrubber) applied to it, as they are to every entry before
+// it's written. Since Processors are run before that happens, Processors which
+// send entries elsewhere can use it to avoid leaking anything. The entry's KV is
+// modified in place, so should be copied first if it's still needed.
+func Sanitize(e Entry) Entry {
+	return scrubEntry(redactEntry(e))
+}
@@ -0,0 +1,161 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *T) {
+	oldOut := Out
+	defer func() {
+		Out = oldOut
+		SetRedactedKeys(DefaultRedactedKeys...)
+	}()
+	buf := bytes.NewBuffer(make([]byte, 0, 128))
+	Out = buf
+
+	SetLevel(InfoLevel)
+	Info("foo", KV{"user": "bob", "Password": "hunter2", "token": "abc"})
+	Flush()
+	assert.Equal(t, "~ INFO -- foo -- Password=\"[REDACTED]\" token=\"[REDACTED]\" user=\"bob\"\n", buf.String())
+
+	// nested KVs should be redacted without modifying the original
+	buf.Reset()
+	nested := KV{"user": "bob", "auth": KV{"token": "abc"}}
+	Info("foo", KV{"req": nested})
+	Flush()
+	assert.Equal(t, "~ INFO -- foo -- req.auth.token=\"[REDACTED]\" req.user=\"bob\"\n", buf.String())
+	assert.Equal(t, KV{"user": "bob", "auth": KV{"token": "abc"}}, nested)
+
+	buf.Reset()
+	SetRedactedKeys("user")
+	Info("foo", KV{"user": "bob", "password": "hunter2"})
+	Flush()
+	assert.Equal(t, "~ INFO -- foo -- password=\"hunter2\" user=\"[REDACTED]\"\n", buf.String())
+
+	buf.Reset()
+	SetRedactedKeys()
+	Info("foo", KV{"user": "bob"})
+	Flush()
+	assert.Equal(t, "~ INFO -- foo -- user=\"bob\"\n", buf.String())
+}
+
+func TestScrub(t *T) {
+	defer func() {
+		scrubbers = nil
+	}()
+	AddScrubber(CreditCardScrubber)
+	AddScrubber(BearerTokenScrubber)
+	AddScrubber(EmailScrubber)
+
+	e := scrubEntry(Entry{
+		Msg: "card 4111 1111 1111 1111 declined",
+		KV: KV{
+			"header": "Bearer abc.def",
+			"email":  "bob.smith@example.co.uk",
+			"card":   4111111111111111,
+			"count":  12,
+			// long numbers which fail the Luhn check aren't card numbers
+			"ts":    int64(1704164645123456789),
+			"order": "order 1234-5678-9012-3456 shipped",
+		},
+	})
+	assert.Equal(t, "card [REDACTED] declined", e.Msg)
+	assert.Equal(t, KV{
+		"header": "Bearer [REDACTED]",
+		"email":  "[REDACTED]@example.co.uk",
+		"card":   "[REDACTED]",
+		"count":  12,
+		"ts":     int64(1704164645123456789),
+		"order":  "order 1234-5678-9012-3456 shipped",
+	}, e.KV)
+}
+
+func TestSanitize(t *T) {
+	defer func() {
+		scrubbers = nil
+	}()
+	AddScrubber(EmailScrubber)
+
+	e := Sanitize(Entry{
+		Msg: "sent to bob@example.com",
+		KV:  KV{"password": "hunter2", "to": "bob@example.com"},
+	})
+	assert.Equal(t, "sent to [REDACTED]@example.com", e.Msg)
+	assert.Equal(t, KV{"password": Redacted, "to": "[REDACTED]@example.com"}, e.KV)
+}
+
+type redactMarshaler struct{}
+
+func (redactMarshaler) MarshalLog() interface{} {
+	return map[string]interface{}{"user": "bob", "token": "abc"}
+}
+
+func TestRedactNested(t *T) {
+	type creds struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+	}
+	type req struct {
+		Creds creds
+		Token string `json:"apiToken"`
+	}
+	m := map[string]string{"user": "bob", "password": "hunter2"}
+	c := creds{User: "bob", Password: "hunter2"}
+	cases := []struct {
+		name string
+		v    interface{}
+		text string
+		json string
+	}{
+		{
+			name: "map",
+			v:    m,
+			text: `req.password="[REDACTED]" req.user="bob"`,
+			json: `"req":{"password":"[REDACTED]","user":"bob"}`,
+		},
+		{
+			name: "struct",
+			v:    c,
+			text: `req.password="[REDACTED]" req.user="bob"`,
+			json: `"req":{"password":"[REDACTED]","user":"bob"}`,
+		},
+		{
+			name: "pointer to struct",
+			v:    &c,
+			text: `req.password="[REDACTED]" req.user="bob"`,
+			json: `"req":{"password":"[REDACTED]","user":"bob"}`,
+		},
+		{
+			name: "nested struct",
+			v:    req{Creds: c, Token: "abc"},
+			text: `req.Creds.password="[REDACTED]" req.Creds.user="bob" req.apiToken="abc"`,
+			json: `"req":{"Creds":{"password":"[REDACTED]","user":"bob"},"apiToken":"abc"}`,
+		},
+		{
+			name: "Marshaler",
+			v:    redactMarshaler{},
+			text: `req.token="[REDACTED]" req.user="bob"`,
+			json: `"req":{"token":"[REDACTED]","user":"bob"}`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *T) {
+			buf := new(bytes.Buffer)
+			l := New(WithOutput(buf), WithSynchronous(true))
+			l.Info("foo", KV{"req": tc.v})
+			assert.Equal(t, "~ INFO -- foo -- "+tc.text+"\n", buf.String())
+
+			buf.Reset()
+			l = New(WithOutput(buf), WithSynchronous(true), WithFormatter(JSONFormatter{}))
+			l.Info("foo", KV{"req": tc.v})
+			assert.Equal(t, `{"level":"INFO","msg":"foo",`+tc.json+"}\n", buf.String())
+		})
+	}
+
+	// the caller's values aren't modified
+	assert.Equal(t, map[string]string{"user": "bob", "password": "hunter2"}, m)
+	assert.Equal(t, creds{User: "bob", Password: "hunter2"}, c)
+}
@@ -0,0 +1,48 @@
+package llog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// RequestIDKey is the key which the request ID embedded in a Context by
+// CtxWithRequestID is given in the KV returned by CtxKV
+const RequestIDKey = "requestID"
+
+type requestIDKey int
+
+// NewRequestID returns a new random (version 4) UUID, for identifying a request
+func NewRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+
+	buf := make([]byte, 36)
+	hex.Encode(buf, b[:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf)
+}
+
+// CtxWithRequestID embeds a request ID into a Context, returning a new Context
+// instance. CtxKV, and so CtxLogger, will include it under RequestIDKey.
+func CtxWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey(0), id)
+}
+
+// CtxRequestID returns the request ID embedded in the Context by
+// CtxWithRequestID, or empty string if there isn't one
+func CtxRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey(0)).(string)
+	return id
+}
@@ -0,0 +1,26 @@
+package llog
+
+import (
+	"context"
+	"regexp"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *T) {
+	uuidRe := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	id := NewRequestID()
+	assert.Regexp(t, uuidRe, id)
+	assert.NotEqual(t, id, NewRequestID())
+
+	ctx := context.Background()
+	assert.Equal(t, "", CtxRequestID(ctx))
+
+	ctx = CtxWithRequestID(ctx, id)
+	assert.Equal(t, id, CtxRequestID(ctx))
+	assert.Equal(t, KV{RequestIDKey: id}, CtxKV(ctx))
+
+	ctx = CtxWithKV(ctx, KV{"a": 1})
+	assert.Equal(t, KV{RequestIDKey: id, "a": 1}, CtxLogger(ctx).KV())
+}
@@ -0,0 +1,115 @@
+package llog
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// ReservedKeyPolicy determines what happens to KV keys which collide with the
+// fields llog writes itself, see SetReservedKeyPolicy
+type ReservedKeyPolicy int
+
+// All the possible ReservedKeyPolicies
+const (
+	// ReservedKeyAllow leaves colliding keys as they are. This is the
+	// default.
+	ReservedKeyAllow ReservedKeyPolicy = iota
+
+	// ReservedKeyPrefix renames colliding keys by prefixing them with
+	// ReservedKeyPrefixString, e.g. "msg" becomes "fields.msg"
+	ReservedKeyPrefix
+
+	// ReservedKeyDrop removes colliding keys from the entry
+	ReservedKeyDrop
+
+	// ReservedKeyStrict replaces an entry with colliding keys with an Error
+	// entry describing the collision, the same as SetStrict does. It's
+	// intended for development and tests, to catch collisions early.
+	ReservedKeyStrict
+)
+
+// ReservedKeyPrefixString is the prefix ReservedKeyPrefix renames keys with
+const ReservedKeyPrefixString = "fields."
+
+// ReservedKeyer is implemented by Formatters which write fields of their own
+// alongside an entry's KV, e.g. the level and message, and returns the keys of
+// those fields, see SetReservedKeyPolicy
+type ReservedKeyer interface {
+	ReservedKeys() []string
+}
+
+var reservedKeyPolicy int32
+
+// SetReservedKeyPolicy sets what happens to KV keys which collide with the
+// fields which llog writes itself, which would otherwise produce ambiguous,
+// duplicate fields in structured output. The reserved keys are "caller" and
+// "stack" (see SetCallerLevels and SetStackTraces), as well as the keys of the
+// fields the output's Formatter writes, if it implements ReservedKeyer, e.g.
+// "level", "ts", and "msg" for a default JSONFormatter. See ReservedKeyPolicy.
+func SetReservedKeyPolicy(p ReservedKeyPolicy) {
+	atomic.StoreInt32(&reservedKeyPolicy, int32(p))
+}
+
+// reservedKeysOf returns the keys reserved when entries are formatted with the
+// Formatter, sorted
+func reservedKeysOf(f Formatter) []string {
+	keys := []string{"caller", "stack"}
+	if rk, ok := f.(ReservedKeyer); ok {
+		keys = append(keys, rk.ReservedKeys()...)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// refreshReservedKeys updates the keys reserved by the core's output. It must
+// be called from the main loop, or before it's started.
+func (c *core) refreshReservedKeys() {
+	_, f, _ := c.output()
+	keys := reservedKeysOf(f)
+	c.reserved.Store(&keys)
+}
+
+// reservedKeys returns the keys reserved by the core's output
+func (c *core) reservedKeys() []string {
+	if keys := c.reserved.Load(); keys != nil {
+		return *keys
+	}
+	// the main loop hasn't been started, or there isn't one
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.reserved.Load() == nil {
+		c.refreshReservedKeys()
+	}
+	return *c.reserved.Load()
+}
+
+// applyReservedKeyPolicy applies the ReservedKeyPolicy to the KV, which must
+// belong to the entry, returning the problems with it if the policy is
+// ReservedKeyStrict. It must be called from the log call.
+func applyReservedKeyPolicy(kv KV, reserved []string) []string {
+	p := ReservedKeyPolicy(atomic.LoadInt32(&reservedKeyPolicy))
+	if p == ReservedKeyAllow {
+		return nil
+	}
+	var problems []string
+	for _, k := range reserved {
+		v, ok := kv[k]
+		if !ok {
+			continue
+		}
+		switch p {
+		case ReservedKeyPrefix:
+			kv[ReservedKeyPrefixString+k] = v
+		case ReservedKeyStrict:
+			problems = append(problems, reservedKeyProblem(k))
+			continue
+		}
+		delete(kv, k)
+	}
+	return problems
+}
+
+func reservedKeyProblem(k string) string {
+	return fmt.Sprintf("key %q is reserved", k)
+}
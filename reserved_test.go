@@ -0,0 +1,70 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservedKeyPolicy(t *T) {
+	defer SetReservedKeyPolicy(ReservedKeyAllow)
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithSynchronous(true), WithFormatter(JSONFormatter{}))
+
+	// by default keys are left as they are
+	l.Info("foo", KV{"msg": "bar"})
+
+	SetReservedKeyPolicy(ReservedKeyPrefix)
+	l.Info("foo", KV{"msg": "bar", "a": 1})
+	l.Infow("foo", String("level", "high"))
+
+	SetReservedKeyPolicy(ReservedKeyDrop)
+	l.Info("foo", KV{"ts": "now", "a": 1})
+
+	assert.Equal(t,
+		`{"level":"INFO","msg":"foo","msg":"bar"}`+"\n"+
+			`{"level":"INFO","msg":"foo","a":1,"fields.msg":"bar"}`+"\n"+
+			`{"level":"INFO","msg":"foo","fields.level":"high"}`+"\n"+
+			`{"level":"INFO","msg":"foo","a":1}`+"\n",
+		buf.String())
+
+	buf.Reset()
+	SetReservedKeyPolicy(ReservedKeyStrict)
+	l.Info("foo", KV{"caller": "me", "a": 1})
+	assert.Regexp(t, `^{"level":"ERROR","msg":"Invalid log entry","caller":"[^/"]+/reserved_test.go:\d+ go-llog.TestReservedKeyPolicy","entryMsg":"foo","problems":\["key \\"caller\\" is reserved"\]}`+"\n$", buf.String())
+}
+
+func TestReservedKeysFormatter(t *T) {
+	defer SetReservedKeyPolicy(ReservedKeyAllow)
+	SetReservedKeyPolicy(ReservedKeyPrefix)
+
+	// the keys are those of the Formatter in use
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithSynchronous(true), WithFormatter(KubernetesFormatter()))
+	l.Info("foo", KV{"msg": "a", "message": "b", "severity": "c"})
+	assert.Equal(t, `{"severity":"INFO","message":"foo","fields.message":"b","fields.severity":"c","msg":"a"}`+"\n", buf.String())
+
+	// the TextFormatter writes nothing which KV could collide with
+	buf.Reset()
+	l = New(WithOutput(buf), WithSynchronous(true))
+	l.Info("foo", KV{"msg": "a", "stack": "b"})
+	assert.Equal(t, "~ INFO -- foo -- fields.stack=\"b\" msg=\"a\"\n", buf.String())
+
+	// and Formatters which wrap others use theirs
+	assert.Equal(t, []string{"caller", "message", "severity", "stack", "time"}, reservedKeysOf(DockerFormatter{Formatter: KubernetesFormatter()}))
+	assert.Equal(t, []string{"caller", "stack"}, reservedKeysOf(SystemdFormatter{}))
+
+	// the global output's keys are refreshed whenever it changes
+	oldOut, oldFormatter := Out, OutFormatter
+	defer func() {
+		SetOutput(oldOut)
+		SetFormatter(oldFormatter)
+	}()
+	SetOutput(buf)
+	SetFormatter(JSONFormatter{MsgKey: "text"})
+	buf.Reset()
+	Info("foo", KV{"text": "a", "msg": "b"})
+	Flush()
+	assert.Equal(t, `{"level":"INFO","text":"foo","fields.text":"a","msg":"b"}`+"\n", buf.String())
+}
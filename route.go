@@ -0,0 +1,102 @@
+package llog
+
+import (
+	"reflect"
+	"sync"
+)
+
+// RouteKey is the reserved key which Route and RouteOnly use to direct entries
+// to named routes. It's removed from entries before they're written.
+const RouteKey = "_route"
+
+type route struct {
+	names []string
+	only  bool
+}
+
+// Route returns a KV which directs an entry to the Sinks of the given routes
+// (see SetRoute), in addition to Out and the usual Sinks. It can be passed to
+// an individual log call, or bound to a Logger so that all of its entries are
+// routed, e.g.
+//
+//	llog.Warn("login failed", llog.Route("security"), llog.KV{"user": user})
+//
+// Routing only applies to the package-level functions, and Loggers not created
+// by New.
+func Route(names ...string) KV {
+	return KV{RouteKey: route{names: names}}
+}
+
+// RouteOnly is like Route, but the entry is written only to the Sinks of the
+// given routes, instead of to Out and the usual Sinks. If none of the routes
+// have any Sinks the entry is written as usual, so that it isn't lost.
+func RouteOnly(names ...string) KV {
+	return KV{RouteKey: route{names: names, only: true}}
+}
+
+var routes = map[string][]Sink{}
+var routesLock sync.RWMutex
+
+// SetRoute replaces the Sinks which entries directed to the named route are
+// written to. Calling it with no Sinks removes the route.
+func SetRoute(name string, ss ...Sink) {
+	routesLock.Lock()
+	defer routesLock.Unlock()
+	if len(ss) == 0 {
+		delete(routes, name)
+		return
+	}
+	routes[name] = ss
+}
+
+// routeSinks returns the Sinks of all the given routes, without duplicates
+func routeSinks(names []string) []Sink {
+	routesLock.RLock()
+	defer routesLock.RUnlock()
+	var ss []Sink
+	for _, name := range names {
+		for _, s := range routes[name] {
+			if !containsSink(ss, s) {
+				ss = append(ss, s)
+			}
+		}
+	}
+	return ss
+}
+
+// containsSink returns whether the Sink is in the slice. Sinks which can't be
+// compared are never considered to be.
+func containsSink(ss []Sink, s Sink) bool {
+	if !reflect.ValueOf(s).Comparable() {
+		return false
+	}
+	for _, existing := range ss {
+		if reflect.TypeOf(existing) == reflect.TypeOf(s) && existing == s {
+			return true
+		}
+	}
+	return false
+}
+
+// allRouteSinks returns the Sinks of every route
+func allRouteSinks() []Sink {
+	routesLock.RLock()
+	names := make([]string, 0, len(routes))
+	for name := range routes {
+		names = append(names, name)
+	}
+	routesLock.RUnlock()
+	return routeSinks(names)
+}
+
+// splitRoute removes the route from the KV, which must belong to the entry,
+// and returns it
+func splitRoute(kv KV) (route, bool) {
+	v, ok := kv[RouteKey]
+	if !ok {
+		return route{}, false
+	}
+	delete(kv, RouteKey)
+	r, ok := v.(route)
+	return r, ok
+}
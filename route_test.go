@@ -0,0 +1,38 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoute(t *T) {
+	oldOut := Out
+	defer func() {
+		Out = oldOut
+		SetRoute("security")
+		SetRoute("audit")
+	}()
+	outBuf, secBuf, auditBuf := new(bytes.Buffer), new(bytes.Buffer), new(bytes.Buffer)
+	Out = outBuf
+	SetRoute("security", WriterSink{Writer: secBuf})
+	SetRoute("audit", WriterSink{Writer: auditBuf})
+
+	Info("foo", Route("security"), KV{"a": 1})
+	Info("bar", RouteOnly("security", "audit"))
+	With(Route("audit")).Info("baz")
+	Info("qux", RouteOnly("missing"))
+	Flush()
+
+	assert.Equal(t, "~ INFO -- foo -- a=\"1\"\n~ INFO -- baz\n~ INFO -- qux\n", outBuf.String())
+	assert.Equal(t, "~ INFO -- foo -- a=\"1\"\n~ INFO -- bar\n", secBuf.String())
+	assert.Equal(t, "~ INFO -- bar\n~ INFO -- baz\n", auditBuf.String())
+
+	// Loggers created by New aren't routed, but the key is still removed
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithSynchronous(true))
+	l.Info("foo", RouteOnly("security"))
+	assert.Equal(t, "~ INFO -- foo\n", buf.String())
+	assert.Equal(t, "~ INFO -- foo -- a=\"1\"\n~ INFO -- bar\n", secBuf.String())
+}
@@ -0,0 +1,102 @@
+package llog
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// LogRuntimeStats starts logging an entry with runtime statistics at the given
+// level, every interval, from its own go-routine. The entry looks like:
+//
+//	~ INFO -- runtime stats -- gcCount="3" gcPauseMax="412µs" gcPauseTotal="1.1ms" goroutines="12" heapAlloc="5242880" heapInuse="6291456" heapObjects="20312" openFDs="9"
+//
+// where the gc values cover the garbage collections since the previous entry,
+// and heap values are in bytes. openFDs is only included on platforms where it
+// can be determined. This gives basic telemetry for services which don't have a
+// metrics stack.
+//
+// The returned function stops the logging, and can be called more than once.
+func LogRuntimeStats(lvl Level, interval time.Duration) (stop func()) {
+	fn := logFuncFromLevel(lvl)
+	var prev runtime.MemStats
+	runtime.ReadMemStats(&prev)
+	return every(interval, func() {
+		fn("runtime stats", runtimeStatsKV(&prev))
+	})
+}
+
+// runtimeStatsKV returns the runtime statistics as KV, with the gc values since
+// those in prev, and updates prev to the current MemStats
+func runtimeStatsKV(prev *runtime.MemStats) KV {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	var pauseMax uint64
+	n := ms.NumGC - prev.NumGC
+	if n > uint32(len(ms.PauseNs)) {
+		n = uint32(len(ms.PauseNs))
+	}
+	for i := uint32(0); i < n; i++ {
+		// PauseNs is a circular buffer, with the most recent pause at
+		// (NumGC+255)%256
+		p := ms.PauseNs[(ms.NumGC-i+uint32(len(ms.PauseNs))-1)%uint32(len(ms.PauseNs))]
+		if p > pauseMax {
+			pauseMax = p
+		}
+	}
+
+	kv := KV{
+		"goroutines":   runtime.NumGoroutine(),
+		"heapAlloc":    ms.HeapAlloc,
+		"heapInuse":    ms.HeapInuse,
+		"heapObjects":  ms.HeapObjects,
+		"gcCount":      ms.NumGC - prev.NumGC,
+		"gcPauseTotal": time.Duration(ms.PauseTotalNs - prev.PauseTotalNs),
+		"gcPauseMax":   time.Duration(pauseMax),
+	}
+	if fds, ok := openFDs(); ok {
+		kv["openFDs"] = fds
+	}
+	*prev = ms
+	return kv
+}
+
+// openFDs returns the number of open file descriptors of the process, if the
+// platform provides a way of counting them
+func openFDs() (int, bool) {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, false
+	}
+	// one of them is the directory being read
+	return len(fds) - 1, true
+}
+
+// every calls fn every interval from its own go-routine, until the returned
+// function is called. Once that returns fn won't be called again.
+func every(interval time.Duration, fn func()) (stop func()) {
+	t := time.NewTicker(interval)
+	stopCh, doneCh := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for {
+			select {
+			case <-t.C:
+				fn()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.Stop()
+			close(stopCh)
+		})
+		<-doneCh
+	}
+}
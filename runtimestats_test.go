@@ -0,0 +1,45 @@
+package llog
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/debug"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntimeStatsKV(t *T) {
+	// stop GCs other than the explicit one from being counted
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+	var prev runtime.MemStats
+	runtime.ReadMemStats(&prev)
+	runtime.GC()
+	kv := runtimeStatsKV(&prev)
+	assert.Equal(t, uint32(1), kv["gcCount"])
+	assert.NotZero(t, kv["gcPauseMax"])
+	assert.NotZero(t, kv["goroutines"])
+	assert.NotZero(t, kv["heapAlloc"])
+	if runtime.GOOS == "linux" {
+		assert.NotZero(t, kv["openFDs"])
+	}
+
+	kv = runtimeStatsKV(&prev)
+	assert.Equal(t, uint32(0), kv["gcCount"])
+	assert.Equal(t, time.Duration(0), kv["gcPauseMax"])
+}
+
+func TestLogRuntimeStats(t *T) {
+	oldOut := Out
+	defer func() { Out = oldOut }()
+	buf := new(bytes.Buffer)
+	Out = buf
+
+	stop := LogRuntimeStats(InfoLevel, 10*time.Millisecond)
+	time.Sleep(35 * time.Millisecond)
+	stop()
+	stop()
+	Flush()
+	assert.Contains(t, buf.String(), "~ INFO -- runtime stats -- gcCount=")
+}
@@ -0,0 +1,152 @@
+//go:build unix
+
+package llog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// HandleLevelSignals starts handling SIGUSR1 and SIGUSR2 to change the log level
+// at runtime. Every SIGUSR1 lowers the level by one, towards DebugLevel, and a
+// SIGUSR2 restores the level to what it was before the first SIGUSR1. So to get
+// debug output from a process currently at InfoLevel:
+//
+//	kill -USR1 <pid>
+//
+// The returned function stops the signal handling, and can be called more than
+// once.
+func HandleLevelSignals() (stop func()) {
+	sigCh := make(chan os.Signal, 1)
+	stopCh := make(chan struct{})
+	signal.Notify(sigCh, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		var prev Level
+		var lowered bool
+		for {
+			select {
+			case sig := <-sigCh:
+				l := GetLevel()
+				switch {
+				case sig == syscall.SIGUSR1 && l > DebugLevel:
+					if !lowered {
+						prev, lowered = l, true
+					}
+					SetLevel(l - 1)
+				case sig == syscall.SIGUSR2 && lowered:
+					SetLevel(prev)
+					lowered = false
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigCh)
+			close(stopCh)
+		})
+	}
+}
+
+// HandleReopenSignal starts handling the given signals, SIGUSR1 if none are
+// given, by calling Reopen, which is the usual contract for working with
+// logrotate. Since HandleLevelSignals also uses SIGUSR1 a different signal,
+// e.g. SIGHUP, should be given if both are used.
+//
+// The returned function stops the signal handling, and can be called more than
+// once.
+func HandleReopenSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGUSR1}
+	}
+	sigCh := make(chan os.Signal, 1)
+	stopCh := make(chan struct{})
+	signal.Notify(sigCh, sigs...)
+	go func() {
+		for {
+			select {
+			case <-sigCh:
+				if err := Reopen(); err != nil {
+					Error("could not reopen log files", Err(err))
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigCh)
+			close(stopCh)
+		})
+	}
+}
+
+// raiseSignal sends the signal to the process, it's replaced in tests
+var raiseSignal = func(sig os.Signal) error {
+	return syscall.Kill(os.Getpid(), sig.(syscall.Signal))
+}
+
+// HandleFatalSignals starts handling the given signals, SIGINT, SIGTERM, and
+// SIGQUIT if none are given, so that entries which are still queued, or
+// buffered by Out or a Sink, aren't lost when the process is killed. When one
+// of the signals arrives a Warn entry recording it is logged, and everything is
+// flushed, as with Flush, waiting at most the given timeout. The handling is
+// then stopped and the signal is sent again, so that whatever would have
+// happened without it, e.g. the process exiting, proceeds.
+//
+// The returned function stops the signal handling, and can be called more than
+// once.
+func HandleFatalSignals(timeout time.Duration, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT}
+	}
+	sigCh := make(chan os.Signal, 1)
+	stopCh := make(chan struct{})
+	signal.Notify(sigCh, sigs...)
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			signal.Stop(sigCh)
+			close(stopCh)
+		})
+	}
+	go func() {
+		select {
+		case sig := <-sigCh:
+			Warn("Received fatal signal, flushing", KV{"signal": sig.String()})
+			flushWithin(timeout)
+			stop()
+			if err := raiseSignal(sig); err != nil {
+				os.Exit(1)
+			}
+		case <-stopCh:
+		}
+	}()
+	return stop
+}
+
+// flushWithin calls Flush, but gives up waiting for it after the timeout
+func flushWithin(timeout time.Duration) {
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		Flush()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-doneCh:
+	case <-timer.C:
+	}
+}
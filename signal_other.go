@@ -0,0 +1,26 @@
+//go:build !unix
+
+package llog
+
+import (
+	"os"
+	"time"
+)
+
+// HandleLevelSignals does nothing, since SIGUSR1 and SIGUSR2 don't exist on this
+// platform
+func HandleLevelSignals() (stop func()) {
+	return func() {}
+}
+
+// HandleReopenSignal does nothing, since SIGUSR1 doesn't exist on this platform
+// and log files aren't usually rotated by renaming them
+func HandleReopenSignal(sigs ...os.Signal) (stop func()) {
+	return func() {}
+}
+
+// HandleFatalSignals does nothing, since signals can't be sent again once
+// they've been handled on this platform
+func HandleFatalSignals(timeout time.Duration, sigs ...os.Signal) (stop func()) {
+	return func() {}
+}
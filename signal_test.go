@@ -0,0 +1,101 @@
+//go:build unix
+
+package llog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleLevelSignals(t *T) {
+	defer SetLevel(InfoLevel)
+	SetLevel(WarnLevel)
+	stop := HandleLevelSignals()
+	defer stop()
+
+	assertSignal := func(sig syscall.Signal, expected Level) {
+		require.NoError(t, syscall.Kill(os.Getpid(), sig))
+		assert.Eventually(t, func() bool {
+			return GetLevel() == expected
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	assertSignal(syscall.SIGUSR1, InfoLevel)
+	assertSignal(syscall.SIGUSR1, DebugLevel)
+	assertSignal(syscall.SIGUSR1, DebugLevel)
+	assertSignal(syscall.SIGUSR2, WarnLevel)
+	assertSignal(syscall.SIGUSR2, WarnLevel)
+
+	stop()
+	stop()
+}
+
+func TestHandleReopenSignal(t *T) {
+	dir := t.TempDir()
+	path, rotated := filepath.Join(dir, "app.log"), filepath.Join(dir, "app.log.1")
+	fw, err := OpenFile(path)
+	require.NoError(t, err)
+	defer fw.Close()
+	stop := HandleReopenSignal()
+	defer stop()
+
+	require.NoError(t, os.Rename(path, rotated))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+}
+
+// bufferedWriter only makes what's written to it visible in flushed once it's
+// been flushed
+type bufferedWriter struct {
+	buf, flushed bytes.Buffer
+}
+
+func (bw *bufferedWriter) Write(b []byte) (int, error) {
+	return bw.buf.Write(b)
+}
+
+func (bw *bufferedWriter) Flush() {
+	bw.buf.WriteTo(&bw.flushed)
+}
+
+func TestHandleFatalSignals(t *T) {
+	raisedCh := make(chan os.Signal, 1)
+	defer func(old func(os.Signal) error) { raiseSignal = old }(raiseSignal)
+	raiseSignal = func(sig os.Signal) error {
+		raisedCh <- sig
+		return nil
+	}
+
+	oldOut := Out
+	defer SetOutput(oldOut)
+	bw := new(bufferedWriter)
+	SetOutput(bw)
+
+	// SIGUSR2 is used so that the test process isn't killed if the signal
+	// somehow isn't handled
+	stop := HandleFatalSignals(time.Second, syscall.SIGUSR2)
+	defer stop()
+
+	Info("foo")
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+	select {
+	case sig := <-raisedCh:
+		assert.Equal(t, syscall.SIGUSR2, sig)
+	case <-time.After(time.Second):
+		t.Fatal("signal wasn't raised again")
+	}
+	assert.Equal(t, "~ INFO -- foo\n~ WARN -- Received fatal signal, flushing -- signal=\"user defined signal 2\"\n", bw.flushed.String())
+
+	stop()
+	stop()
+}
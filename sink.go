@@ -0,0 +1,363 @@
+package llog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Sink is a destination which entries are written to, in addition to Out.
+// Sinks are written to from the same go-routine which writes to Out, after all
+// Processors and Hooks have been run. If writing to a Sink fails the write error
+// handler is called, see OnWriteError.
+//
+// Since every Sink is written to in turn, one which blocks holds up all logging.
+// A Sink which does network IO should do it from a go-routine of its own, as
+// HTTPSink does, or be wrapped in a TimeoutSink.
+//
+// If a Sink has a Flush or Sync method it will be called whenever Flush is.
+type Sink interface {
+	WriteEntry(e Entry) error
+}
+
+var sinks []Sink
+var sinksLock sync.RWMutex
+
+// AddSink registers a Sink which every entry will be written to
+func AddSink(s Sink) {
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+	// copy so that a slice being read by writeSinks is never modified
+	sinks = append(sinks[:len(sinks):len(sinks)], s)
+}
+
+// SetSinks replaces all registered Sinks with the given ones. Calling it with
+// no Sinks removes them all.
+func SetSinks(ss ...Sink) {
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+	sinks = ss
+}
+
+func getSinks() []Sink {
+	sinksLock.RLock()
+	defer sinksLock.RUnlock()
+	return sinks
+}
+
+func (c *core) writeSinks(e Entry, ss []Sink) {
+	for _, s := range ss {
+		if err := writeSink(s, e); err != nil {
+			c.writeError(&WriteError{Sink: s, Err: err}, e)
+		}
+	}
+}
+
+// WriterSink is a Sink which writes entries of at least Level to an io.Writer,
+// using its own Formatter
+type WriterSink struct {
+	Writer io.Writer
+
+	// Formatter defaults to TextFormatter
+	Formatter Formatter
+
+	// Level is the minimum level of entries which will be written
+	Level Level
+
+	DisplayTimestamp bool
+}
+
+// WriteEntry implements the Sink interface
+func (ws WriterSink) WriteEntry(e Entry) error {
+	if e.Level < ws.Level {
+		return nil
+	}
+	f := ws.Formatter
+	if f == nil {
+		f = TextFormatter{}
+	}
+	return f.Format(ws.Writer, e, ws.DisplayTimestamp)
+}
+
+// Flush flushes the underlying Writer, if it has either a Flush or Sync method
+func (ws WriterSink) Flush() {
+	flushWriter(ws.Writer)
+}
+
+// ErrSinkQueueFull is returned by a HTTPSink for entries written while its
+// queue is full
+var ErrSinkQueueFull = errors.New("llog: sink queue is full")
+
+// errHTTPSinkClosed is returned when writing to a closed HTTPSink
+var errHTTPSinkClosed = errors.New("llog: HTTPSink is closed")
+
+// HTTPSink is a Sink which POSTs each entry of at least Level, formatted by its
+// Formatter, to a URL. Any response status other than 2xx is considered an
+// error.
+//
+// Entries are never POSTed from the go-routine which writes them, since a slow
+// endpoint would then block all logging. Instead WriteEntry queues them, and
+// they're POSTed from the HTTPSink's own go-routine. If the queue is full the
+// entry fails with ErrSinkQueueFull, and so goes to the write error handler
+// (see OnWriteError), which by default writes it to Stdout. So do entries which
+// couldn't be POSTed, once they've been retried.
+//
+// A HTTPSink must be used as a pointer, and closed with Close, e.g.
+//
+//	hs := &llog.HTTPSink{URL: url, MaxBatch: 100}
+//	defer hs.Close()
+//	llog.AddSink(hs)
+type HTTPSink struct {
+	URL string
+
+	// Client defaults to an http.Client with a 10 second timeout
+	Client *http.Client
+
+	// Formatter defaults to JSONFormatter
+	Formatter Formatter
+
+	// Level is the minimum level of entries which will be written
+	Level Level
+
+	// QueueSize is the number of entries which can be waiting to be POSTed.
+	// Defaults to 1024
+	QueueSize int
+
+	// MaxBatch is the most entries which are POSTed together, one after
+	// another in the same body, when more than one is waiting. With the
+	// JSONFormatter the body is then newline delimited JSON. Defaults to 1,
+	// i.e. each entry is POSTed on its own
+	MaxBatch int
+
+	// Retries is the maximum number of times a failed POST is retried, with
+	// exponential backoff from 100 milliseconds up to 1 second
+	Retries int
+
+	once    sync.Once
+	queue   chan Entry
+	flushCh chan chan struct{}
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+
+	lock   sync.Mutex
+	closed bool
+}
+
+var defaultHTTPSinkClient = &http.Client{Timeout: 10 * time.Second}
+
+func (hs *HTTPSink) start() {
+	size := hs.QueueSize
+	if size <= 0 {
+		size = 1024
+	}
+	hs.queue = make(chan Entry, size)
+	hs.flushCh = make(chan chan struct{})
+	hs.stopCh = make(chan struct{})
+	hs.doneCh = make(chan struct{})
+	go hs.run()
+}
+
+// WriteEntry implements the Sink interface. It only returns an error if the
+// entry couldn't be queued.
+func (hs *HTTPSink) WriteEntry(e Entry) error {
+	if e.Level < hs.Level {
+		return nil
+	}
+	hs.once.Do(hs.start)
+	hs.lock.Lock()
+	defer hs.lock.Unlock()
+	if hs.closed {
+		return errHTTPSinkClosed
+	}
+	select {
+	case hs.queue <- e:
+		return nil
+	default:
+		return ErrSinkQueueFull
+	}
+}
+
+// Flush waits for the entries which have been queued to be POSTed
+func (hs *HTTPSink) Flush() {
+	hs.once.Do(hs.start)
+	ch := make(chan struct{})
+	select {
+	case hs.flushCh <- ch:
+		<-ch
+	case <-hs.doneCh:
+	}
+}
+
+// Close POSTs the entries which are still queued, and then stops the HTTPSink's
+// go-routine. Entries written after Close is called fail.
+func (hs *HTTPSink) Close() error {
+	hs.once.Do(hs.start)
+	hs.lock.Lock()
+	if hs.closed {
+		hs.lock.Unlock()
+		return errHTTPSinkClosed
+	}
+	hs.closed = true
+	hs.lock.Unlock()
+	close(hs.stopCh)
+	<-hs.doneCh
+	return nil
+}
+
+func (hs *HTTPSink) run() {
+	defer close(hs.doneCh)
+	for {
+		select {
+		case e := <-hs.queue:
+			hs.send(e)
+		case ch := <-hs.flushCh:
+			hs.sendQueued()
+			close(ch)
+		case <-hs.stopCh:
+			hs.sendQueued()
+			return
+		}
+	}
+}
+
+// sendQueued POSTs entries until the queue is empty
+func (hs *HTTPSink) sendQueued() {
+	for len(hs.queue) > 0 {
+		hs.send(<-hs.queue)
+	}
+}
+
+// send POSTs the given entry, along with as many of those queued behind it as
+// fit in a batch, retrying if it fails. If it still fails the error is handled
+// for every one of them.
+func (hs *HTTPSink) send(e Entry) {
+	es := []Entry{e}
+batch:
+	for len(es) < hs.MaxBatch {
+		select {
+		case e := <-hs.queue:
+			es = append(es, e)
+		default:
+			break batch
+		}
+	}
+
+	err := hs.post(es)
+	backoff := 100 * time.Millisecond
+	for i := 0; err != nil && i < hs.Retries; i++ {
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > time.Second {
+			backoff = time.Second
+		}
+		err = hs.post(es)
+	}
+	if err != nil {
+		reportSinkError(hs, err, es)
+	}
+}
+
+func (hs *HTTPSink) post(es []Entry) error {
+	f, contentType := hs.Formatter, "text/plain"
+	if f == nil {
+		f = JSONFormatter{}
+	}
+	if _, ok := f.(JSONFormatter); ok {
+		contentType = "application/json"
+		if hs.MaxBatch > 1 {
+			contentType = "application/x-ndjson"
+		}
+	}
+	buf := new(bytes.Buffer)
+	for _, e := range es {
+		if err := f.Format(buf, e, true); err != nil {
+			return err
+		}
+	}
+
+	client := hs.Client
+	if client == nil {
+		client = defaultHTTPSinkClient
+	}
+	resp, err := client.Post(hs.URL, contentType, buf)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+	return nil
+}
+
+// reportSinkError hands an error which a Sink hit in a go-routine of its own to
+// the global write error handler, for each of the given entries. It's done
+// from yet another go-routine, since the main loop may be waiting on the Sink,
+// e.g. in Close.
+func reportSinkError(s Sink, err error, es []Entry) {
+	go globalCore.apply(func() {
+		for _, e := range es {
+			globalCore.writeError(&WriteError{Sink: s, Err: err}, e)
+		}
+	})
+}
+
+// RetrySink is a Sink which retries writing entries to its underlying Sink if
+// they fail, waiting between each attempt with exponential backoff. This is
+// useful for network backed Sinks, so that a brief outage doesn't cause entries
+// to fall back to Stdout. Since Sinks are written to from the same go-routine
+// as everything else, logging is blocked while retrying, so the total backoff
+// should be kept short and a buffer (see SetBufferSize) may be worthwhile.
+type RetrySink struct {
+	Sink Sink
+
+	// Retries is the maximum number of times a failed entry is retried before
+	// its error is returned
+	Retries int
+
+	// Backoff is how long to wait before the first retry, it doubles for each
+	// subsequent one. Defaults to 100 milliseconds
+	Backoff time.Duration
+
+	// MaxBackoff is the longest to wait between any two attempts. Defaults to
+	// 1 second
+	MaxBackoff time.Duration
+}
+
+// WriteEntry implements the Sink interface
+func (rs RetrySink) WriteEntry(e Entry) error {
+	backoff, maxBackoff := rs.Backoff, rs.MaxBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = time.Second
+	}
+	err := rs.Sink.WriteEntry(e)
+	for i := 0; err != nil && i < rs.Retries; i++ {
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		err = rs.Sink.WriteEntry(e)
+	}
+	return err
+}
+
+// Flush flushes the underlying Sink, if it has either a Flush or Sync method
+func (rs RetrySink) Flush() {
+	flushWriter(rs.Sink)
+}
+
+// Close closes the underlying Sink, if it has a Close method
+func (rs RetrySink) Close() error {
+	if c, ok := rs.Sink.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package llog
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, errors.New("can't write")
+}
+
+func TestSinks(t *T) {
+	oldOut := Out
+	defer func() {
+		Out = oldOut
+		SetSinks()
+	}()
+	Out = nil
+
+	infoBuf, errBuf := new(bytes.Buffer), new(bytes.Buffer)
+	AddSink(WriterSink{Writer: infoBuf})
+	AddSink(WriterSink{Writer: errBuf, Formatter: JSONFormatter{}, Level: ErrorLevel})
+
+	SetLevel(InfoLevel)
+	Debug("foo")
+	Info("bar")
+	Error("baz")
+	Flush()
+	assert.Equal(t, "~ INFO -- bar\n~ ERROR -- baz\n", infoBuf.String())
+	assert.Equal(t, `{"level":"ERROR","msg":"baz"}`+"\n", errBuf.String())
+
+	// if a Sink fails the entry should still be written to the others
+	SetSinks(WriterSink{Writer: errWriter{}}, WriterSink{Writer: infoBuf})
+	infoBuf.Reset()
+	oldDefaultOut := defaultOut
+	defer func() { defaultOut = oldDefaultOut }()
+	fallbackBuf := new(bytes.Buffer)
+	defaultOut = fallbackBuf
+	Info("foo")
+	Flush()
+	assert.Equal(t, "~ INFO -- foo\n", infoBuf.String())
+	assert.Equal(t, "~ ERROR -- Could not write to Sink -- err=\"can't write\"\n~ INFO -- foo\n", fallbackBuf.String())
+}
+
+func TestHTTPSink(t *T) {
+	bodies := make(chan string, 10)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies <- r.Header.Get("Content-Type") + " " + string(b)
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/slow":
+			<-release
+		}
+	}))
+	defer srv.Close()
+
+	e := Entry{Level: InfoLevel, Msg: "foo"}
+	hs := &HTTPSink{URL: srv.URL}
+	require.NoError(t, hs.WriteEntry(e))
+	assert.Regexp(t, `^application/json {"level":"INFO","ts":"[^"]+","msg":"foo"}\n$`, <-bodies)
+	require.NoError(t, hs.Close())
+	assert.Error(t, hs.WriteEntry(e))
+
+	hs = &HTTPSink{URL: srv.URL, Level: WarnLevel}
+	require.NoError(t, hs.WriteEntry(e))
+	hs.Flush()
+	assert.Empty(t, bodies)
+	require.NoError(t, hs.Close())
+
+	// entries which couldn't be POSTed go to the write error handler, once
+	// they've been retried
+	errs := make(chan error, 1)
+	OnWriteError(func(err error, e Entry) { errs <- err })
+	defer OnWriteError(nil)
+	hs = &HTTPSink{URL: srv.URL + "/fail", Retries: 1}
+	require.NoError(t, hs.WriteEntry(e))
+	assert.EqualError(t, <-errs, `could not write to Sink: unexpected response status "503 Service Unavailable"`)
+	assert.Len(t, bodies, 2)
+	<-bodies
+	<-bodies
+	require.NoError(t, hs.Close())
+
+	// a slow endpoint doesn't block WriteEntry, entries are queued behind it
+	// until the queue is full, and then POSTed together
+	hs = &HTTPSink{URL: srv.URL + "/slow", QueueSize: 2, MaxBatch: 10}
+	require.NoError(t, hs.WriteEntry(Entry{Level: InfoLevel, Msg: "a"}))
+	assert.Regexp(t, `^application/x-ndjson {[^}]+"msg":"a"}\n$`, <-bodies)
+	require.NoError(t, hs.WriteEntry(Entry{Level: InfoLevel, Msg: "b"}))
+	require.NoError(t, hs.WriteEntry(Entry{Level: InfoLevel, Msg: "c"}))
+	assert.Equal(t, ErrSinkQueueFull, hs.WriteEntry(Entry{Level: InfoLevel, Msg: "d"}))
+	close(release)
+	hs.Flush()
+	assert.Regexp(t, `^application/x-ndjson {[^}]+"msg":"b"}\n{[^}]+"msg":"c"}\n$`, <-bodies)
+	require.NoError(t, hs.Close())
+}
+
+// flakySink fails the given number of writes before succeeding
+type flakySink struct {
+	fails   int
+	entries []Entry
+}
+
+func (fs *flakySink) WriteEntry(e Entry) error {
+	if fs.fails > 0 {
+		fs.fails--
+		return errors.New("unavailable")
+	}
+	fs.entries = append(fs.entries, e)
+	return nil
+}
+
+func TestRetrySink(t *T) {
+	fs := &flakySink{fails: 2}
+	rs := RetrySink{Sink: fs, Retries: 2, Backoff: time.Millisecond}
+	assert.NoError(t, rs.WriteEntry(Entry{Msg: "foo"}))
+	require.Len(t, fs.entries, 1)
+	assert.Equal(t, "foo", fs.entries[0].Msg)
+
+	fs.fails = 3
+	assert.EqualError(t, rs.WriteEntry(Entry{Msg: "bar"}), "unavailable")
+	assert.Len(t, fs.entries, 1)
+	assert.Zero(t, fs.fails)
+
+	s, err := OutputConfig{Type: "http", URL: "http://localhost", Retries: 3}.sink(nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, &HTTPSink{URL: "http://localhost", Formatter: JSONFormatter{}, Retries: 3}, s)
+}
@@ -0,0 +1,172 @@
+package llog
+
+import (
+	"encoding/json"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// StackTraceOptions describe which entries should have a stack trace captured
+// and attached to them, and what the traces should look like
+type StackTraceOptions struct {
+	// Level is the minimum level an entry must be to have a stack trace
+	// attached to it
+	Level Level
+
+	// Depth is the maximum number of frames a stack trace will have. Defaults
+	// to 32
+	Depth int
+
+	// Skip is the number of frames to skip from the top of the stack, in
+	// addition to the ones within this package. This is useful when logging
+	// from within wrapper functions
+	Skip int
+}
+
+var stackTraceOpts *StackTraceOptions
+var stackTraceOptsLock sync.RWMutex
+
+// SetStackTraces enables capturing a stack trace for every entry of at least
+// the given Level, as described by the given StackTraceOptions. The Stack is
+// attached to the entry under the key "stack". Passing nil disables stack
+// traces, which is the default.
+//
+// Capturing a stack trace has a cost, so this is generally only worth doing for
+// Error and Fatal entries.
+func SetStackTraces(o *StackTraceOptions) {
+	if o != nil {
+		oCopy := *o
+		if oCopy.Depth <= 0 {
+			oCopy.Depth = 32
+		}
+		o = &oCopy
+	}
+	stackTraceOptsLock.Lock()
+	defer stackTraceOptsLock.Unlock()
+	stackTraceOpts = o
+}
+
+// Stack is a stack trace captured at the point an entry was logged, from the
+// caller of the log function outwards. The TextFormatter writes it as an
+// indented block following the entry's line, while the JSONFormatter writes it
+// as an array of "function file:line" strings.
+type Stack []runtime.Frame
+
+// String returns the Stack in the same format as a go panic does, with one
+// line for each frame's function, followed by an indented line with its file
+// and line number
+func (s Stack) String() string {
+	sb := new(strings.Builder)
+	for i, f := range s {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(f.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(f.File)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(f.Line))
+	}
+	return sb.String()
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (s Stack) MarshalJSON() ([]byte, error) {
+	strs := make([]string, len(s))
+	for i, f := range s {
+		strs[i] = f.Function + " " + f.File + ":" + strconv.Itoa(f.Line)
+	}
+	return json.Marshal(strs)
+}
+
+// pkgPrefix is the prefix of the names of all functions in this package, used
+// to skip over them when capturing stack traces
+var pkgPrefix = reflect.TypeOf(Stack(nil)).PkgPath() + "."
+
+func captureStack(l Level) Stack {
+	stackTraceOptsLock.RLock()
+	o := stackTraceOpts
+	stackTraceOptsLock.RUnlock()
+	if o == nil || l < o.Level {
+		return nil
+	}
+	return callers(o.Skip, o.Depth)
+}
+
+// callers returns up to depth frames of the current go-routine's stack, starting
+// from the first frame outside of this package, after skipping skip frames
+func callers(skip, depth int) Stack {
+	// there's no telling how many frames are in this package, so leave plenty
+	// of room
+	pcs := make([]uintptr, depth+skip+16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	stack := make(Stack, 0, depth)
+	inPkg := true
+	for len(stack) < depth {
+		f, more := frames.Next()
+		switch {
+		case inPkg && strings.HasPrefix(f.Function, pkgPrefix) && !strings.HasSuffix(f.File, "_test.go"):
+			// still within llog
+		case skip > 0:
+			inPkg = false
+			skip--
+		default:
+			inPkg = false
+			stack = append(stack, f)
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// callerLevels is a bitmask of the levels which have caller annotation enabled
+var callerLevels uint32
+
+// SetCallerLevels enables annotating entries of the given levels with the
+// location they were logged from, under the key "caller". The value looks
+// like "llhttp/llhttp.go:98 llhttp.Middleware.Wrap.func1", giving the file
+// (and its directory), line number and function of the call. Calling with no
+// levels disables caller annotation, which is the default.
+//
+// Finding the caller has a cost, so it's only done for entries of the enabled
+// levels, e.g. only WarnLevel and above.
+func SetCallerLevels(lvls ...Level) {
+	var mask uint32
+	for _, l := range lvls {
+		mask |= 1 << uint(l)
+	}
+	atomic.StoreUint32(&callerLevels, mask)
+}
+
+func captureCaller(l Level) (string, bool) {
+	if atomic.LoadUint32(&callerLevels)&(1<<uint(l)) == 0 {
+		return "", false
+	}
+	stack := callers(0, 1)
+	if len(stack) == 0 {
+		return "", false
+	}
+	return shortCaller(stack[0]), true
+}
+
+// shortCaller returns the frame's file with only its last directory, its line,
+// and the function name without its package path
+func shortCaller(f runtime.Frame) string {
+	file := f.File
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+	fn := f.Function
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	return file + ":" + strconv.Itoa(f.Line) + " " + fn
+}
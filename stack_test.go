@@ -0,0 +1,94 @@
+package llog
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStackTraces(t *T) {
+	defer SetStackTraces(nil)
+
+	assert.Nil(t, captureStack(FatalLevel))
+
+	SetStackTraces(&StackTraceOptions{Level: ErrorLevel})
+	assert.Nil(t, captureStack(WarnLevel))
+	stack := captureStack(ErrorLevel)
+	require.NotEmpty(t, stack)
+	assert.Equal(t, "github.com/levenlabs/go-llog.TestStackTraces", stack[0].Function)
+	assert.True(t, strings.HasSuffix(stack[0].File, "stack_test.go"))
+
+	SetStackTraces(&StackTraceOptions{Level: ErrorLevel, Depth: 1, Skip: 1})
+	stack = captureStack(ErrorLevel)
+	require.Len(t, stack, 1)
+	assert.Equal(t, "testing.tRunner", stack[0].Function)
+}
+
+func TestStackFormat(t *T) {
+	stack := Stack{
+		{Function: "main.foo", File: "/src/main.go", Line: 12},
+		{Function: "main.main", File: "/src/main.go", Line: 4},
+	}
+	assert.Equal(t, "main.foo\n\t/src/main.go:12\nmain.main\n\t/src/main.go:4", stack.String())
+
+	e := Entry{Level: ErrorLevel, Msg: "foo", KV: KV{"a": "a", "stack": stack}}
+	buf := new(bytes.Buffer)
+	require.NoError(t, TextFormatter{}.Format(buf, e, false))
+	assert.Equal(t, "~ ERROR -- foo -- a=\"a\"\n"+
+		"\tmain.foo\n\t\t/src/main.go:12\n\tmain.main\n\t\t/src/main.go:4\n", buf.String())
+	assert.Contains(t, e.KV, "stack")
+
+	buf.Reset()
+	require.NoError(t, JSONFormatter{}.Format(buf, e, false))
+	assert.Equal(t, `{"level":"ERROR","msg":"foo","a":"a","stack":["main.foo /src/main.go:12","main.main /src/main.go:4"]}`+"\n", buf.String())
+}
+
+func TestStackLogged(t *T) {
+	oldOut := Out
+	defer func() {
+		Out = oldOut
+		SetStackTraces(nil)
+	}()
+	buf := bytes.NewBuffer(make([]byte, 0, 128))
+	Out = buf
+
+	SetStackTraces(&StackTraceOptions{Level: ErrorLevel})
+	SetLevel(InfoLevel)
+	With(KV{"a": "a"}).Error("foo")
+	_, _, line, _ := runtime.Caller(0)
+	Flush()
+	lines := strings.Split(buf.String(), "\n")
+	assert.Equal(t, "~ ERROR -- foo -- a=\"a\"", lines[0])
+	assert.Equal(t, "\tgithub.com/levenlabs/go-llog.TestStackLogged", lines[1])
+	assert.True(t, strings.HasSuffix(lines[2], "stack_test.go:"+strconv.Itoa(line-1)), lines[2])
+}
+
+func TestCaller(t *T) {
+	oldOut := Out
+	defer func() {
+		Out = oldOut
+		SetCallerLevels()
+	}()
+	buf := bytes.NewBuffer(make([]byte, 0, 128))
+	Out = buf
+
+	SetCallerLevels(WarnLevel, ErrorLevel)
+	SetLevel(InfoLevel)
+	Info("foo")
+	Warn("bar")
+	_, file, line, _ := runtime.Caller(0)
+	With(KV{"a": "a"}).Error("baz")
+	Flush()
+
+	caller := filepath.Base(filepath.Dir(file)) + "/stack_test.go:"
+	assert.Equal(t, "~ INFO -- foo\n"+
+		"~ WARN -- bar -- caller=\""+caller+strconv.Itoa(line-1)+" go-llog.TestCaller\"\n"+
+		"~ ERROR -- baz -- a=\"a\" caller=\""+caller+strconv.Itoa(line+1)+" go-llog.TestCaller\"\n",
+		buf.String())
+}
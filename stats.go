@@ -0,0 +1,78 @@
+package llog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// coreStats are the counters kept by a core
+type coreStats struct {
+	written     [FatalLevel + 1]atomic.Uint64
+	dropped     atomic.Uint64
+	writeErrors atomic.Uint64
+
+	// lastWriteError holds a *lastWriteError
+	lastWriteError atomic.Value
+}
+
+type lastWriteError struct {
+	err error
+	t   time.Time
+}
+
+// LoggerStats describes the health of the go-routine which writes entries, see
+// Stats
+type LoggerStats struct {
+	// Written is the number of entries which have been written, by level. This
+	// includes entries which couldn't be written to Out or a Sink.
+	Written map[Level]uint64
+
+	// Dropped is the number of entries which were logged but never written,
+	// e.g. because they were logged after Close. Entries which are filtered
+	// out by level or by a Processor aren't counted.
+	Dropped uint64
+
+	// WriteErrors is the number of times an entry couldn't be written to Out
+	// or to a Sink
+	WriteErrors uint64
+
+	// LastWriteError is the most recent error from writing to Out or a Sink,
+	// and LastWriteErrorTime is when it happened. Both are zero if there
+	// hasn't been one.
+	LastWriteError     error
+	LastWriteErrorTime time.Time
+
+	// QueueDepth and QueueSize are as returned by QueueDepth
+	QueueDepth, QueueSize int
+}
+
+// Stats returns the current LoggerStats for entries logged using the
+// package-level functions, and Loggers not created by New
+func Stats() LoggerStats {
+	return globalCore.stats()
+}
+
+func (c *core) stats() LoggerStats {
+	s := LoggerStats{Written: make(map[Level]uint64, len(c.counters.written))}
+	for l := range c.counters.written {
+		s.Written[Level(l)] = c.counters.written[l].Load()
+	}
+	s.Dropped = c.counters.dropped.Load()
+	s.WriteErrors = c.counters.writeErrors.Load()
+	if lwe, _ := c.counters.lastWriteError.Load().(*lastWriteError); lwe != nil {
+		s.LastWriteError, s.LastWriteErrorTime = lwe.err, lwe.t
+	}
+	s.QueueDepth, s.QueueSize = c.queueDepth()
+	return s
+}
+
+func (c *core) countWritten(l Level) {
+	if l >= 0 && int(l) < len(c.counters.written) {
+		c.counters.written[l].Add(1)
+	}
+}
+
+func (c *core) countWriteError(err error) {
+	c.counters.writeErrors.Add(1)
+	c.counters.lastWriteError.Store(&lastWriteError{err: err, t: time.Now()})
+}
@@ -0,0 +1,39 @@
+package llog
+
+import (
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *T) {
+	l := New(
+		WithOutput(errWriter{}),
+		WithLevel(InfoLevel),
+		WithWriteErrorHandler(IgnoreWriteErrors),
+	)
+	l.Debug("foo")
+	l.Info("foo")
+	l.WithProcessors(func(e Entry) (Entry, bool) { return e, false }).Info("filtered")
+	l.Warn("foo")
+	l.Warn("foo")
+	l.Close()
+	l.Error("foo")
+
+	s := l.Stats()
+	assert.Error(t, s.LastWriteError)
+	assert.False(t, s.LastWriteErrorTime.IsZero())
+	s.LastWriteError, s.LastWriteErrorTime = nil, time.Time{}
+	assert.Equal(t, LoggerStats{
+		Written: map[Level]uint64{
+			DebugLevel: 0,
+			InfoLevel:  1,
+			WarnLevel:  2,
+			ErrorLevel: 0,
+			FatalLevel: 0,
+		},
+		Dropped:     1,
+		WriteErrors: 3,
+	}, s)
+}
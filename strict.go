@@ -0,0 +1,87 @@
+package llog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// maxStrictEntrySize is the largest an entry can be, encoded as JSON, and
+// still pass strict validation
+const maxStrictEntrySize = 64 << 10
+
+var strict int32
+
+// SetStrict sets whether every entry is validated as it's logged, to catch
+// logging bugs during development and in tests, before they reach production
+// pipelines. An entry fails validation if:
+//
+//   - any of its values can't be encoded as JSON, e.g. channels, functions,
+//     NaNs, or values whose MarshalJSON method fails
+//   - any of its keys collide with the fields llog, or the output's Formatter,
+//     writes itself, see SetReservedKeyPolicy
+//   - it's over 64KiB when encoded as JSON
+//
+// An entry which fails is replaced by an Error entry listing its problems,
+// and where it was logged from:
+//
+//	~ ERROR -- Invalid log entry -- caller="app/main.go:12 main.main" entryMsg="foo" problems="[key \"msg\" is reserved]"
+//
+// It's off by default, and can also be turned on with LLOG_STRICT, see
+// ConfigureFromEnv.
+func SetStrict(on bool) {
+	var i int32
+	if on {
+		i = 1
+	}
+	atomic.StoreInt32(&strict, i)
+}
+
+// validateEntry returns the problems with the entry, if strict validation is
+// on, given the keys reserved by its output. It must be called from the log
+// call, before the ReservedKeyPolicy is applied.
+func validateEntry(msg string, kv KV, reserved []string) []string {
+	if atomic.LoadInt32(&strict) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	for _, k := range reserved {
+		if _, ok := kv[k]; ok {
+			problems = append(problems, reservedKeyProblem(k))
+		}
+	}
+	size := len(msg)
+	for _, k := range keys {
+		size += len(k)
+		if _, ok := kv[k].(Lazy); ok {
+			// only resolved once it's being written
+			continue
+		}
+		b, err := json.Marshal(jsonValue(kv[k]))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("value of %q can't be encoded: %v", k, err))
+		}
+		size += len(b)
+	}
+	if size > maxStrictEntrySize {
+		problems = append(problems, fmt.Sprintf("entry is %d bytes, over the limit of %d", size, maxStrictEntrySize))
+	}
+	return problems
+}
+
+// invalidEntryKV returns the KV of the entry which replaces one which failed
+// validation. It must be called from the log call.
+func invalidEntryKV(msg string, problems []string) KV {
+	kv := KV{"entryMsg": msg, "problems": problems}
+	if stack := callers(0, 1); len(stack) > 0 {
+		kv["caller"] = shortCaller(stack[0])
+	}
+	return kv
+}
@@ -0,0 +1,52 @@
+package llog
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type errJSON struct{}
+
+func (errJSON) MarshalJSON() ([]byte, error) { return nil, errors.New("nope") }
+
+func TestStrict(t *T) {
+	SetStrict(true)
+	defer SetStrict(false)
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithSynchronous(true), WithFormatter(JSONFormatter{}))
+	defer l.Close()
+
+	l.Info("fine", KV{"a": 1, "b": KV{"c": "d"}, "err": errors.New("e"), "lazy": Lazy(func() interface{} { return 1 })})
+	assert.Equal(t, `{"level":"INFO","msg":"fine","a":1,"b":{"c":"d"},"err":"e","lazy":1}`+"\n", buf.String())
+
+	buf.Reset()
+	l.Debug("ignored", KV{"ch": make(chan int)})
+	assert.Empty(t, buf.String())
+
+	l.Info("foo", KV{"ch": make(chan int), "nan": math.NaN(), "json": errJSON{}, "msg": "bar"})
+	assert.Regexp(t, `^{"level":"ERROR","msg":"Invalid log entry","caller":"[^/"]+/strict_test.go:\d+ go-llog.TestStrict","entryMsg":"foo","problems":\[`+
+		`"key \\"msg\\" is reserved",`+
+		`"value of \\"ch\\" can't be encoded: json: unsupported type: chan int",`+
+		`"value of \\"json\\" can't be encoded: json: error calling MarshalJSON for type \*?llog.errJSON: nope",`+
+		`"value of \\"nan\\" can't be encoded: json: unsupported value: NaN"\]}`+"\n$", buf.String())
+
+	buf.Reset()
+	l.Info("big", KV{"body": strings.Repeat("a", maxStrictEntrySize)})
+	assert.Contains(t, buf.String(), `"problems":["entry is 65545 bytes, over the limit of 65536"]`)
+
+	// the TextFormatter doesn't reserve "msg"
+	buf.Reset()
+	tl := New(WithOutput(buf), WithSynchronous(true))
+	tl.Info("foo", KV{"msg": "bar"})
+	assert.Equal(t, "~ INFO -- foo -- msg=\"bar\"\n", buf.String())
+
+	SetStrict(false)
+	buf.Reset()
+	l.Info("foo", KV{"msg": "bar"})
+	assert.Equal(t, `{"level":"INFO","msg":"foo","msg":"bar"}`+"\n", buf.String())
+}
@@ -0,0 +1,125 @@
+package llog
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// structField describes how a single field of a struct is converted by Fields
+type structField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+	redact    bool
+}
+
+// structFieldsCache maps reflect.Type to []structField
+var structFieldsCache sync.Map
+
+// Fields converts a struct, or a pointer to one, into a KV with a key for each
+// of its exported fields. Field names can be changed using an llog struct tag,
+// which also takes options after the name:
+//
+//	type User struct {
+//		ID       int    `llog:"userID"`
+//		Email    string `llog:"email,omitempty"`
+//		Password string `llog:"-"`
+//		Token    string `llog:"token,redact"`
+//	}
+//
+// A name of "-" excludes the field. The omitempty option excludes the field if
+// it has its zero value, and the redact option replaces its value with
+// Redacted. Fields of embedded structs are included as if they were fields of
+// the outer struct. Returns empty KV for anything other than a non-nil struct.
+func Fields(v interface{}) KV {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return KV{}
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return KV{}
+	}
+
+	sfs := structFields(rv.Type())
+	kv := make(KV, len(sfs))
+	for _, sf := range sfs {
+		fv, ok := fieldByIndex(rv, sf.index)
+		if !ok || (sf.omitEmpty && fv.IsZero()) {
+			continue
+		}
+		if sf.redact {
+			kv[sf.name] = Redacted
+		} else {
+			kv[sf.name] = fv.Interface()
+		}
+	}
+	return kv
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but returns false rather
+// than panicking if it would have to go through a nil embedded pointer
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+func structFields(rt reflect.Type) []structField {
+	if sfs, ok := structFieldsCache.Load(rt); ok {
+		return sfs.([]structField)
+	}
+	sfs := appendStructFields(nil, rt, nil)
+	structFieldsCache.Store(rt, sfs)
+	return sfs
+}
+
+func appendStructFields(sfs []structField, rt reflect.Type, index []int) []structField {
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		fIndex := append(index[:len(index):len(index)], i)
+		tag := strings.Split(f.Tag.Get("llog"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+
+		if f.Anonymous && tag[0] == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				sfs = appendStructFields(sfs, ft, fIndex)
+				continue
+			}
+		}
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+
+		sf := structField{index: fIndex, name: f.Name}
+		if tag[0] != "" {
+			sf.name = tag[0]
+		}
+		for _, opt := range tag[1:] {
+			switch opt {
+			case "omitempty":
+				sf.omitEmpty = true
+			case "redact":
+				sf.redact = true
+			}
+		}
+		sfs = append(sfs, sf)
+	}
+	return sfs
+}
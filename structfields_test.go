@@ -0,0 +1,51 @@
+package llog
+
+import (
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fieldsInner struct {
+	Region string `llog:"region"`
+}
+
+type fieldsUser struct {
+	fieldsInner
+	ID       int `llog:"userID"`
+	Name     string
+	Email    string `llog:"email,omitempty"`
+	Password string `llog:"-"`
+	Token    string `llog:"token,redact"`
+	secret   string
+}
+
+func TestStructFields(t *T) {
+	u := fieldsUser{
+		fieldsInner: fieldsInner{Region: "us"},
+		ID:          1,
+		Name:        "bob",
+		Password:    "hunter2",
+		Token:       "abc",
+		secret:      "shh",
+	}
+	expected := KV{"region": "us", "userID": 1, "Name": "bob", "token": Redacted}
+	assert.Equal(t, expected, Fields(u))
+	assert.Equal(t, expected, Fields(&u))
+
+	u.Email = "bob@example.com"
+	expected["email"] = "bob@example.com"
+	assert.Equal(t, expected, Fields(u))
+
+	// embedded pointers which are nil are skipped
+	type withPtr struct {
+		*fieldsInner
+		A int
+	}
+	assert.Equal(t, KV{"A": 1}, Fields(withPtr{A: 1}))
+	assert.Equal(t, KV{"A": 1, "region": "eu"}, Fields(withPtr{fieldsInner: &fieldsInner{Region: "eu"}, A: 1}))
+
+	assert.Equal(t, KV{}, Fields(nil))
+	assert.Equal(t, KV{}, Fields((*fieldsUser)(nil)))
+	assert.Equal(t, KV{}, Fields("foo"))
+}
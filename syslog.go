@@ -0,0 +1,95 @@
+//go:build !windows && !plan9
+
+package llog
+
+import (
+	"bytes"
+	"fmt"
+	"log/syslog"
+	"strings"
+)
+
+type syslogSink struct {
+	w          *syslog.Writer
+	f          Formatter
+	level      Level
+	severities [FatalLevel + 1]func(*syslog.Writer, string) error
+}
+
+// syslogSeverities are the methods of syslog.Writer which write with each
+// syslog severity, by name
+var syslogSeverities = map[string]func(*syslog.Writer, string) error{
+	"emerg":   (*syslog.Writer).Emerg,
+	"alert":   (*syslog.Writer).Alert,
+	"crit":    (*syslog.Writer).Crit,
+	"err":     (*syslog.Writer).Err,
+	"warning": (*syslog.Writer).Warning,
+	"notice":  (*syslog.Writer).Notice,
+	"info":    (*syslog.Writer).Info,
+	"debug":   (*syslog.Writer).Debug,
+}
+
+// NewSyslogSink returns a Sink which writes entries of at least the given level
+// to syslog, using the given Formatter (which defaults to TextFormatter if
+// nil). Timestamps are never written, since syslog adds its own. Each entry is
+// written with the syslog severity matching its level. See syslog.Dial for the
+// meaning of network, raddr, and tag.
+func NewSyslogSink(network, raddr, tag string, lvl Level, f Formatter) (Sink, error) {
+	return NewSyslogSinkWithSeverities(network, raddr, tag, lvl, f, nil)
+}
+
+// NewSyslogSinkWithSeverities is like NewSyslogSink, but overrides the syslog
+// severities which entries of some levels are written with. severities maps
+// levels to the names of syslog severities: "emerg", "alert", "crit", "err",
+// "warning", "notice", "info", or "debug", e.g. {WarnLevel: "notice"}.
+func NewSyslogSinkWithSeverities(network, raddr, tag string, lvl Level, f Formatter, severities map[Level]string) (Sink, error) {
+	ss := syslogSink{
+		f:     f,
+		level: lvl,
+		severities: [...]func(*syslog.Writer, string) error{
+			DebugLevel: (*syslog.Writer).Debug,
+			InfoLevel:  (*syslog.Writer).Info,
+			WarnLevel:  (*syslog.Writer).Warning,
+			ErrorLevel: (*syslog.Writer).Err,
+			FatalLevel: (*syslog.Writer).Crit,
+		},
+	}
+	for l, name := range severities {
+		fn, ok := syslogSeverities[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown syslog severity %q", name)
+		} else if l < 0 || l > FatalLevel {
+			return nil, fmt.Errorf("unknown log level %q", l)
+		}
+		ss.severities[l] = fn
+	}
+	if ss.f == nil {
+		ss.f = TextFormatter{}
+	}
+
+	var err error
+	if ss.w, err = syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_USER, tag); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// WriteEntry implements the Sink interface
+func (ss syslogSink) WriteEntry(e Entry) error {
+	if e.Level < ss.level {
+		return nil
+	}
+	buf := new(bytes.Buffer)
+	if err := ss.f.Format(buf, e, false); err != nil {
+		return err
+	}
+	if e.Level < 0 || e.Level > FatalLevel {
+		return ss.w.Crit(buf.String())
+	}
+	return ss.severities[e.Level](ss.w, buf.String())
+}
+
+// Close closes the connection to syslog
+func (ss syslogSink) Close() error {
+	return ss.w.Close()
+}
@@ -0,0 +1,17 @@
+//go:build windows || plan9
+
+package llog
+
+import "errors"
+
+// NewSyslogSink always returns an error, since syslog isn't supported on this
+// platform
+func NewSyslogSink(network, raddr, tag string, lvl Level, f Formatter) (Sink, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
+
+// NewSyslogSinkWithSeverities always returns an error, since syslog isn't
+// supported on this platform
+func NewSyslogSinkWithSeverities(network, raddr, tag string, lvl Level, f Formatter, severities map[Level]string) (Sink, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
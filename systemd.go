@@ -0,0 +1,76 @@
+package llog
+
+import (
+	"bytes"
+	"io"
+)
+
+// systemdPriorities are the sd-daemon priorities of each level, the same as the
+// syslog severities the syslog Sink uses by default
+var systemdPriorities = [...]byte{
+	DebugLevel: '7',
+	InfoLevel:  '6',
+	WarnLevel:  '4',
+	ErrorLevel: '3',
+	FatalLevel: '2',
+}
+
+// SystemdFormatter prefixes every line written by another Formatter with the
+// sd-daemon priority of the entry's level, e.g. "<4>" for WarnLevel, so that
+// journald records the correct priority for the stdout of a service run under
+// systemd:
+//
+//	<3>~ ERROR -- an error happened -- err="some error"
+//
+// Levels are mapped to priorities the same way as they are to syslog
+// severities, see NewSyslogSink.
+type SystemdFormatter struct {
+	// Formatter is used to format the entry before it's prefixed. Defaults to
+	// TextFormatter.
+	Formatter Formatter
+}
+
+// Format implements the Formatter interface. The whole entry is written with a
+// single Write call.
+func (sf SystemdFormatter) Format(w io.Writer, e Entry, displayTS bool) error {
+	f := sf.Formatter
+	if f == nil {
+		f = TextFormatter{}
+	}
+	inner := new(bytes.Buffer)
+	if err := f.Format(inner, e, displayTS); err != nil {
+		return err
+	}
+
+	prio := systemdPriorities[FatalLevel]
+	if e.Level >= 0 && e.Level <= FatalLevel {
+		prio = systemdPriorities[e.Level]
+	}
+	bufp := getBuf()
+	defer putBuf(bufp)
+	buf := *bufp
+	for b := inner.Bytes(); len(b) > 0; {
+		line := b
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			line, b = b[:i+1], b[i+1:]
+		} else {
+			b = nil
+		}
+		buf = append(buf, '<', prio, '>')
+		buf = append(buf, line...)
+	}
+
+	*bufp = buf
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReservedKeys implements the ReservedKeyer interface. Since entries' KV are
+// only written within what the wrapped Formatter writes, those are the wrapped
+// Formatter's reserved keys, if any.
+func (sf SystemdFormatter) ReservedKeys() []string {
+	if rk, ok := sf.Formatter.(ReservedKeyer); ok {
+		return rk.ReservedKeys()
+	}
+	return nil
+}
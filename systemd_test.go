@@ -0,0 +1,30 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemdFormatter(t *T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, SystemdFormatter{}.Format(buf, Entry{Level: WarnLevel, Msg: "foo"}, false))
+	assert.Equal(t, "<4>~ WARN -- foo\n", buf.String())
+
+	// every line of the wrapped Formatter's output is prefixed
+	buf.Reset()
+	sf := SystemdFormatter{Formatter: TextFormatter{Multiline: MultilineBlock}}
+	e := Entry{Level: ErrorLevel, Msg: "foo", KV: KV{"sql": "SELECT *\nFROM users"}}
+	require.NoError(t, sf.Format(buf, e, false))
+	assert.Equal(t, "<3>~ ERROR -- foo\n<3>\tsql:\n<3>\t\tSELECT *\n<3>\t\tFROM users\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, sf.Format(buf, Entry{Level: FatalLevel + 1, Msg: "foo"}, false))
+	assert.Equal(t, "<2>~ unknown level -- foo\n", buf.String())
+
+	f, err := parseFormat("systemd")
+	require.NoError(t, err)
+	assert.Equal(t, SystemdFormatter{}, f)
+}
@@ -0,0 +1,86 @@
+package llog
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// MsgTemplateKey is the key which the original message of an entry is kept
+// under when message templates are enabled, see SetMessageTemplates
+const MsgTemplateKey = "msgTemplate"
+
+var msgTemplates uint32
+
+// SetMessageTemplates sets whether {key} placeholders in messages are replaced
+// by the values of those keys when entries are written. The original message is
+// kept under MsgTemplateKey, so that entries can still be aggregated by it. For
+// example with templates enabled
+//
+//	llog.Info("user {user} logged in", llog.KV{"user": "bob"})
+//
+// writes
+//
+//	~ INFO -- user bob logged in -- msgTemplate="user {user} logged in" user="bob"
+//
+// Placeholders for keys which the entry doesn't have are left as they are.
+// Values are interpolated after redaction, so redacted values stay redacted.
+func SetMessageTemplates(on bool) {
+	var v uint32
+	if on {
+		v = 1
+	}
+	atomic.StoreUint32(&msgTemplates, v)
+}
+
+// interpolateEntry replaces the placeholders in the entry's message, if message
+// templates are enabled
+func interpolateEntry(e Entry) Entry {
+	if atomic.LoadUint32(&msgTemplates) == 0 || !strings.Contains(e.Msg, "{") {
+		return e
+	}
+
+	var b strings.Builder
+	var replaced bool
+	msg := e.Msg
+	for {
+		i := strings.IndexByte(msg, '{')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(msg[i:], '}')
+		if j < 0 {
+			break
+		}
+		j += i
+		v, ok := e.KV[msg[i+1:j]]
+		if !ok {
+			b.WriteString(msg[:i+1])
+			msg = msg[i+1:]
+			continue
+		}
+		b.WriteString(msg[:i])
+		b.WriteString(interpolatedValue(v))
+		msg = msg[j+1:]
+		replaced = true
+	}
+	if !replaced {
+		return e
+	}
+	b.WriteString(msg)
+	e.KV[MsgTemplateKey] = e.Msg
+	e.Msg = b.String()
+	return e
+}
+
+func interpolatedValue(v interface{}) string {
+	switch vv := v.(type) {
+	case string:
+		return vv
+	case error:
+		if vv != nil {
+			return errorString(vv)
+		}
+	}
+	return fmt.Sprint(formatValue(resolveMarshalers(v)))
+}
@@ -0,0 +1,48 @@
+package llog
+
+import (
+	"bytes"
+	"errors"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolateEntry(t *T) {
+	e := Entry{Msg: "user {user} logged in", KV: KV{"user": "bob"}}
+	assert.Equal(t, e, interpolateEntry(e))
+
+	SetMessageTemplates(true)
+	defer SetMessageTemplates(false)
+
+	assertInterpolated := func(msg string, kv KV, expected string) {
+		e := interpolateEntry(Entry{Msg: msg, KV: kv.Copy()})
+		assert.Equal(t, expected, e.Msg)
+		if expected == msg {
+			assert.NotContains(t, e.KV, MsgTemplateKey)
+		} else {
+			assert.Equal(t, msg, e.KV[MsgTemplateKey])
+		}
+	}
+	assertInterpolated("no placeholders", KV{"a": 1}, "no placeholders")
+	assertInterpolated("{missing} {", KV{"a": 1}, "{missing} {")
+	assertInterpolated("{a}+{a}={b}", KV{"a": 1, "b": 2}, "1+1=2")
+	assertInterpolated("{{a}} {b", KV{"a": 1}, "{1} {b")
+	assertInterpolated("failed: {err} after {took}", KV{
+		"err":  errors.New("boom"),
+		"took": time.Second,
+	}, "failed: boom after 1s")
+}
+
+func TestMessageTemplates(t *T) {
+	SetMessageTemplates(true)
+	defer SetMessageTemplates(false)
+
+	buf := new(bytes.Buffer)
+	l := New(WithOutput(buf), WithSynchronous(true))
+	l.Info("user {user} logged in with {password}", KV{"user": "bob", "password": "hunter2"})
+	assert.Equal(t,
+		"~ INFO -- user bob logged in with [REDACTED] -- msgTemplate=\"user {user} logged in with {password}\" password=\"[REDACTED]\" user=\"bob\"\n",
+		buf.String())
+}
@@ -0,0 +1,86 @@
+package llog
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSinkTimeout is returned by a TimeoutSink when a write takes longer than
+// its timeout
+var ErrSinkTimeout = errors.New("llog: sink write timed out")
+
+// ErrSinkUnhealthy is returned by a TimeoutSink for every entry written while
+// a write which timed out still hasn't returned
+var ErrSinkUnhealthy = errors.New("llog: sink is unhealthy")
+
+// TimeoutSink is a Sink which bounds how long writing an entry to another Sink
+// can take, so that a hung collector can't block logging forever. Since Sinks
+// are written to from the same go-routine as Out, a network Sink whose peer
+// stops reading would otherwise stall every log call once the buffer is full.
+//
+// A write which takes longer than the timeout fails with ErrSinkTimeout, so
+// the entry goes to the write error handler (see OnWriteError), which by
+// default writes it to Stdout. The TimeoutSink is then unhealthy until the
+// write eventually returns, and every entry written in the meantime fails
+// immediately with ErrSinkUnhealthy rather than waiting too.
+type TimeoutSink struct {
+	sink    Sink
+	timeout time.Duration
+
+	// unhealthy is set while a write which timed out is still outstanding
+	unhealthy atomic.Bool
+}
+
+// NewTimeoutSink returns a TimeoutSink which fails writes to the given Sink
+// which take longer than timeout
+func NewTimeoutSink(s Sink, timeout time.Duration) *TimeoutSink {
+	return &TimeoutSink{sink: s, timeout: timeout}
+}
+
+// WriteEntry implements the Sink interface
+func (ts *TimeoutSink) WriteEntry(e Entry) error {
+	if ts.unhealthy.Load() {
+		return ErrSinkUnhealthy
+	}
+	errCh := make(chan error, 1)
+	ts.unhealthy.Store(true)
+	go func() {
+		err := ts.sink.WriteEntry(e)
+		// cleared before the result is sent, so that a write which doesn't
+		// time out leaves the TimeoutSink healthy
+		ts.unhealthy.Store(false)
+		errCh <- err
+	}()
+	timer := time.NewTimer(ts.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-errCh:
+		return err
+	case <-timer.C:
+		return ErrSinkTimeout
+	}
+}
+
+// Healthy returns false while a write which timed out hasn't yet returned
+func (ts *TimeoutSink) Healthy() bool {
+	return !ts.unhealthy.Load()
+}
+
+// Flush flushes the underlying Sink, if it has either a Flush or Sync method,
+// unless it's unhealthy
+func (ts *TimeoutSink) Flush() {
+	if ts.Healthy() {
+		flushWriter(ts.sink)
+	}
+}
+
+// Close closes the underlying Sink, if it has a Close method. It's called even
+// if the Sink is unhealthy, since closing it may unblock the hung write.
+func (ts *TimeoutSink) Close() error {
+	if c, ok := ts.sink.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingSink blocks every write until unblockCh is closed
+type blockingSink struct {
+	unblockCh chan struct{}
+}
+
+func (bs blockingSink) WriteEntry(e Entry) error {
+	<-bs.unblockCh
+	return nil
+}
+
+func TestTimeoutSink(t *T) {
+	bs := blockingSink{unblockCh: make(chan struct{})}
+	ts := NewTimeoutSink(bs, 10*time.Millisecond)
+	assert.True(t, ts.Healthy())
+
+	start := time.Now()
+	assert.Equal(t, ErrSinkTimeout, ts.WriteEntry(Entry{Msg: "foo"}))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.False(t, ts.Healthy())
+	assert.Equal(t, ErrSinkUnhealthy, ts.WriteEntry(Entry{Msg: "bar"}))
+
+	close(bs.unblockCh)
+	assert.Eventually(t, ts.Healthy, time.Second, time.Millisecond)
+	assert.NoError(t, ts.WriteEntry(Entry{Msg: "baz"}))
+	assert.True(t, ts.Healthy())
+
+	fs := &flakySink{fails: 1}
+	ts = NewTimeoutSink(fs, time.Second)
+	assert.EqualError(t, ts.WriteEntry(Entry{Msg: "foo"}), "unavailable")
+	assert.True(t, ts.Healthy())
+}
+
+func TestTimeoutSinkFallback(t *T) {
+	buf := new(bytes.Buffer)
+	var errs []error
+	l := New(
+		WithOutput(buf),
+		WithSynchronous(true),
+		WithWriteErrorHandler(func(err error, e Entry) { errs = append(errs, err) }),
+	)
+	defer l.Close()
+
+	bs := blockingSink{unblockCh: make(chan struct{})}
+	defer close(bs.unblockCh)
+	ts := NewTimeoutSink(bs, time.Millisecond)
+	l.getCore().writeSinks(Entry{Msg: "foo"}, []Sink{ts})
+	l.getCore().writeSinks(Entry{Msg: "bar"}, []Sink{ts})
+	require.Len(t, errs, 2)
+	assert.ErrorIs(t, errs[0], ErrSinkTimeout)
+	assert.ErrorIs(t, errs[1], ErrSinkUnhealthy)
+}
+
+func TestOutputConfigTimeout(t *T) {
+	s, err := OutputConfig{Type: "stdout", Timeout: "5s"}.sink(nil, false)
+	require.NoError(t, err)
+	require.IsType(t, &TimeoutSink{}, s)
+	assert.Equal(t, 5*time.Second, s.(*TimeoutSink).timeout)
+
+	_, err = OutputConfig{Type: "stdout", Timeout: "soon"}.sink(nil, false)
+	assert.ErrorContains(t, err, "invalid timeout")
+}
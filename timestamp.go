@@ -0,0 +1,86 @@
+package llog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// timestampCache formats timestamps using a layout which has been split around
+// its fractional seconds. The parts either side of the fraction are only
+// formatted when the second (or location) changes, so for most entries only
+// the fraction needs formatting.
+type timestampCache struct {
+	before, after string // the layouts either side of the fractional seconds
+	digits        int    // the number of fractional second digits
+	trim          bool   // whether trailing zeros are trimmed, like .999
+
+	second atomic.Value // *renderedSecond
+}
+
+type renderedSecond struct {
+	unix          int64
+	loc           *time.Location
+	before, after []byte
+}
+
+// appendTimestamp appends the time to buf, the same as t.AppendFormat would
+// with the layout the timestampCache was created from
+func (tc *timestampCache) appendTimestamp(buf []byte, t time.Time) []byte {
+	unix, loc := t.Unix(), t.Location()
+	rs, _ := tc.second.Load().(*renderedSecond)
+	if rs == nil || rs.unix != unix || rs.loc != loc {
+		rs = &renderedSecond{
+			unix:   unix,
+			loc:    loc,
+			before: t.AppendFormat(nil, tc.before),
+			after:  t.AppendFormat(nil, tc.after),
+		}
+		tc.second.Store(rs)
+	}
+	buf = append(buf, rs.before...)
+	buf = appendFraction(buf, t.Nanosecond(), tc.digits, tc.trim)
+	return append(buf, rs.after...)
+}
+
+// appendFraction appends the nanoseconds as a decimal fraction of a second,
+// with a leading dot
+func appendFraction(buf []byte, ns, digits int, trim bool) []byte {
+	for i := digits; i < 9; i++ {
+		ns /= 10
+	}
+	if trim {
+		for digits > 0 && ns%10 == 0 {
+			ns /= 10
+			digits--
+		}
+		if digits == 0 {
+			return buf
+		}
+	}
+	buf = append(buf, '.')
+	start := len(buf)
+	for i := 0; i < digits; i++ {
+		buf = append(buf, '0')
+	}
+	for i := len(buf) - 1; i >= start; i-- {
+		buf[i] = byte('0' + ns%10)
+		ns /= 10
+	}
+	return buf
+}
+
+// textTimestamps formats timestamps the same as time.Time's String method,
+// without the monotonic clock reading
+var textTimestamps = &timestampCache{
+	before: "2006-01-02 15:04:05",
+	digits: 9,
+	trim:   true,
+	after:  " -0700 MST",
+}
+
+// jsonTimestamps formats timestamps using timeFormatJSON
+var jsonTimestamps = &timestampCache{
+	before: "2006-01-02T15:04:05",
+	digits: 6,
+	after:  "Z07:00",
+}
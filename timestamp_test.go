@@ -0,0 +1,25 @@
+package llog
+
+import (
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestampCache(t *T) {
+	est := time.FixedZone("EST", -5*60*60)
+	base := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	var times []time.Time
+	for _, ns := range []int{0, 1, 10, 100000, 123456789, 999999999, 500000000} {
+		times = append(times, base.Add(time.Duration(ns)))
+	}
+	// change the second, and the location, part way through
+	times = append(times, base.Add(time.Second), base.Add(time.Second+5), base.In(est), base.In(est).Add(time.Hour+1))
+	times = append(times, time.Now(), time.Now().Add(time.Millisecond))
+
+	for _, tt := range times {
+		assert.Equal(t, tt.Round(0).String(), string(textTimestamps.appendTimestamp(nil, tt)))
+		assert.Equal(t, tt.Format(timeFormatJSON), string(jsonTimestamps.appendTimestamp(nil, tt)))
+	}
+}
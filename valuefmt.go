@@ -0,0 +1,116 @@
+package llog
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// DurationFormat describes how time.Duration values in an entry's KV are
+// written
+type DurationFormat int
+
+// All the possible DurationFormats
+const (
+	// DurationDefault writes durations as a string (e.g. "1.5s") in text, and
+	// as an integer number of nanoseconds in JSON
+	DurationDefault DurationFormat = iota
+
+	// DurationString writes durations as a string (e.g. "1.5s") in both text
+	// and JSON
+	DurationString
+
+	// DurationMillis writes durations as a number of milliseconds, which may
+	// be fractional (e.g. 1500)
+	DurationMillis
+
+	// DurationSeconds writes durations as a number of seconds, which may be
+	// fractional (e.g. 1.5)
+	DurationSeconds
+)
+
+type valueFormats struct {
+	duration   DurationFormat
+	timeLayout string
+}
+
+// formats holds a *valueFormats, or nil if neither has been set
+var formats atomic.Value
+
+func getValueFormats() valueFormats {
+	if vf, _ := formats.Load().(*valueFormats); vf != nil {
+		return *vf
+	}
+	return valueFormats{}
+}
+
+// SetDurationFormat sets how time.Duration values in entries' KV are written by
+// the TextFormatter and JSONFormatter, see DurationFormat
+func SetDurationFormat(f DurationFormat) {
+	vf := getValueFormats()
+	vf.duration = f
+	formats.Store(&vf)
+}
+
+// SetTimeFormat sets the layout (see time.Time's Format) which time.Time values
+// in entries' KV are written with by the TextFormatter and JSONFormatter, e.g.
+// time.RFC3339Nano for ISO 8601. An empty layout restores the default, which is
+// the same as time.Time's String in text and time.RFC3339Nano in JSON. It
+// doesn't affect entries' own timestamps.
+func SetTimeFormat(layout string) {
+	vf := getValueFormats()
+	vf.timeLayout = layout
+	formats.Store(&vf)
+}
+
+// appendTextDuration appends the duration as a quoted string according to the
+// DurationFormat
+func appendTextDuration(buf []byte, d time.Duration, ascii bool) []byte {
+	switch getValueFormats().duration {
+	case DurationMillis:
+		buf = append(buf, '"')
+		buf = strconv.AppendFloat(buf, float64(d)/float64(time.Millisecond), 'f', -1, 64)
+		return append(buf, '"')
+	case DurationSeconds:
+		buf = append(buf, '"')
+		buf = strconv.AppendFloat(buf, d.Seconds(), 'f', -1, 64)
+		return append(buf, '"')
+	}
+	return appendQuote(buf, d.String(), ascii)
+}
+
+// appendJSONDuration appends the duration as JSON according to the
+// DurationFormat
+func appendJSONDuration(buf []byte, d time.Duration) []byte {
+	switch getValueFormats().duration {
+	case DurationString:
+		return appendJSONString(buf, d.String())
+	case DurationMillis:
+		return appendJSONFloat(buf, float64(d)/float64(time.Millisecond), 64)
+	case DurationSeconds:
+		return appendJSONFloat(buf, d.Seconds(), 64)
+	}
+	return strconv.AppendInt(buf, int64(d), 10)
+}
+
+// formatValue returns the value as it should be written if it's a duration or
+// time which is affected by the configured formats, or the value itself
+// otherwise. It's used for values nested within others.
+func formatValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case time.Duration:
+		switch getValueFormats().duration {
+		case DurationString:
+			return vv.String()
+		case DurationMillis:
+			return float64(vv) / float64(time.Millisecond)
+		case DurationSeconds:
+			return vv.Seconds()
+		}
+	case time.Time:
+		if layout := getValueFormats().timeLayout; layout != "" {
+			return vv.Format(layout)
+		}
+	}
+	return v
+}
@@ -0,0 +1,54 @@
+package llog
+
+import (
+	"bytes"
+	. "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueFormats(t *T) {
+	defer SetDurationFormat(DurationDefault)
+	defer SetTimeFormat("")
+
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	e := Entry{Level: InfoLevel, Msg: "foo", KV: KV{
+		"d":      1500 * time.Millisecond,
+		"t":      ts,
+		"nested": KV{"d": 2 * time.Second},
+	}}
+	assertFormat := func(text, json string) {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, TextFormatter{}.Format(buf, e, false))
+		assert.Equal(t, "~ INFO -- foo -- "+text+"\n", buf.String())
+		buf.Reset()
+		assert.NoError(t, JSONFormatter{}.Format(buf, e, false))
+		assert.Equal(t, `{"level":"INFO","msg":"foo",`+json+"}\n", buf.String())
+	}
+
+	assertFormat(
+		`d="1.5s" nested.d="2s" t="2020-01-02 03:04:05 +0000 UTC"`,
+		`"d":1500000000,"nested":{"d":2000000000},"t":"2020-01-02T03:04:05Z"`,
+	)
+
+	SetDurationFormat(DurationString)
+	SetTimeFormat(time.RFC3339Nano)
+	assertFormat(
+		`d="1.5s" nested.d="2s" t="2020-01-02T03:04:05Z"`,
+		`"d":"1.5s","nested":{"d":"2s"},"t":"2020-01-02T03:04:05Z"`,
+	)
+
+	SetDurationFormat(DurationMillis)
+	SetTimeFormat(time.Kitchen)
+	assertFormat(
+		`d="1500" nested.d="2000" t="3:04AM"`,
+		`"d":1500,"nested":{"d":2000},"t":"3:04AM"`,
+	)
+
+	SetDurationFormat(DurationSeconds)
+	assertFormat(
+		`d="1.5" nested.d="2" t="3:04AM"`,
+		`"d":1.5,"nested":{"d":2},"t":"3:04AM"`,
+	)
+}
@@ -0,0 +1,99 @@
+package llog
+
+import (
+	"errors"
+	"reflect"
+)
+
+// WriteError is the error passed to the write error handler when an entry
+// couldn't be written
+type WriteError struct {
+	// Sink is the Sink which couldn't be written to, or nil if it was Out (or
+	// the Logger's output) which couldn't be written to
+	Sink Sink
+
+	Err error
+}
+
+// Error implements the error interface
+func (we *WriteError) Error() string {
+	if we.Sink == nil {
+		return "could not write to Out: " + we.Err.Error()
+	}
+	return "could not write to Sink: " + we.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (we *WriteError) Unwrap() error {
+	return we.Err
+}
+
+// OnWriteError sets the function which is called whenever an entry can't be
+// written to Out or to a Sink, with a *WriteError and the entry. It's called
+// from the same go-routine which writes entries, so it shouldn't block, and
+// it mustn't log using the package-level functions. Setting it to nil restores
+// the default, WriteErrorToStdout. IgnoreWriteErrors can be used to do
+// nothing.
+func OnWriteError(fn func(err error, e Entry)) {
+	fn = writeErrorHandler(fn)
+	globalCore.apply(func() { globalCore.onWriteError = fn })
+}
+
+// WriteErrorToStdout is the default write error handler. It writes an ERROR
+// entry describing the error to Stdout, followed by the entry itself, using
+// OutFormatter, or the Formatter of the Logger it's the handler of (see
+// WithWriteErrorHandler). If it was Stdout which couldn't be written to it does
+// nothing.
+func WriteErrorToStdout(err error, e Entry) {
+	globalCore.writeFallback(err, e)
+}
+
+// writeErrorHandler returns the handler to use for fn, where nil means the
+// core's own writeFallback. WriteErrorToStdout is replaced with nil, since it
+// can only use the global core's output, which a Logger created with New
+// mustn't read from its own go-routine.
+func writeErrorHandler(fn func(err error, e Entry)) func(err error, e Entry) {
+	if fn != nil && reflect.ValueOf(fn).Pointer() == reflect.ValueOf(WriteErrorToStdout).Pointer() {
+		return nil
+	}
+	return fn
+}
+
+// IgnoreWriteErrors is a write error handler which does nothing
+func IgnoreWriteErrors(err error, e Entry) {}
+
+func (c *core) writeError(err *WriteError, e Entry) {
+	c.countWriteError(err)
+	if c.onWriteError != nil {
+		c.onWriteError(err, e)
+		return
+	}
+	c.writeFallback(err, e)
+}
+
+// writeFallback writes an error to Stdout, then tries to write the original
+// entry there as well. Shouldn't be called outside the main loop.
+func (c *core) writeFallback(err error, e Entry) {
+	out, f, ts := c.output()
+	var msg string
+	var we *WriteError
+	if errors.As(err, &we) {
+		if we.Sink == nil && out == defaultOut {
+			return
+		}
+		msg, err = "Could not write to error Out", we.Err
+		if we.Sink != nil {
+			msg = "Could not write to Sink"
+		}
+	} else {
+		msg = "Could not write entry"
+	}
+	erre := Entry{
+		Level: ErrorLevel,
+		Time:  c.now(),
+		Msg:   msg,
+		KV:    ErrKV(err),
+	}
+	safeFormat(defaultOut, f, erre, ts)
+	safeFormat(defaultOut, f, e, ts)
+}
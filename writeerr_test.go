@@ -0,0 +1,84 @@
+package llog
+
+import (
+	"bytes"
+	"errors"
+	. "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnWriteError(t *T) {
+	oldOut, oldDefaultOut := Out, defaultOut
+	defer func() {
+		SetOutput(oldOut)
+		defaultOut = oldDefaultOut
+		OnWriteError(nil)
+		SetSinks()
+	}()
+	fallbackBuf := new(bytes.Buffer)
+	defaultOut = fallbackBuf
+	SetOutput(errWriter{})
+
+	// the default writes to Stdout
+	Info("foo")
+	Flush()
+	assert.Equal(t, "~ ERROR -- Could not write to error Out -- err=\"can't write\"\n~ INFO -- foo\n", fallbackBuf.String())
+
+	var errs []error
+	var msgs []string
+	OnWriteError(func(err error, e Entry) {
+		errs = append(errs, err)
+		msgs = append(msgs, e.Msg)
+	})
+	s := WriterSink{Writer: errWriter{}}
+	AddSink(s)
+	fallbackBuf.Reset()
+	Info("bar")
+	Flush()
+	assert.Empty(t, fallbackBuf.String())
+	assert.Equal(t, []string{"bar", "bar"}, msgs)
+	require.Len(t, errs, 2)
+	var we *WriteError
+	require.True(t, errors.As(errs[0], &we))
+	assert.Nil(t, we.Sink)
+	assert.EqualError(t, errs[0], "could not write to Out: can't write")
+	require.True(t, errors.As(errs[1], &we))
+	assert.Equal(t, s, we.Sink)
+	assert.EqualError(t, errs[1], "could not write to Sink: can't write")
+
+	OnWriteError(IgnoreWriteErrors)
+	Info("baz")
+	Flush()
+	assert.Empty(t, fallbackBuf.String())
+}
+
+func TestWithWriteErrorHandler(t *T) {
+	var msgs []string
+	l := New(
+		WithOutput(errWriter{}),
+		WithWriteErrorHandler(func(err error, e Entry) { msgs = append(msgs, e.Msg) }),
+	)
+	l.Info("foo")
+	l.Flush()
+	assert.Equal(t, []string{"foo"}, msgs)
+}
+
+func TestWithWriteErrorHandlerStdout(t *T) {
+	oldDefaultOut := defaultOut
+	defer func() { defaultOut = oldDefaultOut }()
+	fallbackBuf := new(bytes.Buffer)
+	defaultOut = fallbackBuf
+
+	// the Logger's own Formatter is used, rather than OutFormatter
+	l := New(
+		WithOutput(errWriter{}),
+		WithFormatter(JSONFormatter{}),
+		WithWriteErrorHandler(WriteErrorToStdout),
+	)
+	l.Info("foo")
+	l.Flush()
+	assert.Equal(t, `{"level":"ERROR","msg":"Could not write to error Out","err":"can't write"}`+"\n"+
+		`{"level":"INFO","msg":"foo"}`+"\n", fallbackBuf.String())
+}